@@ -1,10 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
-
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/admin"
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/neurons/internal/budget"
 	"github.com/Abraxas-365/neurons/internal/classroom"
+	"github.com/Abraxas-365/neurons/internal/idempotency"
+	"github.com/Abraxas-365/neurons/internal/organization"
 	"github.com/Abraxas-365/neurons/internal/user"
 	"github.com/Abraxas-365/toolkit/pkg/errors"
 	"github.com/Abraxas-365/toolkit/pkg/lucia"
@@ -40,18 +51,33 @@ func main() {
 
 	// Initialize repositories
 	userRepo := user.NewPostgresRepository(db)
+	organizationRepo := organization.NewPostgresRepository(db)
 	classroomRepo := classroom.NewPostgresRepository(db)
+	idempotencyRepo := idempotency.NewPostgresRepository(db)
+	auditRepo := audit.NewPostgresRepository(db)
+	budgetRepo := budget.NewPostgresRepository(db)
 
 	// Initialize services
+	auditService := audit.NewService(auditRepo)
 	userService := user.NewService(userRepo)
-	classroomService := classroom.NewService(userService, classroomRepo)
+	organizationService := organization.NewService(organizationRepo, auditService)
+	budgetService := budget.NewService(auditService, budgetRepo)
+	classroomService := classroom.NewService(userService, organizationService, budgetService, auditService, classroomRepo)
+	idempotencyService := idempotency.NewService(idempotencyRepo)
+	adminService := admin.NewService(userService, organizationService, classroomService, budgetService, auditService)
 
 	luciaRepo := lucia.NewPostgresRepository(db)
 	luciaService := lucia.NewService(luciaRepo)
 
+	// Initialize authorization policy
+	policy := authz.NewPolicy(userService, classroomRepo)
+
 	// Initialize handlers
-	classroomHandler := classroom.NewHandler(classroomService)
+	classroomHandler := classroom.NewHandler(classroomService, policy, idempotencyService)
 	userHandler := user.NewHandler(userService)
+	organizationHandler := organization.NewHandler(organizationService, policy)
+	adminHandler := admin.NewHandler(adminService, policy)
+	budgetHandler := budget.NewHandler(budgetService, policy)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -62,25 +88,81 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:5173", // Update this to match your SvelteKit dev server
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,Idempotency-Key,X-Request-ID",
 		AllowCredentials: true,
 	}))
 
 	// Use middlewares
 	app.Use(recover.New())
 	app.Use(logger.New())
+	app.Use(audit.Middleware())
 
 	// Set up authentication middleware
 	app.Use(lucia.SessionMiddleware(luciaService))
 
+	// Background jobs and the server stop on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var jobs sync.WaitGroup
+
+	// Release the holds of redemption requests nobody decided in time
+	runEvery(ctx, &jobs, time.Minute, "redemption request expiry", classroomService.ExpireRedemptionRequests)
+	// Pay the scheduled allowances of every classroom
+	runEvery(ctx, &jobs, time.Minute, "allowances", classroomService.RunAllowances)
+	// Return expired neurons to their classroom pools
+	runEvery(ctx, &jobs, time.Minute, "neuron expiry", classroomService.ExpireNeurons)
+	// Mint the periodic allocations of teacher and organization budgets
+	runEvery(ctx, &jobs, time.Minute, "budget allocations", budgetService.RunAllocations)
+
 	classroomHandler.RegisterRoutes(app)
 	userHandler.RegisterRoutes(app)
+	organizationHandler.RegisterRoutes(app)
+	adminHandler.RegisterRoutes(app)
+	budgetHandler.RegisterRoutes(app)
 
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
+	go func() {
+		<-ctx.Done()
+		log.Printf("Shutting down")
+		if err := app.Shutdown(); err != nil {
+			log.Printf("Failed to shut down server: %v", err)
+		}
+	}()
+
 	log.Printf("Server starting on port %s", port)
-	log.Fatal(app.Listen(":" + port))
+	if err := app.Listen(":" + port); err != nil {
+		log.Fatal(err)
+	}
+	jobs.Wait()
+}
+
+// runEvery runs job at every interval until ctx is done, logging what it did.
+// A run that is already going when ctx is done is waited for through wg.
+func runEvery(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, name string, job func(ctx context.Context) (int, error)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			n, err := job(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Failed to run %s: %v", name, err)
+				}
+			} else if n > 0 {
+				log.Printf("Ran %s: %d processed", name, n)
+			}
+		}
+	}()
 }
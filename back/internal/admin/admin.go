@@ -0,0 +1,19 @@
+package admin
+
+import "github.com/Abraxas-365/neurons/internal/classroom"
+
+// Audit log actions performed by admins
+const (
+	ActionUserRoleChanged     = "user.role_changed"
+	ActionUserDeactivated     = "user.deactivated"
+	ActionUserReactivated     = "user.reactivated"
+	ActionClassroomReassigned = "classroom.reassigned"
+	ActionOrganizationCreated = "organization.created"
+)
+
+// Stats summarizes the whole deployment
+type Stats struct {
+	// Users counts users by role
+	Users   map[string]int64       `json:"users"`
+	Neurons *classroom.NeuronStats `json:"neurons"`
+}
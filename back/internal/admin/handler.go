@@ -0,0 +1,289 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/neurons/internal/budget"
+	"github.com/Abraxas-365/neurons/internal/organization"
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service Servicer
+	policy  *authz.Policy
+}
+
+func NewHandler(service Servicer, policy *authz.Policy) *Handler {
+	return &Handler{
+		service: service,
+		policy:  policy,
+	}
+}
+
+func (h *Handler) RegisterRoutes(app *fiber.App) {
+	adminGroup := app.Group("/admin")
+
+	// Every route requires the admin role
+	adminGroup.Use(h.policy.Authenticate, authz.Require(authz.HasRole("admin")))
+	adminGroup.Get("/users", h.SearchUsers)
+	adminGroup.Put("/users/:userId/role", h.ChangeUserRole)
+	adminGroup.Post("/users/:userId/deactivate", h.DeactivateUser)
+	adminGroup.Post("/users/:userId/reactivate", h.ReactivateUser)
+	adminGroup.Put("/classrooms/:id/teacher", h.ReassignClassroom)
+	adminGroup.Delete("/classrooms/:id", h.PurgeClassroom)
+	adminGroup.Get("/organizations", h.ListOrganizations)
+	adminGroup.Post("/organizations", h.CreateOrganization)
+	adminGroup.Get("/organizations/:orgId/budgets", h.ListBudgets)
+	adminGroup.Put("/organizations/:orgId/budget", h.SetOrganizationAllocation)
+	adminGroup.Put("/users/:userId/budget", h.SetTeacherAllocation)
+	adminGroup.Post("/budgets/:budgetId/mint", h.MintBudget)
+	adminGroup.Get("/stats", h.GetStats)
+	adminGroup.Get("/audit", h.ListAuditLog)
+}
+
+func (h *Handler) SearchUsers(c *fiber.Ctx) error {
+	filter := user.Filter{
+		Query: c.Query("q"),
+		Role:  c.Query("role"),
+	}
+	if value := c.Query("organization_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.ErrBadRequest("invalid organization_id")
+		}
+		filter.OrganizationID = &id
+	}
+	if value := c.Query("deactivated"); value != "" {
+		deactivated, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.ErrBadRequest("invalid deactivated")
+		}
+		filter.Deactivated = &deactivated
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit", "50"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset", "0"))
+
+	users, err := h.service.SearchUsers(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(users)
+}
+
+func (h *Handler) ChangeUserRole(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid user id")
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	changed, err := h.service.ChangeUserRole(c.Context(), u.ID, userID, input.Role)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(changed)
+}
+
+func (h *Handler) DeactivateUser(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid user id")
+	}
+
+	deactivated, err := h.service.DeactivateUser(c.Context(), u.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(deactivated)
+}
+
+func (h *Handler) ReactivateUser(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid user id")
+	}
+
+	reactivated, err := h.service.ReactivateUser(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reactivated)
+}
+
+func (h *Handler) ReassignClassroom(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		TeacherID int64 `json:"teacher_id"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	classroom, err := h.service.ReassignClassroom(c.Context(), classroomID, input.TeacherID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(classroom)
+}
+
+func (h *Handler) PurgeClassroom(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	err = h.service.PurgeClassroom(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) ListOrganizations(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	orgs, err := h.service.ListOrganizations(c.Context(), limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(orgs)
+}
+
+func (h *Handler) CreateOrganization(c *fiber.Ctx) error {
+	var input organization.Settings
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	org, err := h.service.CreateOrganization(c.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(org)
+}
+
+func (h *Handler) ListBudgets(c *fiber.Ctx) error {
+	orgID, err := strconv.ParseInt(c.Params("orgId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid organization id")
+	}
+
+	budgets, err := h.service.ListBudgets(c.Context(), orgID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(budgets)
+}
+
+func (h *Handler) SetOrganizationAllocation(c *fiber.Ctx) error {
+	orgID, err := strconv.ParseInt(c.Params("orgId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid organization id")
+	}
+
+	var input budget.Allocation
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	b, err := h.service.SetOrganizationAllocation(c.Context(), orgID, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(b)
+}
+
+func (h *Handler) SetTeacherAllocation(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid user id")
+	}
+
+	var input budget.Allocation
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	b, err := h.service.SetTeacherAllocation(c.Context(), userID, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(b)
+}
+
+func (h *Handler) MintBudget(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	budgetID, err := strconv.ParseInt(c.Params("budgetId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid budget id")
+	}
+
+	var input struct {
+		Amount int    `json:"amount"`
+		Note   string `json:"note"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	transaction, err := h.service.MintBudget(c.Context(), u.ID, budgetID, input.Amount, input.Note)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(transaction)
+}
+
+func (h *Handler) GetStats(c *fiber.Ctx) error {
+	stats, err := h.service.GetStats(c.Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(stats)
+}
+
+func (h *Handler) ListAuditLog(c *fiber.Ctx) error {
+	filter, err := audit.ParseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.service.ListAuditLog(c.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(entries)
+}
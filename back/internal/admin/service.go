@@ -0,0 +1,253 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/neurons/internal/budget"
+	"github.com/Abraxas-365/neurons/internal/classroom"
+	"github.com/Abraxas-365/neurons/internal/organization"
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Servicer defines the interface for administration operations. Every
+// change is written to the audit log.
+type Servicer interface {
+	SearchUsers(ctx context.Context, filter user.Filter) ([]*user.User, error)
+	ChangeUserRole(ctx context.Context, adminID, userID int64, role string) (*user.User, error)
+	DeactivateUser(ctx context.Context, adminID, userID int64) (*user.User, error)
+	ReactivateUser(ctx context.Context, userID int64) (*user.User, error)
+	ReassignClassroom(ctx context.Context, classroomID, teacherID int64) (*classroom.ClassroomWithData, error)
+	PurgeClassroom(ctx context.Context, classroomID int64) error
+	ListOrganizations(ctx context.Context, limit, offset int) ([]*organization.Organization, error)
+	CreateOrganization(ctx context.Context, settings organization.Settings) (*organization.Organization, error)
+	ListBudgets(ctx context.Context, organizationID int64) ([]*budget.Budget, error)
+	SetOrganizationAllocation(ctx context.Context, organizationID int64, allocation budget.Allocation) (*budget.Budget, error)
+	SetTeacherAllocation(ctx context.Context, teacherID int64, allocation budget.Allocation) (*budget.Budget, error)
+	MintBudget(ctx context.Context, adminID, budgetID int64, amount int, note string) (*budget.Transaction, error)
+	GetStats(ctx context.Context) (*Stats, error)
+	ListAuditLog(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error)
+}
+
+// Ensure Service implements Servicer
+var _ Servicer = (*Service)(nil)
+
+// Service implements the Servicer interface
+type Service struct {
+	userService         user.Servicer
+	organizationService organization.Servicer
+	classroomService    classroom.Servicer
+	budgetService       budget.Servicer
+	auditService        audit.Servicer
+}
+
+// NewService creates a new admin service
+func NewService(userService user.Servicer, organizationService organization.Servicer, classroomService classroom.Servicer, budgetService budget.Servicer, auditService audit.Servicer) *Service {
+	return &Service{
+		userService:         userService,
+		organizationService: organizationService,
+		classroomService:    classroomService,
+		budgetService:       budgetService,
+		auditService:        auditService,
+	}
+}
+
+// SearchUsers finds users across every organization
+func (s *Service) SearchUsers(ctx context.Context, filter user.Filter) ([]*user.User, error) {
+	return s.userService.SearchUsers(ctx, filter)
+}
+
+// ChangeUserRole changes a user's global role
+func (s *Service) ChangeUserRole(ctx context.Context, adminID, userID int64, role string) (*user.User, error) {
+	if adminID == userID {
+		return nil, errors.ErrBadRequest("you cannot change your own role")
+	}
+
+	var changed *user.User
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.userService.GetUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.userService.ChangeUserRole(ctx, userID, role)
+		if err != nil {
+			return nil, err
+		}
+
+		changed, err = s.userService.GetUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: ActionUserRoleChanged, TargetType: "user", TargetID: userID, Before: before, After: changed}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// DeactivateUser blocks a user from signing in
+func (s *Service) DeactivateUser(ctx context.Context, adminID, userID int64) (*user.User, error) {
+	if adminID == userID {
+		return nil, errors.ErrBadRequest("you cannot deactivate yourself")
+	}
+
+	return s.setUserActive(ctx, userID, false)
+}
+
+// ReactivateUser lets a deactivated user sign in again
+func (s *Service) ReactivateUser(ctx context.Context, userID int64) (*user.User, error) {
+	return s.setUserActive(ctx, userID, true)
+}
+
+func (s *Service) setUserActive(ctx context.Context, userID int64, active bool) (*user.User, error) {
+	var changed *user.User
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.userService.GetUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		action := ActionUserDeactivated
+		if active {
+			action = ActionUserReactivated
+			err = s.userService.ReactivateUser(ctx, userID)
+		} else {
+			err = s.userService.DeactivateUser(ctx, userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		changed, err = s.userService.GetUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: action, TargetType: "user", TargetID: userID, Before: before, After: changed}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// ReassignClassroom makes another teacher the owner of a classroom
+func (s *Service) ReassignClassroom(ctx context.Context, classroomID, teacherID int64) (*classroom.ClassroomWithData, error) {
+	var reassigned *classroom.ClassroomWithData
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		current, err := s.classroomService.GetClassroom(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		reassigned, err = s.classroomService.ReassignClassroom(ctx, classroomID, teacherID)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{
+			ClassroomID: &classroomID,
+			Action:      ActionClassroomReassigned,
+			TargetType:  "classroom",
+			TargetID:    classroomID,
+			Before:      current.Classroom,
+			After:       reassigned.Classroom,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reassigned, nil
+}
+
+// PurgeClassroom permanently deletes a classroom archived for longer than
+// classroom.PurgeRetention. Its audit trail is kept.
+func (s *Service) PurgeClassroom(ctx context.Context, classroomID int64) error {
+	return s.classroomService.PurgeClassroom(ctx, classroomID)
+}
+
+// ListOrganizations retrieves every organization
+func (s *Service) ListOrganizations(ctx context.Context, limit, offset int) ([]*organization.Organization, error) {
+	return s.organizationService.ListOrganizations(ctx, limit, offset)
+}
+
+// CreateOrganization sets up a new school
+func (s *Service) CreateOrganization(ctx context.Context, settings organization.Settings) (*organization.Organization, error) {
+	var org *organization.Organization
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		var err error
+		org, err = s.organizationService.CreateOrganization(ctx, settings)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: ActionOrganizationCreated, TargetType: "organization", TargetID: org.ID, After: org}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// ListBudgets retrieves the budgets of an organization and its teachers
+func (s *Service) ListBudgets(ctx context.Context, organizationID int64) ([]*budget.Budget, error) {
+	return s.budgetService.ListBudgets(ctx, organizationID)
+}
+
+// SetOrganizationAllocation sets the periodic allocation of an organization's
+// budget, which its teachers without a budget of their own draw from
+func (s *Service) SetOrganizationAllocation(ctx context.Context, organizationID int64, allocation budget.Allocation) (*budget.Budget, error) {
+	org, err := s.organizationService.GetOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.budgetService.SetAllocation(ctx, org.ID, nil, allocation)
+}
+
+// SetTeacherAllocation sets the periodic allocation of a teacher's own budget
+func (s *Service) SetTeacherAllocation(ctx context.Context, teacherID int64, allocation budget.Allocation) (*budget.Budget, error) {
+	teacher, err := s.userService.GetUser(ctx, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if teacher.Role != "teacher" {
+		return nil, errors.ErrBadRequest("user is not a teacher")
+	}
+
+	return s.budgetService.SetAllocation(ctx, teacher.OrganizationID, &teacher.ID, allocation)
+}
+
+// MintBudget grants a budget neurons outside of its periodic allocation
+func (s *Service) MintBudget(ctx context.Context, adminID, budgetID int64, amount int, note string) (*budget.Transaction, error) {
+	return s.budgetService.Mint(ctx, adminID, budgetID, amount, note)
+}
+
+// GetStats summarizes users and neurons across the deployment
+func (s *Service) GetStats(ctx context.Context) (*Stats, error) {
+	stats := &Stats{Users: make(map[string]int64)}
+	for _, role := range []string{"teacher", "student", "admin"} {
+		count, err := s.userService.CountUsersByRole(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		stats.Users[role] = count
+	}
+
+	neurons, err := s.classroomService.GetNeuronStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.Neurons = neurons
+
+	return stats, nil
+}
+
+// ListAuditLog retrieves the audit trail
+func (s *Service) ListAuditLog(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	return s.auditService.List(ctx, filter)
+}
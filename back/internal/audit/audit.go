@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Entry is an append-only record of who did what to which entity
+type Entry struct {
+	ID          int64          `json:"id" db:"id"`
+	ActorID     *int64         `json:"actor_id,omitempty" db:"actor_id"`
+	Action      string         `json:"action" db:"action"`
+	TargetType  string         `json:"target_type" db:"target_type"`
+	TargetID    int64          `json:"target_id" db:"target_id"`
+	ClassroomID *int64         `json:"classroom_id,omitempty" db:"classroom_id"`
+	Before      types.JSONText `json:"before" db:"before"`
+	After       types.JSONText `json:"after" db:"after"`
+	Diff        types.JSONText `json:"diff" db:"diff"`
+	RequestID   *string        `json:"request_id,omitempty" db:"request_id"`
+	IP          *string        `json:"ip,omitempty" db:"ip"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Change describes a state change to record. Before and After are encoded
+// as JSON; either may be nil when an entity is created or removed.
+type Change struct {
+	ClassroomID *int64
+	Action      string
+	TargetType  string
+	TargetID    int64
+	Before      interface{}
+	After       interface{}
+}
+
+// FieldChange is a single top-level field that differs between the before
+// and after states of a change
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Filter narrows down audit log listings; zero values are ignored
+type Filter struct {
+	ActorID     *int64
+	ClassroomID *int64
+	Action      string
+	TargetType  string
+	TargetID    *int64
+	Limit       int
+	Offset      int
+}
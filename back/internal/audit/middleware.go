@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const requestLocalKey = "audit.request"
+
+// Request identifies the HTTP request a change was made in
+type Request struct {
+	ID string
+	IP string
+}
+
+// Middleware tags every request with an ID, taken from the X-Request-ID
+// header when the client sends one, so that audit entries can be traced back
+// to it. The ID is echoed in the response.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if id == "" {
+			id = utils.UUIDv4()
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+		c.Locals(requestLocalKey, &Request{ID: id, IP: c.IP()})
+		return c.Next()
+	}
+}
+
+// requestFromContext returns the request tagged by Middleware, or nil for
+// changes made outside of a request, such as background jobs
+func requestFromContext(ctx context.Context) *Request {
+	request, _ := ctx.Value(requestLocalKey).(*Request)
+	return request
+}
+
+// ParseFilter reads an audit log filter from the query string
+func ParseFilter(c *fiber.Ctx) (Filter, error) {
+	filter := Filter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+	ids := map[string]**int64{
+		"actor_id":     &filter.ActorID,
+		"classroom_id": &filter.ClassroomID,
+		"target_id":    &filter.TargetID,
+	}
+	for name, dest := range ids {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return filter, errors.ErrBadRequest("invalid " + name)
+		}
+		*dest = &id
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit", "50"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset", "0"))
+	return filter, nil
+}
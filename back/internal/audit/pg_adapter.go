@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+type PostgresRepository struct {
+	db *database.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: database.New(db)}
+}
+
+func (r *PostgresRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.WithTx(ctx, fn)
+}
+
+func (r *PostgresRepository) Record(ctx context.Context, entry *Entry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_type, target_id, classroom_id, before, after, diff, request_id, ip, created_at)
+		VALUES (:actor_id, :action, :target_type, :target_id, :classroom_id, :before, :after, :diff, :request_id, :ip, :created_at)
+		RETURNING id
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, entry)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to record audit entry: %v", err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&entry.ID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to scan audit entry ID: %v", err))
+		}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) List(ctx context.Context, filter Filter) ([]*Entry, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorID != nil {
+		addCondition("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.ClassroomID != nil {
+		addCondition("classroom_id = $%d", *filter.ClassroomID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		addCondition("target_id = $%d", *filter.TargetID)
+	}
+
+	query := "SELECT id, actor_id, action, target_type, target_id, classroom_id, before, after, diff, request_id, ip, created_at FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	entries := []*Entry{}
+	err := r.db.SelectContext(ctx, &entries, query, args...)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list audit entries: %v", err))
+	}
+	return entries, nil
+}
@@ -0,0 +1,18 @@
+package audit
+
+import (
+	"context"
+)
+
+// DBRepository defines the interface for audit log database operations
+type DBRepository interface {
+	// WithTx runs fn in a transaction shared by every repository that is
+	// passed its context
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Record appends an entry to the audit log
+	Record(ctx context.Context, entry *Entry) error
+
+	// List retrieves audit log entries, newest first
+	List(ctx context.Context, filter Filter) ([]*Entry, error)
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Servicer defines the interface for audit log operations
+type Servicer interface {
+	Record(ctx context.Context, change Change) error
+	Track(ctx context.Context, fn func(ctx context.Context) (*Change, error)) error
+	List(ctx context.Context, filter Filter) ([]*Entry, error)
+}
+
+// Ensure Service implements Servicer
+var _ Servicer = (*Service)(nil)
+
+// Service implements the Servicer interface
+type Service struct {
+	repo DBRepository
+}
+
+// NewService creates a new audit service
+func NewService(repo DBRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record appends a change to the audit log. The actor and request are taken
+// from the context. Called inside Track, the entry is written in the same
+// transaction as the change.
+func (s *Service) Record(ctx context.Context, change Change) error {
+	before, err := json.Marshal(change.Before)
+	if err != nil {
+		return errors.ErrUnexpected("failed to encode audit state")
+	}
+	after, err := json.Marshal(change.After)
+	if err != nil {
+		return errors.ErrUnexpected("failed to encode audit state")
+	}
+	diff, err := diffStates(before, after)
+	if err != nil {
+		return errors.ErrUnexpected("failed to compute audit diff")
+	}
+
+	entry := &Entry{
+		Action:      change.Action,
+		TargetType:  change.TargetType,
+		TargetID:    change.TargetID,
+		ClassroomID: change.ClassroomID,
+		Before:      before,
+		After:       after,
+		Diff:        diff,
+		CreatedAt:   time.Now(),
+	}
+	if actor := authz.UserFromContext(ctx); actor != nil {
+		entry.ActorID = &actor.ID
+	}
+	if request := requestFromContext(ctx); request != nil {
+		entry.RequestID = &request.ID
+		entry.IP = &request.IP
+	}
+
+	return s.repo.Record(ctx, entry)
+}
+
+// Track runs fn in a database transaction and records the change it reports
+// in that same transaction, so a change is never made without its audit
+// entry. fn may return a nil change when there was nothing to record.
+func (s *Service) Track(ctx context.Context, fn func(ctx context.Context) (*Change, error)) error {
+	return s.repo.WithTx(ctx, func(ctx context.Context) error {
+		change, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		if change == nil {
+			return nil
+		}
+		return s.Record(ctx, *change)
+	})
+}
+
+// List retrieves audit log entries
+func (s *Service) List(ctx context.Context, filter Filter) ([]*Entry, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// diffStates lists the top-level fields that differ between two JSON
+// states. States that are not objects are compared as a whole.
+func diffStates(before, after []byte) ([]byte, error) {
+	var from, to interface{}
+	if err := json.Unmarshal(before, &from); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &to); err != nil {
+		return nil, err
+	}
+
+	fromFields, fromIsObject := from.(map[string]interface{})
+	toFields, toIsObject := to.(map[string]interface{})
+	if (!fromIsObject && from != nil) || (!toIsObject && to != nil) {
+		if reflect.DeepEqual(from, to) {
+			return json.Marshal(map[string]FieldChange{})
+		}
+		return json.Marshal(map[string]FieldChange{"value": {From: from, To: to}})
+	}
+
+	diff := make(map[string]FieldChange)
+	for key, value := range fromFields {
+		if !reflect.DeepEqual(value, toFields[key]) {
+			diff[key] = FieldChange{From: value, To: toFields[key]}
+		}
+	}
+	for key, value := range toFields {
+		if _, ok := fromFields[key]; !ok {
+			diff[key] = FieldChange{From: nil, To: value}
+		}
+	}
+	return json.Marshal(diff)
+}
@@ -0,0 +1,106 @@
+package authz
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+const userLocalKey = "authz.user"
+
+// Rule reports whether the current user may access the requested resource
+type Rule func(c *fiber.Ctx, u *user.User) (bool, error)
+
+// CurrentUser returns the user resolved by Policy.Authenticate
+func CurrentUser(c *fiber.Ctx) *user.User {
+	u, ok := c.Locals(userLocalKey).(*user.User)
+	if !ok {
+		return nil
+	}
+	return u
+}
+
+// UserFromContext returns the user resolved by Policy.Authenticate from the
+// context of the request, or nil outside of an authenticated request
+func UserFromContext(ctx context.Context) *user.User {
+	u, _ := ctx.Value(userLocalKey).(*user.User)
+	return u
+}
+
+// Require lets the request through if any of the rules grants access
+func Require(rules ...Rule) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		u := CurrentUser(c)
+		if u == nil {
+			return errors.ErrUnauthorized("No valid session found")
+		}
+
+		for _, rule := range rules {
+			allowed, err := rule(c, u)
+			if err != nil {
+				return err
+			}
+			if allowed {
+				return c.Next()
+			}
+		}
+		return errors.ErrForbidden("you are not allowed to perform this action")
+	}
+}
+
+// All grants access only if every rule does
+func All(rules ...Rule) Rule {
+	return func(c *fiber.Ctx, u *user.User) (bool, error) {
+		for _, rule := range rules {
+			allowed, err := rule(c, u)
+			if err != nil || !allowed {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// Authenticated grants access to any user with a profile
+func Authenticated(c *fiber.Ctx, u *user.User) (bool, error) {
+	return true, nil
+}
+
+// OrgAdmin grants access to the admins of the current user's organization
+func OrgAdmin(c *fiber.Ctx, u *user.User) (bool, error) {
+	return u.OrgAdmin, nil
+}
+
+// HasRole grants access to users with one of the roles
+func HasRole(roles ...string) Rule {
+	return func(c *fiber.Ctx, u *user.User) (bool, error) {
+		for _, role := range roles {
+			if u.Role == role {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// Self grants access when the route parameter is the current user's ID
+func Self(param string) Rule {
+	return func(c *fiber.Ctx, u *user.User) (bool, error) {
+		id, err := paramID(c, param)
+		if err != nil {
+			return false, err
+		}
+		return id == u.ID, nil
+	}
+}
+
+func paramID(c *fiber.Ctx, param string) (int64, error) {
+	id, err := strconv.ParseInt(c.Params(param), 10, 64)
+	if err != nil {
+		return 0, errors.ErrBadRequest("invalid " + param)
+	}
+	return id, nil
+}
@@ -0,0 +1,84 @@
+package authz
+
+import (
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/Abraxas-365/toolkit/pkg/lucia"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy resolves the current user and evaluates ownership-aware rules
+type Policy struct {
+	userService user.Servicer
+	classrooms  ClassroomStore
+}
+
+// NewPolicy creates a new authorization policy
+func NewPolicy(userService user.Servicer, classrooms ClassroomStore) *Policy {
+	return &Policy{
+		userService: userService,
+		classrooms:  classrooms,
+	}
+}
+
+// Authenticate requires a session and loads its user once for the rest of the request
+func (p *Policy) Authenticate(c *fiber.Ctx) error {
+	session := lucia.GetSession(c)
+	if session == nil {
+		return errors.ErrUnauthorized("No valid session found")
+	}
+
+	u, err := p.userService.GetUserByAuthUserID(c.Context(), session.UserID)
+	if err != nil {
+		return err
+	}
+	if u.DeactivatedAt != nil {
+		return errors.ErrForbidden("account is deactivated")
+	}
+	c.Locals(userLocalKey, u)
+
+	return c.Next()
+}
+
+// TeacherOwnsClassroom grants access to the teacher of the classroom in the route parameter
+func (p *Policy) TeacherOwnsClassroom(param string) Rule {
+	return func(c *fiber.Ctx, u *user.User) (bool, error) {
+		classroomID, err := paramID(c, param)
+		if err != nil {
+			return false, err
+		}
+		teacherID, err := p.classrooms.GetClassroomTeacherID(c.Context(), classroomID)
+		if err != nil {
+			return false, err
+		}
+		return teacherID == u.ID, nil
+	}
+}
+
+// StaffCan grants access to staff of the classroom in the route parameter whose role has the permission
+func (p *Policy) StaffCan(param, permission string) Rule {
+	return func(c *fiber.Ctx, u *user.User) (bool, error) {
+		if u.Role != "teacher" {
+			return false, nil
+		}
+		classroomID, err := paramID(c, param)
+		if err != nil {
+			return false, err
+		}
+		return p.classrooms.HasClassroomPermission(c.Context(), classroomID, u.ID, permission)
+	}
+}
+
+// StudentInClassroom grants access to students enrolled in the classroom in the route parameter
+func (p *Policy) StudentInClassroom(param string) Rule {
+	return func(c *fiber.Ctx, u *user.User) (bool, error) {
+		if u.Role != "student" {
+			return false, nil
+		}
+		classroomID, err := paramID(c, param)
+		if err != nil {
+			return false, err
+		}
+		return p.classrooms.IsStudentInClassroom(c.Context(), classroomID, u.ID)
+	}
+}
@@ -0,0 +1,18 @@
+package authz
+
+import (
+	"context"
+)
+
+// ClassroomStore exposes the classroom relationships access rules are evaluated against
+type ClassroomStore interface {
+	// GetClassroomTeacherID returns the ID of the teacher who owns the classroom
+	GetClassroomTeacherID(ctx context.Context, classroomID int64) (int64, error)
+
+	// IsStudentInClassroom checks whether the student is enrolled in the classroom
+	IsStudentInClassroom(ctx context.Context, classroomID, studentID int64) (bool, error)
+
+	// HasClassroomPermission checks whether the user is active staff of the classroom
+	// with a role that grants the permission
+	HasClassroomPermission(ctx context.Context, classroomID, userID int64, permission string) (bool, error)
+}
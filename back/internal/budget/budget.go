@@ -0,0 +1,100 @@
+package budget
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Errors returned when neurons can't be drawn from a budget
+var (
+	ErrInsufficientBudget = errors.ErrBadRequest("not enough neurons in the budget")
+	ErrNoBudget           = errors.ErrForbidden("no budget to allocate neurons from")
+)
+
+// Allocation periods
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+// Budget transaction types
+const (
+	// TransactionTypeMint creates neurons in a budget, from its periodic
+	// allocation or a one-off grant
+	TransactionTypeMint = "mint"
+	// TransactionTypeAllocate draws neurons from a budget into a classroom pool
+	TransactionTypeAllocate = "allocate"
+	// TransactionTypeDeallocate gives a classroom pool's neurons back to the budget
+	TransactionTypeDeallocate = "deallocate"
+)
+
+// Audit log actions on budgets
+const (
+	ActionAllocationSet = "budget.allocation_set"
+	ActionBudgetMinted  = "budget.minted"
+)
+
+// Budget is the supply of neurons a teacher, or their organization, can put
+// into classroom pools. Classrooms draw from the acting teacher's budget and
+// fall back to the organization's. Neurons taken out of a pool go back to the
+// budgets that funded it.
+type Budget struct {
+	ID             int64 `json:"id" db:"id"`
+	OrganizationID int64 `json:"organization_id" db:"organization_id"`
+	// UserID is the teacher the budget belongs to; nil for the organization's budget
+	UserID  *int64 `json:"user_id,omitempty" db:"user_id"`
+	Balance int    `json:"balance" db:"balance"`
+	// AllocationAmount is minted into the budget every AllocationPeriod
+	AllocationAmount int        `json:"allocation_amount" db:"allocation_amount"`
+	AllocationPeriod string     `json:"allocation_period" db:"allocation_period"`
+	NextAllocationAt *time.Time `json:"next_allocation_at,omitempty" db:"next_allocation_at"`
+	// Minted is the total ever minted into the budget
+	Minted int `json:"minted" db:"minted"`
+	// Allocated is what classroom pools currently hold from the budget
+	Allocated int       `json:"allocated" db:"allocated"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Transaction is an entry in a budget's history. Amounts are positive when
+// neurons come into the budget.
+type Transaction struct {
+	ID              int64     `json:"id" db:"id"`
+	BudgetID        int64     `json:"budget_id" db:"budget_id"`
+	ClassroomID     *int64    `json:"classroom_id,omitempty" db:"classroom_id"`
+	Amount          int       `json:"amount" db:"amount"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	CreatedBy       *int64    `json:"created_by,omitempty" db:"created_by"`
+	Note            string    `json:"note" db:"note"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// Funding is what a budget currently has allocated to a classroom
+type Funding struct {
+	BudgetID int64 `json:"budget_id" db:"budget_id"`
+	Amount   int   `json:"amount" db:"amount"`
+}
+
+// Allocation is the periodic supply of a budget; a zero amount stops it
+type Allocation struct {
+	Amount int    `json:"amount"`
+	Period string `json:"period"`
+}
+
+func (a Allocation) validate() error {
+	if a.Amount < 0 {
+		return errors.ErrBadRequest("allocation amount cannot be negative")
+	}
+	if a.Amount > 0 && a.Period != PeriodWeekly && a.Period != PeriodMonthly {
+		return errors.ErrBadRequest("allocation period must be weekly or monthly")
+	}
+	return nil
+}
+
+// next returns when the allocation after one made at t is due
+func (a Allocation) next(t time.Time) time.Time {
+	if a.Period == PeriodWeekly {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 1, 0)
+}
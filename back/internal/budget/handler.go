@@ -0,0 +1,98 @@
+package budget
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service Servicer
+	policy  *authz.Policy
+}
+
+func NewHandler(service Servicer, policy *authz.Policy) *Handler {
+	return &Handler{
+		service: service,
+		policy:  policy,
+	}
+}
+
+func (h *Handler) RegisterRoutes(app *fiber.App) {
+	budgetGroup := app.Group("/budgets")
+
+	// Routes act on the budgets of the current user's organization
+	budgetGroup.Use(h.policy.Authenticate)
+	budgetGroup.Get("/me", authz.Require(authz.HasRole("teacher")), h.GetMyBudget)
+	budgetGroup.Get("/me/transactions", authz.Require(authz.HasRole("teacher")), h.ListMyTransactions)
+	budgetGroup.Get("/", authz.Require(authz.OrgAdmin), h.ListBudgets)
+	budgetGroup.Get("/:budgetId/transactions", authz.Require(authz.OrgAdmin), h.ListTransactions)
+}
+
+// GetMyBudget returns the budget the current teacher's classrooms draw from
+func (h *Handler) GetMyBudget(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	budget, err := h.service.BudgetFor(c.Context(), u.ID, u.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(budget)
+}
+
+func (h *Handler) ListMyTransactions(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	budget, err := h.service.BudgetFor(c.Context(), u.ID, u.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return h.listTransactions(c, budget.ID)
+}
+
+func (h *Handler) ListBudgets(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	budgets, err := h.service.ListBudgets(c.Context(), u.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(budgets)
+}
+
+func (h *Handler) ListTransactions(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	budgetID, err := strconv.ParseInt(c.Params("budgetId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid budget id")
+	}
+
+	budget, err := h.service.GetBudget(c.Context(), budgetID)
+	if err != nil {
+		return err
+	}
+	// Budgets of other organizations are not visible to org admins
+	if budget.OrganizationID != u.OrganizationID {
+		return errors.ErrNotFound("budget not found")
+	}
+
+	return h.listTransactions(c, budget.ID)
+}
+
+func (h *Handler) listTransactions(c *fiber.Ctx, budgetID int64) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	transactions, err := h.service.ListTransactions(c.Context(), budgetID, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(transactions)
+}
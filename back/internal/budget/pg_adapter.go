@@ -0,0 +1,253 @@
+package budget
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+const budgetQuery = `
+	SELECT b.id, b.organization_id, b.user_id, b.balance, b.allocation_amount, b.allocation_period,
+		   b.next_allocation_at, b.created_at,
+		   COALESCE((SELECT SUM(t.amount) FROM budget_transactions t WHERE t.budget_id = b.id AND t.transaction_type = 'mint'), 0) AS minted,
+		   COALESCE((SELECT -SUM(t.amount) FROM budget_transactions t WHERE t.budget_id = b.id AND t.transaction_type IN ('allocate', 'deallocate')), 0) AS allocated
+	FROM budgets b
+`
+
+const transactionColumns = "id, budget_id, classroom_id, amount, transaction_type, created_by, note, created_at"
+
+type PostgresRepository struct {
+	db *database.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: database.New(db)}
+}
+
+func (r *PostgresRepository) GetBudget(ctx context.Context, id int64) (*Budget, error) {
+	var budget Budget
+	err := r.db.GetContext(ctx, &budget, budgetQuery+"WHERE b.id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("budget not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get budget: %v", err))
+	}
+	return &budget, nil
+}
+
+func (r *PostgresRepository) FindBudget(ctx context.Context, organizationID int64, userID *int64) (*Budget, error) {
+	var budget Budget
+	err := r.db.GetContext(ctx, &budget, budgetQuery+`
+		WHERE b.organization_id = $1 AND b.user_id IS NOT DISTINCT FROM $2
+	`, organizationID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to find budget: %v", err))
+	}
+	return &budget, nil
+}
+
+func (r *PostgresRepository) ListBudgets(ctx context.Context, organizationID int64) ([]*Budget, error) {
+	budgets := []*Budget{}
+	err := r.db.SelectContext(ctx, &budgets, budgetQuery+`
+		WHERE b.organization_id = $1
+		ORDER BY b.user_id NULLS FIRST
+	`, organizationID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list budgets: %v", err))
+	}
+	return budgets, nil
+}
+
+func (r *PostgresRepository) SetAllocation(ctx context.Context, budget *Budget) error {
+	// The partial unique indexes can't be used as conflict targets for both
+	// kinds of budget, so look the budget up under lock first
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &budget.ID, `
+		SELECT id FROM budgets
+		WHERE organization_id = $1 AND user_id IS NOT DISTINCT FROM $2
+		FOR UPDATE
+	`, budget.OrganizationID, budget.UserID)
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.GetContext(ctx, &budget.ID, `
+			INSERT INTO budgets (organization_id, user_id, allocation_amount, allocation_period, next_allocation_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, budget.OrganizationID, budget.UserID, budget.AllocationAmount, budget.AllocationPeriod,
+			budget.NextAllocationAt, budget.CreatedAt)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to create budget: %v", err))
+		}
+	case err != nil:
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock budget: %v", err))
+	default:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE budgets
+			SET allocation_amount = $2, allocation_period = $3, next_allocation_at = $4
+			WHERE id = $1
+		`, budget.ID, budget.AllocationAmount, budget.AllocationPeriod, budget.NextAllocationAt)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to update budget allocation: %v", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) PostTransaction(ctx context.Context, transaction *Transaction) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	if err := postTransaction(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListClassroomFunding(ctx context.Context, classroomID int64) ([]*Funding, error) {
+	query := `
+		SELECT budget_id, -SUM(amount) AS amount
+		FROM budget_transactions
+		WHERE classroom_id = $1 AND transaction_type IN ('allocate', 'deallocate')
+		GROUP BY budget_id
+		HAVING -SUM(amount) > 0
+		ORDER BY MAX(id) DESC
+	`
+	funding := []*Funding{}
+	err := r.db.SelectContext(ctx, &funding, query, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list classroom funding: %v", err))
+	}
+	return funding, nil
+}
+
+func (r *PostgresRepository) ListTransactions(ctx context.Context, budgetID int64, limit, offset int) ([]*Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM budget_transactions
+		WHERE budget_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	transactions := []*Transaction{}
+	err := r.db.SelectContext(ctx, &transactions, query, budgetID, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list budget transactions: %v", err))
+	}
+	return transactions, nil
+}
+
+func (r *PostgresRepository) ListDueBudgets(ctx context.Context, now time.Time) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM budgets
+		WHERE allocation_amount > 0 AND next_allocation_at <= $1
+		ORDER BY id
+	`
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, query, now)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list due budgets: %v", err))
+	}
+	return ids, nil
+}
+
+func (r *PostgresRepository) RunAllocation(ctx context.Context, id int64, now time.Time) (*Transaction, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// Lock the budget so an allocation is only minted once
+	var budget Budget
+	err = tx.GetContext(ctx, &budget, `
+		SELECT id, allocation_amount, allocation_period, next_allocation_at
+		FROM budgets
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("budget not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to lock budget: %v", err))
+	}
+	if budget.AllocationAmount == 0 || budget.NextAllocationAt == nil || budget.NextAllocationAt.After(now) {
+		return nil, nil
+	}
+
+	transaction := &Transaction{
+		BudgetID:        id,
+		Amount:          budget.AllocationAmount,
+		TransactionType: TransactionTypeMint,
+		Note:            budget.AllocationPeriod + " allocation",
+		CreatedAt:       now,
+	}
+	if err := postTransaction(ctx, tx, transaction); err != nil {
+		return nil, err
+	}
+
+	// Missed allocations are caught up one period at a time
+	allocation := Allocation{Amount: budget.AllocationAmount, Period: budget.AllocationPeriod}
+	_, err = tx.ExecContext(ctx, "UPDATE budgets SET next_allocation_at = $2 WHERE id = $1", id, allocation.next(*budget.NextAllocationAt))
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to schedule budget allocation: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return transaction, nil
+}
+
+// postTransaction records the transaction and applies it to the budget's
+// balance while the budget row is locked by the update
+func postTransaction(ctx context.Context, tx *database.Tx, transaction *Transaction) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE budgets
+		SET balance = balance + $1
+		WHERE id = $2 AND balance + $1 >= 0
+	`, transaction.Amount, transaction.BudgetID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update budget balance: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInsufficientBudget
+	}
+
+	err = tx.GetContext(ctx, &transaction.ID, `
+		INSERT INTO budget_transactions (budget_id, classroom_id, amount, transaction_type, created_by, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, transaction.BudgetID, transaction.ClassroomID, transaction.Amount, transaction.TransactionType,
+		transaction.CreatedBy, transaction.Note, transaction.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to record budget transaction: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package budget
+
+import (
+	"context"
+	"time"
+)
+
+// DBRepository defines the interface for budget database operations
+type DBRepository interface {
+	// GetBudget retrieves a budget by its ID
+	GetBudget(ctx context.Context, id int64) (*Budget, error)
+
+	// FindBudget retrieves the budget of a teacher, or of the organization
+	// when userID is nil; it returns nil when there is none
+	FindBudget(ctx context.Context, organizationID int64, userID *int64) (*Budget, error)
+
+	// ListBudgets retrieves the budgets of an organization and its teachers
+	ListBudgets(ctx context.Context, organizationID int64) ([]*Budget, error)
+
+	// SetAllocation opens the budget if needed and sets its periodic allocation
+	SetAllocation(ctx context.Context, budget *Budget) error
+
+	// PostTransaction applies a transaction to its budget's balance, refusing
+	// to take the balance below zero
+	PostTransaction(ctx context.Context, transaction *Transaction) error
+
+	// ListClassroomFunding retrieves what each budget currently has allocated
+	// to a classroom, the budget that funded it last first
+	ListClassroomFunding(ctx context.Context, classroomID int64) ([]*Funding, error)
+
+	// ListTransactions retrieves a budget's history, newest first
+	ListTransactions(ctx context.Context, budgetID int64, limit, offset int) ([]*Transaction, error)
+
+	// ListDueBudgets retrieves the budgets whose allocation is due
+	ListDueBudgets(ctx context.Context, now time.Time) ([]int64, error)
+
+	// RunAllocation mints the budget's allocation if it is still due and
+	// schedules the next one; it returns nil when nothing was due
+	RunAllocation(ctx context.Context, id int64, now time.Time) (*Transaction, error)
+}
@@ -0,0 +1,242 @@
+package budget
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Servicer defines the interface for budget operations
+type Servicer interface {
+	GetBudget(ctx context.Context, id int64) (*Budget, error)
+	BudgetFor(ctx context.Context, teacherID, organizationID int64) (*Budget, error)
+	ListBudgets(ctx context.Context, organizationID int64) ([]*Budget, error)
+	ListTransactions(ctx context.Context, budgetID int64, limit, offset int) ([]*Transaction, error)
+	SetAllocation(ctx context.Context, organizationID int64, userID *int64, allocation Allocation) (*Budget, error)
+	Mint(ctx context.Context, adminID, budgetID int64, amount int, note string) (*Transaction, error)
+	Allocate(ctx context.Context, teacherID, organizationID, classroomID int64, amount int) error
+	RunAllocations(ctx context.Context) (int, error)
+}
+
+// Ensure Service implements Servicer
+var _ Servicer = (*Service)(nil)
+
+// Service implements the Servicer interface
+type Service struct {
+	auditService audit.Servicer
+	repo         DBRepository
+}
+
+// NewService creates a new budget service
+func NewService(auditService audit.Servicer, repo DBRepository) *Service {
+	return &Service{
+		auditService: auditService,
+		repo:         repo,
+	}
+}
+
+// GetBudget retrieves a budget by its ID
+func (s *Service) GetBudget(ctx context.Context, id int64) (*Budget, error) {
+	return s.repo.GetBudget(ctx, id)
+}
+
+// BudgetFor retrieves the budget a teacher's classrooms draw from: their own
+// or, without one, their organization's
+func (s *Service) BudgetFor(ctx context.Context, teacherID, organizationID int64) (*Budget, error) {
+	budget, err := s.repo.FindBudget(ctx, organizationID, &teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if budget != nil {
+		return budget, nil
+	}
+
+	budget, err = s.repo.FindBudget(ctx, organizationID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrNoBudget
+	}
+	return budget, nil
+}
+
+// ListBudgets retrieves the budgets of an organization and its teachers
+func (s *Service) ListBudgets(ctx context.Context, organizationID int64) ([]*Budget, error) {
+	return s.repo.ListBudgets(ctx, organizationID)
+}
+
+// ListTransactions retrieves a budget's history, newest first
+func (s *Service) ListTransactions(ctx context.Context, budgetID int64, limit, offset int) ([]*Transaction, error) {
+	return s.repo.ListTransactions(ctx, budgetID, limit, offset)
+}
+
+// SetAllocation sets the periodic allocation of a teacher's budget, or of the
+// organization's when userID is nil, opening the budget if needed. A newly
+// enabled allocation is minted right away; changing an existing one keeps its
+// schedule.
+func (s *Service) SetAllocation(ctx context.Context, organizationID int64, userID *int64, allocation Allocation) (*Budget, error) {
+	if err := allocation.validate(); err != nil {
+		return nil, err
+	}
+
+	var changed *Budget
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.FindBudget(ctx, organizationID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		budget := &Budget{
+			OrganizationID:   organizationID,
+			UserID:           userID,
+			AllocationAmount: allocation.Amount,
+			AllocationPeriod: allocation.Period,
+			CreatedAt:        time.Now(),
+		}
+		if allocation.Amount > 0 {
+			budget.NextAllocationAt = &budget.CreatedAt
+			if before != nil && before.NextAllocationAt != nil {
+				budget.NextAllocationAt = before.NextAllocationAt
+			}
+		}
+
+		err = s.repo.SetAllocation(ctx, budget)
+		if err != nil {
+			return nil, err
+		}
+
+		changed, err = s.repo.GetBudget(ctx, budget.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &audit.Change{Action: ActionAllocationSet, TargetType: "budget", TargetID: changed.ID, Before: before, After: changed}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// Mint grants a budget neurons outside of its periodic allocation
+func (s *Service) Mint(ctx context.Context, adminID, budgetID int64, amount int, note string) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, errors.ErrBadRequest("amount must be positive")
+	}
+
+	transaction := &Transaction{
+		BudgetID:        budgetID,
+		Amount:          amount,
+		TransactionType: TransactionTypeMint,
+		CreatedBy:       &adminID,
+		Note:            note,
+		CreatedAt:       time.Now(),
+	}
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.GetBudget(ctx, budgetID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.PostTransaction(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := s.repo.GetBudget(ctx, budgetID)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: ActionBudgetMinted, TargetType: "budget", TargetID: budgetID, Before: before, After: after}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// Allocate draws neurons from the teacher's budget into a classroom pool.
+// Drawing more than the budget holds fails with ErrInsufficientBudget. A
+// negative amount gives neurons from the pool back to the budgets that funded
+// it, whoever takes them out; neurons that didn't come from a budget aren't
+// given to any.
+func (s *Service) Allocate(ctx context.Context, teacherID, organizationID, classroomID int64, amount int) error {
+	if amount == 0 {
+		return nil
+	}
+	if amount < 0 {
+		return s.deallocate(ctx, teacherID, classroomID, -amount)
+	}
+
+	budget, err := s.BudgetFor(ctx, teacherID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.PostTransaction(ctx, &Transaction{
+		BudgetID:        budget.ID,
+		ClassroomID:     &classroomID,
+		Amount:          -amount,
+		TransactionType: TransactionTypeAllocate,
+		CreatedBy:       &teacherID,
+		CreatedAt:       time.Now(),
+	})
+}
+
+// deallocate gives neurons back to the budgets that funded the classroom,
+// starting with the one that funded it last
+func (s *Service) deallocate(ctx context.Context, teacherID, classroomID int64, amount int) error {
+	funding, err := s.repo.ListClassroomFunding(ctx, classroomID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, f := range funding {
+		if amount == 0 {
+			break
+		}
+		returned := min(amount, f.Amount)
+		err := s.repo.PostTransaction(ctx, &Transaction{
+			BudgetID:        f.BudgetID,
+			ClassroomID:     &classroomID,
+			Amount:          returned,
+			TransactionType: TransactionTypeDeallocate,
+			CreatedBy:       &teacherID,
+			CreatedAt:       now,
+		})
+		if err != nil {
+			return err
+		}
+		amount -= returned
+	}
+	return nil
+}
+
+// RunAllocations mints every allocation that is due and returns how many
+// were minted
+func (s *Service) RunAllocations(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.ListDueBudgets(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	minted := 0
+	for _, id := range ids {
+		transaction, err := s.repo.RunAllocation(ctx, id, now)
+		if err != nil {
+			return minted, err
+		}
+		// Another instance may have minted it in the meantime
+		if transaction != nil {
+			minted++
+		}
+	}
+	return minted, nil
+}
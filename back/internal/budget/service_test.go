@@ -0,0 +1,62 @@
+package budget
+
+import (
+	"context"
+	"testing"
+)
+
+type stubRepository struct {
+	DBRepository
+	funding  []*Funding
+	postings []*Transaction
+}
+
+func (r *stubRepository) ListClassroomFunding(ctx context.Context, classroomID int64) ([]*Funding, error) {
+	return r.funding, nil
+}
+
+func (r *stubRepository) PostTransaction(ctx context.Context, transaction *Transaction) error {
+	r.postings = append(r.postings, transaction)
+	return nil
+}
+
+func TestAllocateGivesNeuronsBackToFundingBudgets(t *testing.T) {
+	coTeacherID := int64(11)
+	// The organization's budget 1 funded the classroom first, then the
+	// owner's budget 2
+	repo := &stubRepository{funding: []*Funding{{BudgetID: 2, Amount: 30}, {BudgetID: 1, Amount: 50}}}
+	service := NewService(nil, repo)
+
+	if err := service.Allocate(context.Background(), coTeacherID, 1, 7, -60); err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+
+	want := []struct {
+		budgetID int64
+		amount   int
+	}{{2, 30}, {1, 30}}
+	if len(repo.postings) != len(want) {
+		t.Fatalf("expected %d postings, got %d", len(want), len(repo.postings))
+	}
+	for i, posting := range repo.postings {
+		if posting.BudgetID != want[i].budgetID || posting.Amount != want[i].amount {
+			t.Errorf("expected %d back to budget %d, got %d to budget %d",
+				want[i].amount, want[i].budgetID, posting.Amount, posting.BudgetID)
+		}
+		if posting.TransactionType != TransactionTypeDeallocate || *posting.CreatedBy != coTeacherID {
+			t.Errorf("expected a deallocation by %d, got %s by %d", coTeacherID, posting.TransactionType, *posting.CreatedBy)
+		}
+	}
+}
+
+func TestAllocateKeepsNeuronsNoBudgetFunded(t *testing.T) {
+	repo := &stubRepository{funding: []*Funding{{BudgetID: 1, Amount: 10}}}
+	service := NewService(nil, repo)
+
+	if err := service.Allocate(context.Background(), 10, 1, 7, -25); err != nil {
+		t.Fatalf("failed to allocate: %v", err)
+	}
+	if len(repo.postings) != 1 || repo.postings[0].Amount != 10 {
+		t.Errorf("expected only the 10 funded neurons back, got %+v", repo.postings)
+	}
+}
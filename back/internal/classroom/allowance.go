@@ -0,0 +1,87 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Allowance run statuses
+const (
+	AllowanceRunPaid = "paid"
+	// AllowanceRunFailed runs paid nobody, for example because the pool ran
+	// dry; the rule carries on with its next run
+	AllowanceRunFailed = "failed"
+)
+
+// AllowanceRule pays eligible students a fixed amount on a schedule, on
+// behalf of the teacher who created it
+type AllowanceRule struct {
+	ID          int64  `json:"id" db:"id"`
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	Name        string `json:"name" db:"name"`
+	// Amount is paid to every eligible student
+	Amount int `json:"amount" db:"amount"`
+	// Schedule is a five-field cron expression evaluated in UTC, such as
+	// "0 8 * * 1" for every Monday at 08:00
+	Schedule string `json:"schedule" db:"schedule"`
+	// GroupID limits the allowance to the members of a group; nil pays every
+	// enrolled student
+	GroupID    *int64     `json:"group_id,omitempty" db:"group_id"`
+	CategoryID *int64     `json:"category_id,omitempty" db:"category_id"`
+	Note       string     `json:"note" db:"note"`
+	StartsAt   time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	// NextRunAt is when the rule runs next; nil once it has ended
+	NextRunAt *time.Time `json:"next_run_at,omitempty" db:"next_run_at"`
+	CreatedBy int64      `json:"created_by" db:"created_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// AllowanceRun is one execution of an allowance rule. A rule runs at most
+// once per scheduled time.
+type AllowanceRun struct {
+	ID           int64     `json:"id" db:"id"`
+	RuleID       int64     `json:"rule_id" db:"rule_id"`
+	ScheduledFor time.Time `json:"scheduled_for" db:"scheduled_for"`
+	Status       string    `json:"status" db:"status"`
+	// BatchID holds the transactions of a paid run
+	BatchID   *int64    `json:"batch_id,omitempty" db:"batch_id"`
+	Error     string    `json:"error" db:"error"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+func (a *AllowanceRule) validate() error {
+	if a.Name == "" {
+		return errors.ErrBadRequest("name is required")
+	}
+	if len(a.Name) > 100 {
+		return errors.ErrBadRequest("name must be at most 100 characters")
+	}
+	if a.Amount <= 0 {
+		return ErrAmountNotPositive
+	}
+	if a.EndsAt != nil && !a.EndsAt.After(a.StartsAt) {
+		return errors.ErrBadRequest("ends_at must be after starts_at")
+	}
+	_, err := parseCron(a.Schedule)
+	return err
+}
+
+// nextRun returns the first scheduled time after t, and no earlier than the
+// start of the rule, or nil if the rule has ended by then
+func (a *AllowanceRule) nextRun(t time.Time) (*time.Time, error) {
+	schedule, err := parseCron(a.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if a.StartsAt.After(t) {
+		t = a.StartsAt.Add(-time.Nanosecond)
+	}
+
+	next := schedule.next(t)
+	if next.IsZero() || (a.EndsAt != nil && next.After(*a.EndsAt)) {
+		return nil, nil
+	}
+	return &next, nil
+}
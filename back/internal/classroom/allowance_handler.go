@@ -0,0 +1,142 @@
+package classroom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// allowanceInput is the editable part of an allowance rule
+type allowanceInput struct {
+	Name       string     `json:"name"`
+	Amount     int        `json:"amount"`
+	Schedule   string     `json:"schedule"`
+	GroupID    *int64     `json:"group_id"`
+	CategoryID *int64     `json:"category_id"`
+	Note       string     `json:"note"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+}
+
+func (in allowanceInput) rule(classroomID int64) *AllowanceRule {
+	rule := &AllowanceRule{
+		ClassroomID: classroomID,
+		Name:        in.Name,
+		Amount:      in.Amount,
+		Schedule:    in.Schedule,
+		GroupID:     in.GroupID,
+		CategoryID:  in.CategoryID,
+		Note:        in.Note,
+		EndsAt:      in.EndsAt,
+	}
+	if in.StartsAt != nil {
+		rule.StartsAt = *in.StartsAt
+	}
+	return rule
+}
+
+func (h *Handler) ListAllowanceRules(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	rules, err := h.service.ListAllowanceRules(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(rules)
+}
+
+func (h *Handler) CreateAllowanceRule(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input allowanceInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	rule := input.rule(classroomID)
+	rule.CreatedBy = u.ID
+	created, err := h.service.CreateAllowanceRule(c.Context(), rule)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(created)
+}
+
+func (h *Handler) UpdateAllowanceRule(c *fiber.Ctx) error {
+	classroomID, ruleID, err := allowanceParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input allowanceInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	rule := input.rule(classroomID)
+	rule.ID = ruleID
+	updated, err := h.service.UpdateAllowanceRule(c.Context(), rule)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(updated)
+}
+
+func (h *Handler) DeleteAllowanceRule(c *fiber.Ctx) error {
+	classroomID, ruleID, err := allowanceParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteAllowanceRule(c.Context(), classroomID, ruleID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) ListAllowanceRuns(c *fiber.Ctx) error {
+	classroomID, ruleID, err := allowanceParams(c)
+	if err != nil {
+		return err
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	runs, err := h.service.ListAllowanceRuns(c.Context(), classroomID, ruleID, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(runs)
+}
+
+func allowanceParams(c *fiber.Ctx) (classroomID, ruleID int64, err error) {
+	classroomID, err = strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	ruleID, err = strconv.ParseInt(c.Params("allowanceId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid allowance id")
+	}
+
+	return classroomID, ruleID, nil
+}
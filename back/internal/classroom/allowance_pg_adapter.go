@@ -0,0 +1,173 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+const allowanceRuleColumns = `id, classroom_id, name, amount, schedule, group_id, category_id, note,
+	starts_at, ends_at, next_run_at, created_by, created_at`
+
+const allowanceRunColumns = "id, rule_id, scheduled_for, status, batch_id, error, created_at"
+
+func (r *PostgresRepository) CreateAllowanceRule(ctx context.Context, rule *AllowanceRule) error {
+	query := `
+		INSERT INTO allowance_rules (classroom_id, name, amount, schedule, group_id, category_id, note,
+			starts_at, ends_at, next_run_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+	err := r.db.GetContext(ctx, &rule.ID, query, rule.ClassroomID, rule.Name, rule.Amount, rule.Schedule,
+		rule.GroupID, rule.CategoryID, rule.Note, rule.StartsAt, rule.EndsAt, rule.NextRunAt, rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create allowance rule: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetAllowanceRule(ctx context.Context, id int64) (*AllowanceRule, error) {
+	query := "SELECT " + allowanceRuleColumns + " FROM allowance_rules WHERE id = $1"
+	var rule AllowanceRule
+	err := r.db.GetContext(ctx, &rule, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("allowance rule not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get allowance rule: %v", err))
+	}
+	return &rule, nil
+}
+
+func (r *PostgresRepository) ListAllowanceRules(ctx context.Context, classroomID int64) ([]*AllowanceRule, error) {
+	query := "SELECT " + allowanceRuleColumns + " FROM allowance_rules WHERE classroom_id = $1 ORDER BY name"
+	rules := []*AllowanceRule{}
+	err := r.db.SelectContext(ctx, &rules, query, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list allowance rules: %v", err))
+	}
+	return rules, nil
+}
+
+func (r *PostgresRepository) UpdateAllowanceRule(ctx context.Context, rule *AllowanceRule) error {
+	query := `
+		UPDATE allowance_rules
+		SET name = $2, amount = $3, schedule = $4, group_id = $5, category_id = $6, note = $7,
+			starts_at = $8, ends_at = $9, next_run_at = $10
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, rule.ID, rule.Name, rule.Amount, rule.Schedule, rule.GroupID,
+		rule.CategoryID, rule.Note, rule.StartsAt, rule.EndsAt, rule.NextRunAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update allowance rule: %v", err))
+	}
+	return nil
+}
+
+// DeleteAllowanceRule deletes a rule and its run history; the transactions
+// it paid are kept
+func (r *PostgresRepository) DeleteAllowanceRule(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM allowance_runs WHERE rule_id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to delete allowance runs: %v", err))
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM allowance_rules WHERE id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to delete allowance rule: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListAllowanceRuns(ctx context.Context, ruleID int64, limit, offset int) ([]*AllowanceRun, error) {
+	query := `
+		SELECT ` + allowanceRunColumns + `
+		FROM allowance_runs
+		WHERE rule_id = $1
+		ORDER BY scheduled_for DESC
+		LIMIT $2 OFFSET $3
+	`
+	runs := []*AllowanceRun{}
+	err := r.db.SelectContext(ctx, &runs, query, ruleID, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list allowance runs: %v", err))
+	}
+	return runs, nil
+}
+
+// ListDueAllowanceRules retrieves the rules of active classrooms whose next
+// run is due
+func (r *PostgresRepository) ListDueAllowanceRules(ctx context.Context, now time.Time) ([]int64, error) {
+	query := `
+		SELECT ar.id
+		FROM allowance_rules ar
+		JOIN classrooms c ON c.id = ar.classroom_id
+		WHERE ar.next_run_at <= $1 AND c.archived_at IS NULL
+		ORDER BY ar.next_run_at
+	`
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, query, now)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list due allowance rules: %v", err))
+	}
+	return ids, nil
+}
+
+// ClaimAllowanceRun schedules the rule's next run if its run at scheduledFor
+// is still due. It returns false when another scheduler claimed it first.
+// The rule stays locked until the surrounding transaction ends.
+func (r *PostgresRepository) ClaimAllowanceRun(ctx context.Context, ruleID int64, scheduledFor time.Time, next *time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE allowance_rules
+		SET next_run_at = $3
+		WHERE id = $1 AND next_run_at = $2
+	`, ruleID, scheduledFor, next)
+	if err != nil {
+		return false, errors.ErrDatabase(fmt.Sprintf("failed to claim allowance run: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return true, nil
+}
+
+// RecordAllowanceRun records a run, refusing to record the same scheduled
+// run twice
+func (r *PostgresRepository) RecordAllowanceRun(ctx context.Context, run *AllowanceRun) error {
+	query := `
+		INSERT INTO allowance_runs (rule_id, scheduled_for, status, batch_id, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	err := r.db.GetContext(ctx, &run.ID, query, run.RuleID, run.ScheduledFor, run.Status, run.BatchID, run.Error, run.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errors.ErrConflict("allowance run already recorded")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to record allowance run: %v", err))
+	}
+	return nil
+}
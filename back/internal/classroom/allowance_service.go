@@ -0,0 +1,246 @@
+package classroom
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// ListAllowanceRules retrieves a classroom's allowances
+func (s *Service) ListAllowanceRules(ctx context.Context, classroomID int64) ([]*AllowanceRule, error) {
+	if _, err := s.repo.GetClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAllowanceRules(ctx, classroomID)
+}
+
+// CreateAllowanceRule schedules an allowance paid on behalf of the rule's
+// creator, who must be allowed to send neurons in the classroom when it runs
+func (s *Service) CreateAllowanceRule(ctx context.Context, rule *AllowanceRule) (*AllowanceRule, error) {
+	now := time.Now()
+	if rule.StartsAt.IsZero() {
+		rule.StartsAt = now
+	}
+	if err := s.checkAllowanceRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	next, err := rule.nextRun(now)
+	if err != nil {
+		return nil, err
+	}
+	rule.NextRunAt = next
+	rule.CreatedAt = now
+
+	var created *AllowanceRule
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateAllowanceRule(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+
+		created, err = s.repo.GetAllowanceRule(ctx, rule.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(rule.ClassroomID, ActionAllowanceCreated, "allowance_rule", rule.ID, nil, created), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateAllowanceRule changes an allowance and reschedules its next run
+func (s *Service) UpdateAllowanceRule(ctx context.Context, rule *AllowanceRule) (*AllowanceRule, error) {
+	before, err := s.getAllowanceRule(ctx, rule.ClassroomID, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.StartsAt.IsZero() {
+		rule.StartsAt = before.StartsAt
+	}
+	if err := s.checkAllowanceRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	next, err := rule.nextRun(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rule.NextRunAt = next
+
+	var after *AllowanceRule
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.UpdateAllowanceRule(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetAllowanceRule(ctx, rule.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(rule.ClassroomID, ActionAllowanceUpdated, "allowance_rule", rule.ID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteAllowanceRule stops an allowance
+func (s *Service) DeleteAllowanceRule(ctx context.Context, classroomID, ruleID int64) error {
+	before, err := s.getAllowanceRule(ctx, classroomID, ruleID)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.DeleteAllowanceRule(ctx, ruleID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionAllowanceDeleted, "allowance_rule", ruleID, before, nil), nil
+	})
+}
+
+// ListAllowanceRuns retrieves the runs of an allowance, newest first
+func (s *Service) ListAllowanceRuns(ctx context.Context, classroomID, ruleID int64, limit, offset int) ([]*AllowanceRun, error) {
+	if _, err := s.getAllowanceRule(ctx, classroomID, ruleID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAllowanceRuns(ctx, ruleID, limit, offset)
+}
+
+// RunAllowances pays every allowance that is due and returns how many runs
+// paid. Each scheduled run is claimed in the same database transaction as
+// its transfers, so it is paid at most once however many schedulers run. A
+// rule that fails is logged and retried on the next call, without holding
+// up the other rules.
+func (s *Service) RunAllowances(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.ListDueAllowanceRules(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return paid, err
+		}
+		rule, err := s.repo.GetAllowanceRule(ctx, id)
+		if err != nil {
+			log.Printf("failed to load allowance %d: %v", id, err)
+			continue
+		}
+		ok, err := s.runAllowanceRule(ctx, rule, now)
+		if err != nil {
+			log.Printf("failed to run allowance %d: %v", id, err)
+			continue
+		}
+		if ok {
+			paid++
+		}
+	}
+	return paid, nil
+}
+
+// runAllowanceRule pays the rule's due run. A run that can't be paid, for
+// example because the pool ran dry, is recorded as failed and the rule
+// carries on with its next run.
+func (s *Service) runAllowanceRule(ctx context.Context, rule *AllowanceRule, now time.Time) (bool, error) {
+	if rule.NextRunAt == nil {
+		return false, nil
+	}
+	// Runs missed while no scheduler was up are skipped rather than paid
+	// all at once
+	next, err := rule.nextRun(now)
+	if err != nil {
+		return false, err
+	}
+	run := &AllowanceRun{
+		RuleID:       rule.ID,
+		ScheduledFor: *rule.NextRunAt,
+		CreatedAt:    now,
+	}
+
+	claimed := false
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		var err error
+		claimed, err = s.repo.ClaimAllowanceRun(ctx, rule.ID, run.ScheduledFor, next)
+		if err != nil || !claimed {
+			return nil, err
+		}
+
+		batch, err := s.payAllowance(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+		run.Status = AllowanceRunPaid
+		run.BatchID = &batch.ID
+		return nil, s.repo.RecordAllowanceRun(ctx, run)
+	})
+	if err == nil {
+		return claimed, nil
+	}
+	// Database failures are retried on the next tick
+	if apiErr, ok := err.(errors.ApiError); ok && apiErr.Type == "DatabaseError" {
+		return false, err
+	}
+
+	run.Status = AllowanceRunFailed
+	run.BatchID = nil
+	run.Error = err.Error()
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		claimed, err := s.repo.ClaimAllowanceRun(ctx, rule.ID, run.ScheduledFor, next)
+		if err != nil || !claimed {
+			return nil, err
+		}
+		return nil, s.repo.RecordAllowanceRun(ctx, run)
+	})
+	return false, err
+}
+
+// payAllowance sends the allowance to every eligible student through the
+// same path as a teacher's bulk send
+func (s *Service) payAllowance(ctx context.Context, rule *AllowanceRule) (*TransactionBatch, error) {
+	details := TransactionDetails{CategoryID: rule.CategoryID, Note: rule.Note}
+	if rule.GroupID != nil {
+		return s.SendNeuronsToGroup(ctx, rule.CreatedBy, rule.ClassroomID, *rule.GroupID, GroupSend{
+			Amount:             rule.Amount,
+			TransactionDetails: details,
+		})
+	}
+	return s.SendNeuronsBulk(ctx, rule.CreatedBy, rule.ClassroomID, nil, rule.Amount, details)
+}
+
+// checkAllowanceRule validates a rule against its classroom
+func (s *Service) checkAllowanceRule(ctx context.Context, rule *AllowanceRule) error {
+	if err := rule.validate(); err != nil {
+		return err
+	}
+	if _, err := s.getActiveClassroom(ctx, rule.ClassroomID); err != nil {
+		return err
+	}
+	if rule.GroupID != nil {
+		if _, err := s.getGroup(ctx, rule.ClassroomID, *rule.GroupID); err != nil {
+			return err
+		}
+	}
+	_, err := s.resolveCategory(ctx, rule.ClassroomID, TransactionDetails{CategoryID: rule.CategoryID})
+	return err
+}
+
+func (s *Service) getAllowanceRule(ctx context.Context, classroomID, ruleID int64) (*AllowanceRule, error) {
+	rule, err := s.repo.GetAllowanceRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("allowance rule not found")
+	}
+	return rule, nil
+}
@@ -0,0 +1,81 @@
+package classroom
+
+import "github.com/Abraxas-365/neurons/internal/audit"
+
+// Audit log actions performed in classrooms
+const (
+	ActionClassroomCreated        = "classroom.created"
+	ActionClassroomUpdated        = "classroom.updated"
+	ActionClassroomArchived       = "classroom.archived"
+	ActionClassroomRestored       = "classroom.restored"
+	ActionClassroomPurged         = "classroom.purged"
+	ActionPoolUpdated             = "classroom.pool_updated"
+	ActionOwnershipTransferred    = "classroom.ownership_transferred"
+	ActionStudentAdded            = "student.added"
+	ActionStudentRemoved          = "student.removed"
+	ActionStudentJoined           = "student.joined"
+	ActionNeuronsSent             = "neurons.sent"
+	ActionNeuronsSentBulk         = "neurons.sent_bulk"
+	ActionNeuronsReturned         = "neurons.returned"
+	ActionNeuronsDeducted         = "neurons.deducted"
+	ActionTransactionReversed     = "transaction.reversed"
+	ActionCategoryCreated         = "category.created"
+	ActionCategoryUpdated         = "category.updated"
+	ActionCategoryDeleted         = "category.deleted"
+	ActionRewardCreated           = "reward.created"
+	ActionRewardUpdated           = "reward.updated"
+	ActionRewardDeleted           = "reward.deleted"
+	ActionRewardRedeemed          = "reward.redeemed"
+	ActionRedemptionRequested     = "redemption.requested"
+	ActionRedemptionApproved      = "redemption.approved"
+	ActionRedemptionRejected      = "redemption.rejected"
+	ActionRedemptionFulfilled     = "redemption.fulfilled"
+	ActionRedemptionExpired       = "redemption.expired"
+	ActionJoinCodeGenerated       = "join_code.generated"
+	ActionJoinCodeUpdated         = "join_code.updated"
+	ActionEnrollmentApproved      = "enrollment.approved"
+	ActionEnrollmentRejected      = "enrollment.rejected"
+	ActionStaffInvited            = "staff.invited"
+	ActionStaffInvitationAccepted = "staff.invitation_accepted"
+	ActionStaffUpdated            = "staff.updated"
+	ActionStaffRemoved            = "staff.removed"
+	ActionGroupCreated            = "group.created"
+	ActionGroupRenamed            = "group.renamed"
+	ActionGroupMembersChanged     = "group.members_changed"
+	ActionGroupDeleted            = "group.deleted"
+	ActionGroupsShuffled          = "group.shuffled"
+	ActionGroupFunded             = "group.funded"
+	ActionGroupContribution       = "group.contribution"
+	ActionGiftSettingsUpdated     = "gift_settings.updated"
+	ActionGiftGiven               = "gift.given"
+	ActionGiftRequested           = "gift.requested"
+	ActionGiftApproved            = "gift.approved"
+	ActionGiftRejected            = "gift.rejected"
+	ActionAllowanceCreated        = "allowance.created"
+	ActionAllowanceUpdated        = "allowance.updated"
+	ActionAllowanceDeleted        = "allowance.deleted"
+	ActionNeuronPolicyUpdated     = "neuron_policy.updated"
+	ActionNeuronsExpired          = "neurons.expired"
+	ActionTermEnded               = "classroom.term_ended"
+	ActionBadgeCreated            = "badge.created"
+	ActionBadgeUpdated            = "badge.updated"
+	ActionBadgeDeleted            = "badge.deleted"
+	ActionBadgeAwarded            = "badge.awarded"
+)
+
+// enrollment is the audited state of a student's place in a classroom
+type enrollment struct {
+	Enrolled bool `json:"enrolled"`
+}
+
+// classroomChange describes a change to an entity of a classroom for the audit log
+func classroomChange(classroomID int64, action, targetType string, targetID int64, before, after interface{}) *audit.Change {
+	return &audit.Change{
+		ClassroomID: &classroomID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Before:      before,
+		After:       after,
+	}
+}
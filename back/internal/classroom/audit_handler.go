@@ -0,0 +1,28 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) ListAuditLog(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	filter, err := audit.ParseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.service.ListAuditLog(c.Context(), classroomID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(entries)
+}
@@ -0,0 +1,19 @@
+package classroom
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+)
+
+// ListAuditLog retrieves the audit trail of a classroom
+func (s *Service) ListAuditLog(ctx context.Context, classroomID int64, filter audit.Filter) ([]*audit.Entry, error) {
+	// Verify that the classroom exists
+	_, err := s.repo.GetClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.ClassroomID = &classroomID
+	return s.auditService.List(ctx, filter)
+}
@@ -0,0 +1,96 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// ErrBadgeAlreadyAwarded is returned when a student already has the badge in the classroom
+var ErrBadgeAlreadyAwarded = errors.ErrConflict("student already has this badge")
+
+// Badge rule metrics
+const (
+	// BadgeMetricCount counts the student's matching transactions
+	BadgeMetricCount = "count"
+	// BadgeMetricAmount sums the neurons of the student's matching transactions
+	BadgeMetricAmount = "amount"
+)
+
+// Badge is an achievement students earn in a classroom. System badges have no
+// classroom and are offered by every classroom.
+//
+// A badge with a rule is awarded automatically as soon as the student's
+// transactions of the rule's type, optionally of one category and within the
+// last WindowDays days, reach the threshold. Reversed transactions don't
+// count. For example "earned 100 neurons" is the amount of assignments
+// reaching 100, and "first redemption" is a count of redemptions reaching 1.
+// Badges without a rule are only awarded by staff.
+type Badge struct {
+	ID          int64  `json:"id" db:"id"`
+	ClassroomID *int64 `json:"classroom_id" db:"classroom_id"`
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+	Icon        string `json:"icon" db:"icon"`
+	// Metric is empty for badges awarded by hand
+	Metric          *string `json:"metric" db:"rule_metric"`
+	TransactionType *string `json:"transaction_type" db:"rule_transaction_type"`
+	CategoryID      *int64  `json:"category_id" db:"rule_category_id"`
+	Threshold       *int    `json:"threshold" db:"rule_threshold"`
+	// WindowDays limits the rule to recent transactions; nil means all time
+	WindowDays *int   `json:"window_days" db:"rule_window_days"`
+	CreatedBy  *int64 `json:"created_by,omitempty" db:"created_by"`
+	// Awarded is how many students of the classroom have the badge
+	Awarded   int       `json:"awarded" db:"awarded"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// BadgeAward is a badge a student earned in a classroom, either automatically
+// through a transaction or from a staff member
+type BadgeAward struct {
+	ID          int64 `json:"id" db:"id"`
+	BadgeID     int64 `json:"badge_id" db:"badge_id"`
+	ClassroomID int64 `json:"classroom_id" db:"classroom_id"`
+	UserID      int64 `json:"user_id" db:"user_id"`
+	// TransactionID is the transaction that earned the badge
+	TransactionID *int64 `json:"transaction_id,omitempty" db:"transaction_id"`
+	// AwardedBy is the staff member who gave the badge by hand
+	AwardedBy        *int64    `json:"awarded_by,omitempty" db:"awarded_by"`
+	Note             string    `json:"note" db:"note"`
+	BadgeName        string    `json:"badge_name" db:"badge_name"`
+	BadgeDescription string    `json:"badge_description" db:"badge_description"`
+	BadgeIcon        string    `json:"badge_icon" db:"badge_icon"`
+	AwardedAt        time.Time `json:"awarded_at" db:"awarded_at"`
+}
+
+func (b *Badge) validate() error {
+	if b.Name == "" {
+		return errors.ErrBadRequest("name is required")
+	}
+	if len(b.Name) > 100 {
+		return errors.ErrBadRequest("name must be at most 100 characters")
+	}
+	if len(b.Icon) > 100 {
+		return errors.ErrBadRequest("icon must be at most 100 characters")
+	}
+
+	if b.Metric == nil {
+		if b.TransactionType != nil || b.CategoryID != nil || b.Threshold != nil || b.WindowDays != nil {
+			return errors.ErrBadRequest("a badge without a metric cannot have a rule")
+		}
+		return nil
+	}
+	if *b.Metric != BadgeMetricCount && *b.Metric != BadgeMetricAmount {
+		return errors.ErrBadRequest("metric must be count or amount")
+	}
+	if b.TransactionType == nil || !transactionTypes[*b.TransactionType] {
+		return errors.ErrBadRequest("invalid transaction type")
+	}
+	if b.Threshold == nil || *b.Threshold <= 0 {
+		return errors.ErrBadRequest("threshold must be positive")
+	}
+	if b.WindowDays != nil && *b.WindowDays <= 0 {
+		return errors.ErrBadRequest("window days must be positive")
+	}
+	return nil
+}
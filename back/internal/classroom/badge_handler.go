@@ -0,0 +1,180 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// badgeInput is the editable part of a badge
+type badgeInput struct {
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	Icon            string  `json:"icon"`
+	Metric          *string `json:"metric"`
+	TransactionType *string `json:"transaction_type"`
+	CategoryID      *int64  `json:"category_id"`
+	Threshold       *int    `json:"threshold"`
+	WindowDays      *int    `json:"window_days"`
+}
+
+func (in badgeInput) badge(classroomID int64) *Badge {
+	return &Badge{
+		ClassroomID:     &classroomID,
+		Name:            in.Name,
+		Description:     in.Description,
+		Icon:            in.Icon,
+		Metric:          in.Metric,
+		TransactionType: in.TransactionType,
+		CategoryID:      in.CategoryID,
+		Threshold:       in.Threshold,
+		WindowDays:      in.WindowDays,
+	}
+}
+
+func (h *Handler) ListBadges(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	badges, err := h.service.ListBadges(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(badges)
+}
+
+func (h *Handler) CreateBadge(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input badgeInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+	badge := input.badge(classroomID)
+	badge.CreatedBy = &u.ID
+
+	badge, err = h.service.CreateBadge(c.Context(), badge)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(badge)
+}
+
+func (h *Handler) UpdateBadge(c *fiber.Ctx) error {
+	classroomID, badgeID, err := badgeParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input badgeInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+	badge := input.badge(classroomID)
+	badge.ID = badgeID
+
+	badge, err = h.service.UpdateBadge(c.Context(), badge)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(badge)
+}
+
+func (h *Handler) DeleteBadge(c *fiber.Ctx) error {
+	classroomID, badgeID, err := badgeParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteBadge(c.Context(), classroomID, badgeID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) AwardBadge(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, badgeID, err := badgeParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		StudentID int64  `json:"student_id"`
+		Note      string `json:"note"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	award, err := h.service.AwardBadge(c.Context(), u.ID, classroomID, badgeID, input.StudentID, input.Note)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(award)
+}
+
+func (h *Handler) ListBadgeAwards(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var userID *int64
+	if value := c.Query("user_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.ErrBadRequest("invalid user id")
+		}
+		userID = &id
+	}
+
+	awards, err := h.service.ListBadgeAwards(c.Context(), classroomID, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(awards)
+}
+
+// ListMyBadges returns the badges the current user earned in every classroom
+func (h *Handler) ListMyBadges(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	awards, err := h.service.ListUserBadges(c.Context(), u.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(awards)
+}
+
+func badgeParams(c *fiber.Ctx) (classroomID, badgeID int64, err error) {
+	classroomID, err = strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	badgeID, err = strconv.ParseInt(c.Params("badgeId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid badge id")
+	}
+
+	return classroomID, badgeID, nil
+}
@@ -0,0 +1,196 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// badgeQuery selects badges with how many students of the classroom in $1 have them
+const badgeQuery = `
+	SELECT b.id, b.classroom_id, b.name, b.description, b.icon, b.rule_metric, b.rule_transaction_type,
+		   b.rule_category_id, b.rule_threshold, b.rule_window_days, b.created_by, b.created_at,
+		   (SELECT COUNT(*) FROM badge_awards a WHERE a.badge_id = b.id AND a.classroom_id = $1) AS awarded
+	FROM badges b
+`
+
+const badgeAwardQuery = `
+	SELECT a.id, a.badge_id, a.classroom_id, a.user_id, a.transaction_id, a.awarded_by, a.note, a.awarded_at,
+		   b.name AS badge_name, b.description AS badge_description, b.icon AS badge_icon
+	FROM badge_awards a
+	JOIN badges b ON b.id = a.badge_id
+`
+
+func (r *PostgresRepository) CreateBadge(ctx context.Context, badge *Badge) error {
+	query := `
+		INSERT INTO badges (classroom_id, name, description, icon, rule_metric, rule_transaction_type, rule_category_id,
+			rule_threshold, rule_window_days, created_by, created_at)
+		VALUES (:classroom_id, :name, :description, :icon, :rule_metric, :rule_transaction_type, :rule_category_id,
+			:rule_threshold, :rule_window_days, :created_by, :created_at)
+		RETURNING id
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, badge)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create badge: %v", err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&badge.ID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to scan badge ID: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetBadge retrieves a badge offered by the classroom, either its own or a system badge
+func (r *PostgresRepository) GetBadge(ctx context.Context, classroomID, id int64) (*Badge, error) {
+	var badge Badge
+	err := r.db.GetContext(ctx, &badge, badgeQuery+"WHERE b.id = $2 AND (b.classroom_id IS NULL OR b.classroom_id = $1)", classroomID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("badge not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get badge: %v", err))
+	}
+	return &badge, nil
+}
+
+// ListBadges retrieves the system badges and the classroom's own
+func (r *PostgresRepository) ListBadges(ctx context.Context, classroomID int64) ([]*Badge, error) {
+	badges := []*Badge{}
+	err := r.db.SelectContext(ctx, &badges, badgeQuery+`
+		WHERE b.classroom_id IS NULL OR b.classroom_id = $1
+		ORDER BY b.classroom_id NULLS FIRST, b.name, b.id
+	`, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list badges: %v", err))
+	}
+	return badges, nil
+}
+
+func (r *PostgresRepository) UpdateBadge(ctx context.Context, badge *Badge) error {
+	query := `
+		UPDATE badges
+		SET name = :name, description = :description, icon = :icon, rule_metric = :rule_metric,
+			rule_transaction_type = :rule_transaction_type, rule_category_id = :rule_category_id,
+			rule_threshold = :rule_threshold, rule_window_days = :rule_window_days
+		WHERE id = :id
+	`
+	_, err := r.db.NamedExecContext(ctx, query, badge)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update badge: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) DeleteBadge(ctx context.Context, id int64) error {
+	var awarded bool
+	err := r.db.GetContext(ctx, &awarded, "SELECT EXISTS(SELECT 1 FROM badge_awards WHERE badge_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check badge awards: %v", err))
+	}
+	if awarded {
+		return errors.ErrConflict("badge has been awarded")
+	}
+
+	_, err = r.db.ExecContext(ctx, "DELETE FROM badges WHERE id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to delete badge: %v", err))
+	}
+	return nil
+}
+
+// AwardBadge gives a badge to a student by hand
+func (r *PostgresRepository) AwardBadge(ctx context.Context, award *BadgeAward) error {
+	err := r.db.GetContext(ctx, &award.ID, `
+		INSERT INTO badge_awards (badge_id, classroom_id, user_id, awarded_by, note, awarded_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, award.BadgeID, award.ClassroomID, award.UserID, award.AwardedBy, award.Note, award.AwardedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrBadgeAlreadyAwarded
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to award badge: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetBadgeAward(ctx context.Context, id int64) (*BadgeAward, error) {
+	var award BadgeAward
+	err := r.db.GetContext(ctx, &award, badgeAwardQuery+"WHERE a.id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("badge award not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get badge award: %v", err))
+	}
+	return &award, nil
+}
+
+// ListBadgeAwards retrieves the badges earned in a classroom, newest first,
+// optionally only those of one student
+func (r *PostgresRepository) ListBadgeAwards(ctx context.Context, classroomID int64, userID *int64) ([]*BadgeAward, error) {
+	awards := []*BadgeAward{}
+	err := r.db.SelectContext(ctx, &awards, badgeAwardQuery+`
+		WHERE a.classroom_id = $1 AND ($2::integer IS NULL OR a.user_id = $2)
+		ORDER BY a.awarded_at DESC, a.id DESC
+	`, classroomID, userID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list badge awards: %v", err))
+	}
+	return awards, nil
+}
+
+// ListUserBadgeAwards retrieves the badges a student earned in every classroom, newest first
+func (r *PostgresRepository) ListUserBadgeAwards(ctx context.Context, userID int64) ([]*BadgeAward, error) {
+	awards := []*BadgeAward{}
+	err := r.db.SelectContext(ctx, &awards, badgeAwardQuery+`
+		WHERE a.user_id = $1
+		ORDER BY a.awarded_at DESC, a.id DESC
+	`, userID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list badge awards: %v", err))
+	}
+	return awards, nil
+}
+
+// awardBadges gives the student of a new transaction every badge whose rule
+// the transaction completes. Only rules on the transaction's type and
+// category can change, so only those are evaluated.
+func awardBadges(ctx context.Context, q sqlx.ExtContext, transaction *NeuronTransaction) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO badge_awards (badge_id, classroom_id, user_id, transaction_id, awarded_at)
+		SELECT b.id, $1::integer, $2::integer, $3::integer, $4::timestamptz
+		FROM badges b
+		WHERE (b.classroom_id IS NULL OR b.classroom_id = $1)
+		  AND b.rule_transaction_type = $5
+		  AND (b.rule_category_id IS NULL OR b.rule_category_id = $6)
+		  AND EXISTS (SELECT 1 FROM users_classrooms uc WHERE uc.classroom_id = $1 AND uc.user_id = $2)
+		  AND NOT EXISTS (
+			  SELECT 1 FROM badge_awards a
+			  WHERE a.badge_id = b.id AND a.classroom_id = $1 AND a.user_id = $2
+		  )
+		  AND (
+			  SELECT CASE WHEN b.rule_metric = 'count' THEN COUNT(*) ELSE COALESCE(SUM(nt.amount), 0) END
+			  FROM neuron_transactions nt
+			  WHERE nt.classroom_id = $1 AND nt.user_id = $2
+				AND nt.transaction_type = b.rule_transaction_type
+				AND (b.rule_category_id IS NULL OR nt.category_id = b.rule_category_id)
+				AND (b.rule_window_days IS NULL OR nt.created_at > $4::timestamptz - make_interval(days => b.rule_window_days))
+				AND NOT EXISTS (SELECT 1 FROM neuron_transactions r WHERE r.reverses_id = nt.id)
+		  ) >= b.rule_threshold
+		ON CONFLICT (badge_id, classroom_id, user_id) DO NOTHING
+	`, transaction.ClassroomID, transaction.UserID, transaction.ID, transaction.CreatedAt,
+		transaction.TransactionType, transaction.CategoryID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to award badges: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// ListBadges retrieves the badges a classroom offers, system badges first
+func (s *Service) ListBadges(ctx context.Context, classroomID int64) ([]*Badge, error) {
+	if _, err := s.repo.GetClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListBadges(ctx, classroomID)
+}
+
+// CreateBadge adds a badge of the classroom's own. Students who already meet
+// its rule earn it with their next matching transaction.
+func (s *Service) CreateBadge(ctx context.Context, badge *Badge) (*Badge, error) {
+	if err := s.checkBadge(ctx, badge); err != nil {
+		return nil, err
+	}
+	badge.CreatedAt = time.Now()
+
+	var created *Badge
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateBadge(ctx, badge)
+		if err != nil {
+			return nil, err
+		}
+
+		created, err = s.repo.GetBadge(ctx, *badge.ClassroomID, badge.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(*badge.ClassroomID, ActionBadgeCreated, "badge", badge.ID, nil, created), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// UpdateBadge changes a classroom's badge. Students keep the badges they
+// already earned.
+func (s *Service) UpdateBadge(ctx context.Context, badge *Badge) (*Badge, error) {
+	before, err := s.getOwnBadge(ctx, *badge.ClassroomID, badge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkBadge(ctx, badge); err != nil {
+		return nil, err
+	}
+
+	var after *Badge
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.UpdateBadge(ctx, badge)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetBadge(ctx, *badge.ClassroomID, badge.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(*badge.ClassroomID, ActionBadgeUpdated, "badge", badge.ID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteBadge deletes a classroom's badge that nobody has earned
+func (s *Service) DeleteBadge(ctx context.Context, classroomID, badgeID int64) error {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return err
+	}
+	before, err := s.getOwnBadge(ctx, classroomID, badgeID)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.DeleteBadge(ctx, badgeID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionBadgeDeleted, "badge", badgeID, before, nil), nil
+	})
+}
+
+// AwardBadge gives a student a badge by hand, whether or not it has a rule
+func (s *Service) AwardBadge(ctx context.Context, teacherID, classroomID, badgeID, studentID int64, note string) (*BadgeAward, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetBadge(ctx, classroomID, badgeID); err != nil {
+		return nil, err
+	}
+
+	isStudentInClassroom, err := s.repo.IsStudentInClassroom(ctx, classroomID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !isStudentInClassroom {
+		return nil, errors.ErrBadRequest("student is not in this classroom")
+	}
+
+	award := &BadgeAward{
+		BadgeID:     badgeID,
+		ClassroomID: classroomID,
+		UserID:      studentID,
+		AwardedBy:   &teacherID,
+		Note:        note,
+		AwardedAt:   time.Now(),
+	}
+	var awarded *BadgeAward
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.AwardBadge(ctx, award)
+		if err != nil {
+			return nil, err
+		}
+
+		awarded, err = s.repo.GetBadgeAward(ctx, award.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionBadgeAwarded, "badge_award", award.ID, nil, awarded), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return awarded, nil
+}
+
+// ListBadgeAwards retrieves the badges earned in a classroom, optionally only
+// those of one student
+func (s *Service) ListBadgeAwards(ctx context.Context, classroomID int64, userID *int64) ([]*BadgeAward, error) {
+	if _, err := s.repo.GetClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListBadgeAwards(ctx, classroomID, userID)
+}
+
+// ListUserBadges retrieves the badges a user earned across their classrooms
+func (s *Service) ListUserBadges(ctx context.Context, userID int64) ([]*BadgeAward, error) {
+	return s.repo.ListUserBadgeAwards(ctx, userID)
+}
+
+// checkBadge validates a badge against its classroom
+func (s *Service) checkBadge(ctx context.Context, badge *Badge) error {
+	if err := badge.validate(); err != nil {
+		return err
+	}
+	if _, err := s.getActiveClassroom(ctx, *badge.ClassroomID); err != nil {
+		return err
+	}
+	_, err := s.resolveCategory(ctx, *badge.ClassroomID, TransactionDetails{CategoryID: badge.CategoryID})
+	return err
+}
+
+// getOwnBadge retrieves a badge the classroom defined; system badges can't be changed
+func (s *Service) getOwnBadge(ctx context.Context, classroomID, badgeID int64) (*Badge, error) {
+	badge, err := s.repo.GetBadge(ctx, classroomID, badgeID)
+	if err != nil {
+		return nil, err
+	}
+	if badge.ClassroomID == nil {
+		return nil, errors.ErrForbidden("system badges cannot be changed")
+	}
+	return badge, nil
+}
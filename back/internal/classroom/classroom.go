@@ -4,8 +4,30 @@ import (
 	"time"
 
 	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
 )
 
+// Errors returned when a neuron transfer would leave a balance negative
+var (
+	ErrInsufficientClassroomNeurons = errors.ErrBadRequest("not enough neurons in the classroom")
+	ErrInsufficientStudentNeurons   = errors.ErrBadRequest("student does not have enough neurons")
+	ErrInsufficientGroupNeurons     = errors.ErrBadRequest("not enough neurons in the group wallet")
+)
+
+// ErrAmountNotPositive is returned when a transaction's amount is zero or
+// negative; taking neurons away is a deduction
+var ErrAmountNotPositive = errors.ErrBadRequest("amount must be positive")
+
+// ErrStudentNotInClassroom is returned when a student has no wallet in the
+// classroom because they are not enrolled in it
+var ErrStudentNotInClassroom = errors.ErrBadRequest("student is not in this classroom")
+
+// ErrClassroomArchived is returned for changes an archived classroom doesn't allow
+var ErrClassroomArchived = errors.ErrConflict("classroom is archived")
+
+// PurgeRetention is how long an archived classroom is kept before it can be purged
+const PurgeRetention = 90 * 24 * time.Hour
+
 type Student struct {
 	user.User `json:"user"`
 	Neurons   int `json:"neurons"`
@@ -16,8 +38,12 @@ type Classroom struct {
 	ID               int64     `json:"id" db:"id"`
 	Name             string    `json:"name" db:"name"`
 	TeacherID        int64     `json:"teacher_id" db:"teacher_id"`
+	OrganizationID   int64     `json:"organization_id" db:"organization_id"`
 	AvailableNeurons int       `json:"available_neurons" db:"available_neurons"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	// ArchivedAt is set while the classroom is archived; archived classrooms
+	// are read-only
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
 }
 
 // ClassroomWithData represents a classroom with additional data about the teacher and students
@@ -25,6 +51,11 @@ type ClassroomWithData struct {
 	Classroom
 	Teacher  user.User  `json:"teacher"`
 	Students []*Student `json:"students"`
+	// Groups are the classroom's student groups with their members' total balance
+	Groups []*StudentGroup `json:"groups" db:"-"`
+	// Enrollment is set when a student listing their classrooms has asked to join
+	// this one but is not enrolled yet
+	Enrollment *EnrollmentRequest `json:"enrollment,omitempty" db:"-"`
 }
 
 // UserClassroom represents the relationship between a user (student) and a classroom
@@ -34,12 +65,126 @@ type UserClassroom struct {
 	Neurons     int   `json:"neurons" db:"neurons"`
 }
 
+// Neuron transaction types
+const (
+	TransactionTypeAssignment = "assignment"
+	TransactionTypeReturn     = "return"
+	TransactionTypeReversal   = "reversal"
+	TransactionTypeRedemption = "redemption"
+	TransactionTypeHold       = "hold"
+	TransactionTypeRelease    = "release"
+	// TransactionTypeGroupFunding moves neurons from the classroom pool to a group wallet
+	TransactionTypeGroupFunding = "group_funding"
+	// TransactionTypeContribution moves neurons from a student's wallet to their group's wallet
+	TransactionTypeContribution = "contribution"
+	// TransactionTypeGift moves neurons from one student's wallet to another's
+	TransactionTypeGift = "gift"
+	// TransactionTypeExpiry returns a student's expired neurons to the classroom pool
+	TransactionTypeExpiry = "expiry"
+	// TransactionTypeDeduction takes neurons from a student back to the classroom pool
+	TransactionTypeDeduction = "deduction"
+)
+
+// transactionTypes are the valid values of NeuronTransaction.TransactionType
+var transactionTypes = map[string]bool{
+	TransactionTypeAssignment:   true,
+	TransactionTypeReturn:       true,
+	TransactionTypeReversal:     true,
+	TransactionTypeRedemption:   true,
+	TransactionTypeHold:         true,
+	TransactionTypeRelease:      true,
+	TransactionTypeGroupFunding: true,
+	TransactionTypeContribution: true,
+	TransactionTypeGift:         true,
+	TransactionTypeExpiry:       true,
+	TransactionTypeDeduction:    true,
+}
+
 // NeuronTransaction represents a transaction of neurons
 type NeuronTransaction struct {
-	ID              int64     `json:"id" db:"id"`
-	ClassroomID     int64     `json:"classroom_id" db:"classroom_id"`
-	UserID          int64     `json:"user_id" db:"user_id"`
-	Amount          int       `json:"amount" db:"amount"`
-	TransactionType string    `json:"transaction_type" db:"transaction_type"`
-	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	ID              int64  `json:"id" db:"id"`
+	ClassroomID     int64  `json:"classroom_id" db:"classroom_id"`
+	UserID          int64  `json:"user_id" db:"user_id"`
+	Amount          int    `json:"amount" db:"amount"`
+	TransactionType string `json:"transaction_type" db:"transaction_type"`
+	// ReversesID is the transaction a reversal compensates
+	ReversesID *int64 `json:"reverses_id,omitempty" db:"reverses_id"`
+	CategoryID *int64 `json:"category_id,omitempty" db:"category_id"`
+	// RewardID is the reward a redemption paid for
+	RewardID *int64 `json:"reward_id,omitempty" db:"reward_id"`
+	// BatchID groups the transactions of a bulk operation
+	BatchID *int64 `json:"batch_id,omitempty" db:"batch_id"`
+	// GroupID is the group whose wallet was the counterparty; UserID is then
+	// the member or staff member who moved the neurons
+	GroupID *int64 `json:"group_id,omitempty" db:"group_id"`
+	// RecipientID is the student a gift from UserID went to
+	RecipientID *int64 `json:"recipient_id,omitempty" db:"recipient_id"`
+	// CreatedBy is the user who performed the transaction
+	CreatedBy *int64    `json:"created_by,omitempty" db:"created_by"`
+	Note      string    `json:"note" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TransactionDetails explains why a transaction happened
+type TransactionDetails struct {
+	CategoryID *int64 `json:"category_id"`
+	Note       string `json:"note"`
+}
+
+// TransactionCategory is a teacher-defined reason for awarding neurons
+type TransactionCategory struct {
+	ID          int64  `json:"id" db:"id"`
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	Name        string `json:"name" db:"name"`
+	// DefaultAmount is sent when a transaction in this category has no amount
+	DefaultAmount int       `json:"default_amount" db:"default_amount"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// CategoryTotal is how many neurons a student earned in one category
+type CategoryTotal struct {
+	CategoryID *int64 `json:"category_id" db:"category_id"`
+	Name       string `json:"name" db:"name"`
+	Count      int    `json:"count" db:"count"`
+	Total      int    `json:"total" db:"total"`
+}
+
+// BulkTransfer is one student's share of a bulk neuron distribution
+type BulkTransfer struct {
+	StudentID int64 `json:"student_id"`
+	Amount    int   `json:"amount"`
+}
+
+// TransactionBatch groups the transactions applied together by a bulk operation
+type TransactionBatch struct {
+	ID           int64                `json:"id" db:"id"`
+	ClassroomID  int64                `json:"classroom_id" db:"classroom_id"`
+	CreatedBy    int64                `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time            `json:"created_at" db:"created_at"`
+	Transactions []*NeuronTransaction `json:"transactions" db:"-"`
+}
+
+// TransactionFilter narrows down a neuron transaction history query
+type TransactionFilter struct {
+	ClassroomID *int64
+	// UserID matches the user's own transactions and the gifts they received
+	UserID          *int64
+	GroupID         *int64
+	CategoryID      *int64
+	TransactionType string
+	From            *time.Time
+	To              *time.Time
+	MinAmount       *int
+	MaxAmount       *int
+	// Ascending sorts oldest first; by default the newest transactions come first
+	Ascending bool
+	// Cursor is the opaque NextCursor of a previous page
+	Cursor string
+	Limit  int
+}
+
+// TransactionPage is one page of a neuron transaction history
+type TransactionPage struct {
+	Transactions []*NeuronTransaction `json:"transactions"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
 }
@@ -0,0 +1,41 @@
+package classroom
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// transactionCursor is the position of the last transaction of a page
+type transactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+func encodeCursor(t *NeuronTransaction) string {
+	raw := t.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(t.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*transactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.ErrBadRequest("invalid cursor")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.ErrBadRequest("invalid cursor")
+	}
+
+	var c transactionCursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, errors.ErrBadRequest("invalid cursor")
+	}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, errors.ErrBadRequest("invalid cursor")
+	}
+	return &c, nil
+}
@@ -0,0 +1,28 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/user"
+)
+
+// Enrollment request statuses
+const (
+	EnrollmentRequestPending  = "pending"
+	EnrollmentRequestApproved = "approved"
+	EnrollmentRequestRejected = "rejected"
+)
+
+// EnrollmentRequest is a student's request to join a classroom that requires the
+// teacher's approval
+type EnrollmentRequest struct {
+	ID          int64      `json:"id" db:"id"`
+	ClassroomID int64      `json:"classroom_id" db:"classroom_id"`
+	UserID      int64      `json:"user_id" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	Reason      string     `json:"reason" db:"reason"`
+	DecidedBy   *int64     `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	Student     user.User  `json:"student" db:"student"`
+}
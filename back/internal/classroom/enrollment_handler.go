@@ -0,0 +1,99 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) ListEnrollmentRequests(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	requests, err := h.service.ListEnrollmentRequests(c.Context(), classroomID, c.Query("status", EnrollmentRequestPending))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(requests)
+}
+
+func (h *Handler) ApproveEnrollmentRequest(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, requestID, err := enrollmentRequestParams(c)
+	if err != nil {
+		return err
+	}
+
+	requests, err := h.service.ApproveEnrollmentRequests(c.Context(), u.ID, classroomID, []int64{requestID})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(requests[0])
+}
+
+func (h *Handler) ApproveEnrollmentRequests(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		RequestIDs []int64 `json:"request_ids"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	requests, err := h.service.ApproveEnrollmentRequests(c.Context(), u.ID, classroomID, input.RequestIDs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(requests)
+}
+
+func (h *Handler) RejectEnrollmentRequest(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, requestID, err := enrollmentRequestParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	request, err := h.service.RejectEnrollmentRequest(c.Context(), u.ID, classroomID, requestID, input.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(request)
+}
+
+func enrollmentRequestParams(c *fiber.Ctx) (int64, int64, error) {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	requestID, err := strconv.ParseInt(c.Params("requestId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid enrollment request id")
+	}
+
+	return classroomID, requestID, nil
+}
@@ -0,0 +1,143 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+const enrollmentRequestQuery = `
+	SELECT er.id, er.classroom_id, er.user_id, er.status, er.reason, er.decided_by, er.decided_at, er.created_at,
+		   u.id AS "student.id", u.name AS "student.name", u.email AS "student.email",
+		   u.role AS "student.role", u.created_at AS "student.created_at"
+	FROM enrollment_requests er
+	JOIN users u ON u.id = er.user_id
+`
+
+func (r *PostgresRepository) GetEnrollmentRequest(ctx context.Context, id int64) (*EnrollmentRequest, error) {
+	var request EnrollmentRequest
+	err := r.db.GetContext(ctx, &request, enrollmentRequestQuery+"WHERE er.id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("enrollment request not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get enrollment request: %v", err))
+	}
+	return &request, nil
+}
+
+// ListEnrollmentRequests lists a classroom's enrollment requests, oldest first;
+// an empty status lists them all
+func (r *PostgresRepository) ListEnrollmentRequests(ctx context.Context, classroomID int64, status string) ([]*EnrollmentRequest, error) {
+	requests := []*EnrollmentRequest{}
+	err := r.db.SelectContext(ctx, &requests, enrollmentRequestQuery+`
+		WHERE er.classroom_id = $1 AND ($2 = '' OR er.status = $2)
+		ORDER BY er.created_at, er.id
+	`, classroomID, status)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list enrollment requests: %v", err))
+	}
+	return requests, nil
+}
+
+// ApproveEnrollmentRequests enrolls the students of the given pending requests.
+// Either all of them are approved or none is.
+func (r *PostgresRepository) ApproveEnrollmentRequests(ctx context.Context, classroomID int64, ids []int64, decidedBy int64, decidedAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var studentIDs []int64
+	err = tx.SelectContext(ctx, &studentIDs, `
+		SELECT user_id FROM enrollment_requests
+		WHERE id = ANY($1) AND classroom_id = $2 AND status = 'pending'
+		ORDER BY id
+		FOR UPDATE
+	`, pq.Array(ids), classroomID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock enrollment requests: %v", err))
+	}
+	if len(studentIDs) != len(ids) {
+		return errors.ErrConflict("some enrollment requests are not pending")
+	}
+
+	for _, studentID := range studentIDs {
+		// The teacher may have added the student directly in the meantime
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO users_classrooms (user_id, classroom_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, studentID, classroomID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to add student to classroom: %v", err))
+		}
+		if _, err := ensureLedgerAccount(ctx, tx, classroomID, AccountTypeStudent, &studentID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE enrollment_requests
+		SET status = 'approved', decided_by = $2, decided_at = $3
+		WHERE id = ANY($1)
+	`, pq.Array(ids), decidedBy, decidedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to approve enrollment requests: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// RejectEnrollmentRequest closes a pending request with the teacher's reason
+func (r *PostgresRepository) RejectEnrollmentRequest(ctx context.Context, request *EnrollmentRequest) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE enrollment_requests
+		SET status = 'rejected', reason = $2, decided_by = $3, decided_at = $4
+		WHERE id = $1 AND status = 'pending'
+	`, request.ID, request.Reason, request.DecidedBy, request.DecidedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to reject enrollment request: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrConflict("enrollment request is not pending")
+	}
+	return nil
+}
+
+// getOpenEnrollmentRequests returns the student's latest request per classroom
+// for classrooms they are not enrolled in, if it is still pending or was rejected
+func (r *PostgresRepository) getOpenEnrollmentRequests(ctx context.Context, userID int64) (map[int64]*EnrollmentRequest, error) {
+	var requests []*EnrollmentRequest
+	err := r.db.SelectContext(ctx, &requests, `
+		SELECT * FROM (
+			SELECT DISTINCT ON (er.classroom_id)
+				   er.id, er.classroom_id, er.user_id, er.status, er.reason, er.decided_by, er.decided_at, er.created_at,
+				   u.id AS "student.id", u.name AS "student.name", u.email AS "student.email",
+				   u.role AS "student.role", u.created_at AS "student.created_at"
+			FROM enrollment_requests er
+			JOIN users u ON u.id = er.user_id
+			WHERE er.user_id = $1
+			  AND NOT EXISTS (SELECT 1 FROM users_classrooms uc WHERE uc.classroom_id = er.classroom_id AND uc.user_id = er.user_id)
+			ORDER BY er.classroom_id, er.created_at DESC, er.id DESC
+		) latest
+		WHERE status IN ('pending', 'rejected')
+	`, userID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get enrollment requests: %v", err))
+	}
+
+	byClassroom := make(map[int64]*EnrollmentRequest, len(requests))
+	for _, request := range requests {
+		byClassroom[request.ClassroomID] = request
+	}
+	return byClassroom, nil
+}
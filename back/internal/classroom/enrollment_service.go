@@ -0,0 +1,124 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// ListEnrollmentRequests retrieves a classroom's enrollment requests with the given status
+func (s *Service) ListEnrollmentRequests(ctx context.Context, classroomID int64, status string) ([]*EnrollmentRequest, error) {
+	switch status {
+	case "", EnrollmentRequestPending, EnrollmentRequestApproved, EnrollmentRequestRejected:
+	default:
+		return nil, errors.ErrBadRequest("invalid status")
+	}
+	return s.repo.ListEnrollmentRequests(ctx, classroomID, status)
+}
+
+// ApproveEnrollmentRequests enrolls the students behind one or more pending requests
+func (s *Service) ApproveEnrollmentRequests(ctx context.Context, teacherID, classroomID int64, requestIDs []int64) ([]*EnrollmentRequest, error) {
+	if len(requestIDs) == 0 {
+		return nil, errors.ErrBadRequest("no enrollment requests to approve")
+	}
+
+	ids := make([]int64, 0, len(requestIDs))
+	seen := make(map[int64]bool, len(requestIDs))
+	for _, id := range requestIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	pending := make([]*EnrollmentRequest, 0, len(ids))
+	for _, id := range ids {
+		request, err := s.getEnrollmentRequest(ctx, classroomID, id)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, request)
+	}
+
+	approved := make([]*EnrollmentRequest, 0, len(ids))
+	// Each approval gets its own entry, recorded in the batch's transaction
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.ApproveEnrollmentRequests(ctx, classroomID, ids, teacherID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		for i, id := range ids {
+			request, err := s.repo.GetEnrollmentRequest(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			approved = append(approved, request)
+
+			change := classroomChange(classroomID, ActionEnrollmentApproved, "enrollment_request", id, pending[i], request)
+			if err := s.auditService.Record(ctx, *change); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return approved, nil
+}
+
+// RejectEnrollmentRequest turns a student away; the reason is shown to them
+func (s *Service) RejectEnrollmentRequest(ctx context.Context, teacherID, classroomID, requestID int64, reason string) (*EnrollmentRequest, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	request, err := s.getEnrollmentRequest(ctx, classroomID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *request
+	now := time.Now()
+	request.Reason = reason
+	request.DecidedBy = &teacherID
+	request.DecidedAt = &now
+
+	var rejected *EnrollmentRequest
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.RejectEnrollmentRequest(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		rejected, err = s.repo.GetEnrollmentRequest(ctx, requestID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionEnrollmentRejected, "enrollment_request", requestID, &before, rejected), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rejected, nil
+}
+
+func (s *Service) getEnrollmentRequest(ctx context.Context, classroomID, requestID int64) (*EnrollmentRequest, error) {
+	request, err := s.repo.GetEnrollmentRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("enrollment request not found")
+	}
+	return request, nil
+}
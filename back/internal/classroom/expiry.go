@@ -0,0 +1,115 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// What happens to student balances at the end of a term
+const (
+	TermEndCarryOver = "carry_over"
+	TermEndReset     = "reset"
+	// TermEndConvert keeps ConversionPercent of each balance for the next
+	// term and returns the rest to the pool
+	TermEndConvert = "convert"
+)
+
+// defaultExpiringWithin is how far ahead expiring neurons are shown by default
+const defaultExpiringWithin = 7 * 24 * time.Hour
+
+// NeuronPolicy is a classroom's rules on neurons expiring, on balances at the
+// end of a term and on how far deductions can go. Neurons don't expire until
+// a teacher sets an expiry.
+type NeuronPolicy struct {
+	ClassroomID int64 `json:"classroom_id" db:"classroom_id"`
+	// ExpiresAfterDays is how long granted neurons last; nil means they
+	// never expire. Changing it doesn't affect neurons already granted.
+	ExpiresAfterDays  *int   `json:"expires_after_days,omitempty" db:"expires_after_days"`
+	TermEndAction     string `json:"term_end_action" db:"term_end_action"`
+	ConversionPercent int    `json:"conversion_percent" db:"conversion_percent"`
+	// AllowNegativeBalance lets deductions take students below zero
+	AllowNegativeBalance bool       `json:"allow_negative_balance" db:"allow_negative_balance"`
+	UpdatedAt            *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// NeuronPolicyUpdate changes some of a classroom's neuron rules; rules left out are kept
+type NeuronPolicyUpdate struct {
+	// ExpiresAfterDays set to null turns expiry off
+	ExpiresAfterDays     Optional[int] `json:"expires_after_days"`
+	TermEndAction        *string       `json:"term_end_action"`
+	ConversionPercent    *int          `json:"conversion_percent"`
+	AllowNegativeBalance *bool         `json:"allow_negative_balance"`
+}
+
+// apply merges the update into the stored policy
+func (u *NeuronPolicyUpdate) apply(p *NeuronPolicy) {
+	u.ExpiresAfterDays.apply(&p.ExpiresAfterDays)
+	if u.TermEndAction != nil {
+		p.TermEndAction = *u.TermEndAction
+	}
+	if u.ConversionPercent != nil {
+		p.ConversionPercent = *u.ConversionPercent
+	}
+	if u.AllowNegativeBalance != nil {
+		p.AllowNegativeBalance = *u.AllowNegativeBalance
+	}
+}
+
+// NeuronLot is neurons granted to a student at once, which expire together.
+// Every debit of the student's wallet spends their lots soonest to expire
+// first; neurons credited outside of a lot, such as gifts and refunds, never
+// expire.
+type NeuronLot struct {
+	ID            int64 `json:"id" db:"id"`
+	ClassroomID   int64 `json:"classroom_id" db:"classroom_id"`
+	UserID        int64 `json:"user_id" db:"user_id"`
+	TransactionID int64 `json:"transaction_id" db:"transaction_id"`
+	Amount        int   `json:"amount" db:"amount"`
+	Spent         int   `json:"spent" db:"spent"`
+	// Remaining is the part of the lot the student hasn't spent yet
+	Remaining     int        `json:"remaining" db:"remaining"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	ExpiredAmount int        `json:"expired_amount" db:"expired_amount"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty" db:"processed_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ExpiringNeurons are a student's neurons that expire soon
+type ExpiringNeurons struct {
+	Total int          `json:"total"`
+	Lots  []*NeuronLot `json:"lots"`
+}
+
+// TermEndResult describes the balances returned to the pool at the end of a term
+type TermEndResult struct {
+	Action string `json:"action"`
+	// Batch holds the expiry transactions; nil when balances were carried over
+	Batch *TransactionBatch `json:"batch,omitempty"`
+}
+
+func (p *NeuronPolicy) validate() error {
+	if p.ExpiresAfterDays != nil && (*p.ExpiresAfterDays <= 0 || *p.ExpiresAfterDays > 3650) {
+		return errors.ErrBadRequest("expires_after_days must be between 1 and 3650")
+	}
+	switch p.TermEndAction {
+	case TermEndCarryOver, TermEndReset, TermEndConvert:
+	default:
+		return errors.ErrBadRequest("term_end_action must be carry_over, reset or convert")
+	}
+	if p.ConversionPercent < 0 || p.ConversionPercent > 100 {
+		return errors.ErrBadRequest("conversion_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// kept returns how much of a balance the student keeps at the end of a term
+func (p *NeuronPolicy) kept(balance int) int {
+	switch p.TermEndAction {
+	case TermEndReset:
+		return 0
+	case TermEndConvert:
+		return balance * p.ConversionPercent / 100
+	}
+	return balance
+}
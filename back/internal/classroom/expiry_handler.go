@@ -0,0 +1,88 @@
+package classroom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetNeuronPolicy(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	policy, err := h.service.GetNeuronPolicy(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(policy)
+}
+
+func (h *Handler) UpdateNeuronPolicy(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	// Only the rules in the body are changed
+	var update NeuronPolicyUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	policy, err := h.service.UpdateNeuronPolicy(c.Context(), classroomID, &update)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(policy)
+}
+
+func (h *Handler) GetExpiringNeurons(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid user id")
+	}
+
+	var within time.Duration
+	if value := c.Query("days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			return errors.ErrBadRequest("invalid days")
+		}
+		within = time.Duration(days) * 24 * time.Hour
+	}
+
+	expiring, err := h.service.GetExpiringNeurons(c.Context(), classroomID, userID, within)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(expiring)
+}
+
+func (h *Handler) EndTerm(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	result, err := h.service.EndTerm(c.Context(), u.ID, classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
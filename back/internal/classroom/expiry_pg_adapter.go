@@ -0,0 +1,285 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+const neuronLotColumns = "id, classroom_id, user_id, transaction_id, amount, spent, amount - spent AS remaining, expires_at, expired_amount, processed_at, created_at"
+
+func (r *PostgresRepository) GetNeuronPolicy(ctx context.Context, classroomID int64) (*NeuronPolicy, error) {
+	return getNeuronPolicy(ctx, r.db, classroomID)
+}
+
+func (r *PostgresRepository) UpdateNeuronPolicy(ctx context.Context, policy *NeuronPolicy) error {
+	query := `
+		INSERT INTO classroom_neuron_policies (classroom_id, expires_after_days, term_end_action, conversion_percent,
+			allow_negative_balance, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (classroom_id) DO UPDATE
+		SET expires_after_days = EXCLUDED.expires_after_days,
+			term_end_action = EXCLUDED.term_end_action,
+			conversion_percent = EXCLUDED.conversion_percent,
+			allow_negative_balance = EXCLUDED.allow_negative_balance,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.ExecContext(ctx, query, policy.ClassroomID, policy.ExpiresAfterDays, policy.TermEndAction,
+		policy.ConversionPercent, policy.AllowNegativeBalance, policy.UpdatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update neuron policy: %v", err))
+	}
+	return nil
+}
+
+// GetStudentLots retrieves the lots a student hasn't spent or lost yet, with
+// what remains of each, soonest to expire first
+func (r *PostgresRepository) GetStudentLots(ctx context.Context, classroomID, studentID int64) ([]*NeuronLot, error) {
+	query := `
+		SELECT ` + neuronLotColumns + `
+		FROM neuron_lots
+		WHERE classroom_id = $1 AND user_id = $2 AND processed_at IS NULL AND spent < amount
+		ORDER BY expires_at, id
+	`
+	lots := []*NeuronLot{}
+	err := r.db.SelectContext(ctx, &lots, query, classroomID, studentID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get neuron lots: %v", err))
+	}
+	return lots, nil
+}
+
+// ListExpiredLots retrieves the unprocessed lots of active classrooms that
+// have expired, soonest expired first
+func (r *PostgresRepository) ListExpiredLots(ctx context.Context, now time.Time) ([]int64, error) {
+	query := `
+		SELECT l.id
+		FROM neuron_lots l
+		JOIN classrooms c ON c.id = l.classroom_id
+		WHERE l.processed_at IS NULL AND l.expires_at <= $1 AND c.archived_at IS NULL
+		ORDER BY l.expires_at, l.id
+	`
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, query, now)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list expired neuron lots: %v", err))
+	}
+	return ids, nil
+}
+
+// ExpireLot returns what remains of an expired lot to the classroom pool. It
+// returns nil when nothing remained or the lot was already processed.
+func (r *PostgresRepository) ExpireLot(ctx context.Context, id int64, now time.Time) (*NeuronTransaction, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var lot NeuronLot
+	err = tx.GetContext(ctx, &lot, "SELECT "+neuronLotColumns+" FROM neuron_lots WHERE id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get neuron lot: %v", err))
+	}
+
+	// Lock the wallet before the lot, in the order spending takes them, so
+	// the student can't spend the lot while it expires. A student removed
+	// from the classroom keeps their wallet, so it is looked up without
+	// checking the enrollment.
+	var transaction *NeuronTransaction
+	var wallet LedgerAccount
+	err = tx.GetContext(ctx, &wallet, `
+		SELECT id, classroom_id, user_id, account_type, balance, created_at
+		FROM ledger_accounts
+		WHERE classroom_id = $1 AND user_id = $2 AND account_type = 'student'
+		FOR UPDATE
+	`, lot.ClassroomID, lot.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to lock student account: %v", err))
+	}
+
+	err = tx.GetContext(ctx, &lot, "SELECT "+neuronLotColumns+" FROM neuron_lots WHERE id = $1 AND processed_at IS NULL FOR UPDATE", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to lock neuron lot: %v", err))
+	}
+
+	// Neurons on hold are out of reach until the hold is settled, and a
+	// student in debt has nothing left to lose
+	amount := min(lot.Remaining, max(wallet.Balance, 0))
+
+	if amount > 0 {
+		pool, err := getClassroomAccount(ctx, tx, lot.ClassroomID, AccountTypeClassroom)
+		if err != nil {
+			return nil, err
+		}
+		transaction = &NeuronTransaction{
+			ClassroomID:     lot.ClassroomID,
+			UserID:          lot.UserID,
+			Amount:          amount,
+			TransactionType: TransactionTypeExpiry,
+			Note:            "neurons expired",
+			CreatedAt:       now,
+		}
+		if err := transfer(ctx, tx, transaction, &wallet, pool); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE neuron_lots SET expired_amount = $2, processed_at = $3 WHERE id = $1", lot.ID, amount, now)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to update neuron lot: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return transaction, nil
+}
+
+// EndTerm returns to the pool what the policy doesn't let students keep of
+// their balances. Kept neurons no longer expire; negative balances are left
+// as they are.
+func (r *PostgresRepository) EndTerm(ctx context.Context, batch *TransactionBatch, policy *NeuronPolicy) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	pool, err := getClassroomAccount(ctx, tx, batch.ClassroomID, AccountTypeClassroom)
+	if err != nil {
+		return err
+	}
+
+	// Lock the wallets of every enrolled student, in ID order like the postings
+	var wallets []*LedgerAccount
+	err = tx.SelectContext(ctx, &wallets, `
+		SELECT la.id, la.classroom_id, la.user_id, la.account_type, la.balance, la.created_at
+		FROM ledger_accounts la
+		JOIN users_classrooms uc ON uc.classroom_id = la.classroom_id AND uc.user_id = la.user_id
+		WHERE la.classroom_id = $1 AND la.account_type = 'student'
+		ORDER BY la.id
+		FOR UPDATE OF la
+	`, batch.ClassroomID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock student accounts: %v", err))
+	}
+
+	err = tx.GetContext(ctx, &batch.ID, `
+		INSERT INTO neuron_transaction_batches (classroom_id, created_by, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, batch.ClassroomID, batch.CreatedBy, batch.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create transaction batch: %v", err))
+	}
+
+	batch.Transactions = []*NeuronTransaction{}
+	for _, wallet := range wallets {
+		amount := wallet.Balance - policy.kept(wallet.Balance)
+		if amount <= 0 {
+			continue
+		}
+		transaction := &NeuronTransaction{
+			ClassroomID:     batch.ClassroomID,
+			UserID:          *wallet.UserID,
+			Amount:          amount,
+			TransactionType: TransactionTypeExpiry,
+			BatchID:         &batch.ID,
+			CreatedBy:       &batch.CreatedBy,
+			Note:            "end of term",
+			CreatedAt:       batch.CreatedAt,
+		}
+		if err := transfer(ctx, tx, transaction, wallet, pool); err != nil {
+			return err
+		}
+		batch.Transactions = append(batch.Transactions, transaction)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE neuron_lots SET processed_at = $2
+		WHERE classroom_id = $1 AND processed_at IS NULL
+	`, batch.ClassroomID, batch.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to close neuron lots: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func getNeuronPolicy(ctx context.Context, q sqlx.QueryerContext, classroomID int64) (*NeuronPolicy, error) {
+	query := `
+		SELECT classroom_id, expires_after_days, term_end_action, conversion_percent, allow_negative_balance, updated_at
+		FROM classroom_neuron_policies
+		WHERE classroom_id = $1
+	`
+	var policy NeuronPolicy
+	err := sqlx.GetContext(ctx, q, &policy, query, classroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &NeuronPolicy{ClassroomID: classroomID, TermEndAction: TermEndCarryOver, ConversionPercent: 100}, nil
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get neuron policy: %v", err))
+	}
+	return &policy, nil
+}
+
+// grantLot opens a lot for an assignment when the classroom's neurons expire
+func grantLot(ctx context.Context, tx *database.Tx, transaction *NeuronTransaction) error {
+	policy, err := getNeuronPolicy(ctx, tx, transaction.ClassroomID)
+	if err != nil {
+		return err
+	}
+	if policy.ExpiresAfterDays == nil {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO neuron_lots (classroom_id, user_id, transaction_id, amount, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, transaction.ClassroomID, transaction.UserID, transaction.ID, transaction.Amount,
+		transaction.CreatedAt.AddDate(0, 0, *policy.ExpiresAfterDays), transaction.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create neuron lot: %v", err))
+	}
+	return nil
+}
+
+// spendLots marks amount neurons of a student's open lots as spent, soonest
+// to expire first. Reversing an assignment spends its own lot first. What the
+// lots can't cover was credited outside of them.
+func spendLots(ctx context.Context, tx *database.Tx, classroomID, studentID int64, amount int, transactionID *int64) error {
+	_, err := tx.ExecContext(ctx, `
+		WITH lots AS (
+			SELECT l.id, l.amount - l.spent AS unspent,
+				   SUM(l.amount - l.spent) OVER (
+					   ORDER BY COALESCE(l.transaction_id = (SELECT reverses_id FROM neuron_transactions WHERE id = $4), FALSE) DESC,
+								l.expires_at, l.id
+				   ) AS running
+			FROM neuron_lots l
+			WHERE l.classroom_id = $1 AND l.user_id = $2 AND l.processed_at IS NULL AND l.spent < l.amount
+		)
+		UPDATE neuron_lots l
+		SET spent = l.spent + LEAST(lots.unspent, $3 - (lots.running - lots.unspent))
+		FROM lots
+		WHERE l.id = lots.id AND lots.running - lots.unspent < $3
+	`, classroomID, studentID, amount, transactionID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to spend neuron lots: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package classroom
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// expireAfterOneDay makes the neurons sent from now on expire after a day
+func (f *transferFixture) expireAfterOneDay(t *testing.T) {
+	t.Helper()
+	days, updatedAt := 1, time.Now()
+	err := f.repo.UpdateNeuronPolicy(context.Background(), &NeuronPolicy{
+		ClassroomID:       f.classroomID,
+		ExpiresAfterDays:  &days,
+		TermEndAction:     TermEndCarryOver,
+		ConversionPercent: 100,
+		UpdatedAt:         &updatedAt,
+	})
+	if err != nil {
+		t.Fatalf("failed to set neuron policy: %v", err)
+	}
+}
+
+func (f *transferFixture) remainingLots(t *testing.T) int {
+	t.Helper()
+	lots, err := f.repo.GetStudentLots(context.Background(), f.classroomID, f.studentID)
+	if err != nil {
+		t.Fatalf("failed to get lots: %v", err)
+	}
+	remaining := 0
+	for _, lot := range lots {
+		remaining += lot.Remaining
+	}
+	return remaining
+}
+
+func TestSpendingTakesLotsBeforeNeuronsThatDontExpire(t *testing.T) {
+	ctx := context.Background()
+	// The wallet's 5 neurons were sent before expiry was turned on
+	f := newTransferFixture(t, 100, 5)
+	f.expireAfterOneDay(t)
+
+	if err := f.repo.TransferNeurons(ctx, f.transaction(TransactionTypeAssignment, 10)); err != nil {
+		t.Fatalf("failed to send neurons: %v", err)
+	}
+	if err := f.repo.TransferNeuronsToClassroom(ctx, f.transaction(TransactionTypeReturn, 10)); err != nil {
+		t.Fatalf("failed to return neurons: %v", err)
+	}
+
+	if remaining := f.remainingLots(t); remaining != 0 {
+		t.Errorf("expected the lot to be spent, %d remain", remaining)
+	}
+	if _, wallet := f.balances(t); wallet != 5 {
+		t.Errorf("expected the student to keep 5, got %d", wallet)
+	}
+}
+
+func TestReversingAnAssignmentSpendsItsLot(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t, 100, 0)
+	f.expireAfterOneDay(t)
+
+	first := f.transaction(TransactionTypeAssignment, 10)
+	if err := f.repo.TransferNeurons(ctx, first); err != nil {
+		t.Fatalf("failed to send neurons: %v", err)
+	}
+	if err := f.repo.TransferNeurons(ctx, f.transaction(TransactionTypeAssignment, 20)); err != nil {
+		t.Fatalf("failed to send neurons: %v", err)
+	}
+
+	reversal := f.transaction(TransactionTypeReversal, 10)
+	reversal.ReversesID = &first.ID
+	if err := f.repo.ReverseNeuronTransaction(ctx, reversal); err != nil {
+		t.Fatalf("failed to reverse assignment: %v", err)
+	}
+
+	lots, err := f.repo.GetStudentLots(ctx, f.classroomID, f.studentID)
+	if err != nil {
+		t.Fatalf("failed to get lots: %v", err)
+	}
+	if len(lots) != 1 || lots[0].TransactionID == first.ID || lots[0].Remaining != 20 {
+		t.Errorf("expected only the second lot to remain, with 20, got %+v", lots)
+	}
+}
+
+func TestExpireLotOfRemovedStudent(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t, 100, 0)
+	f.expireAfterOneDay(t)
+
+	if err := f.repo.TransferNeurons(ctx, f.transaction(TransactionTypeAssignment, 10)); err != nil {
+		t.Fatalf("failed to send neurons: %v", err)
+	}
+	if err := f.repo.RemoveStudentFromClassroom(ctx, f.classroomID, f.studentID); err != nil {
+		t.Fatalf("failed to remove student: %v", err)
+	}
+
+	now := time.Now().AddDate(0, 0, 2)
+	ids, err := f.repo.ListExpiredLots(ctx, now)
+	if err != nil {
+		t.Fatalf("failed to list expired lots: %v", err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 expired lot, got %d", len(ids))
+	}
+
+	transaction, err := f.repo.ExpireLot(ctx, ids[0], now)
+	if err != nil {
+		t.Fatalf("failed to expire lot: %v", err)
+	}
+	if transaction == nil || transaction.Amount != 10 {
+		t.Fatalf("expected 10 neurons to expire, got %+v", transaction)
+	}
+	classroom, err := f.repo.GetClassroom(ctx, f.classroomID)
+	if err != nil {
+		t.Fatalf("failed to get classroom: %v", err)
+	}
+	if classroom.AvailableNeurons != 100 {
+		t.Errorf("expected the pool to hold 100 again, got %d", classroom.AvailableNeurons)
+	}
+}
@@ -0,0 +1,149 @@
+package classroom
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+)
+
+// GetNeuronPolicy retrieves the classroom's rules on expiry and the end of term
+func (s *Service) GetNeuronPolicy(ctx context.Context, classroomID int64) (*NeuronPolicy, error) {
+	if _, err := s.repo.GetClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetNeuronPolicy(ctx, classroomID)
+}
+
+// UpdateNeuronPolicy changes the classroom's rules on expiry, the end of term
+// and deductions. Rules the update leaves out keep their current value. A
+// new expiry applies to neurons granted from then on.
+func (s *Service) UpdateNeuronPolicy(ctx context.Context, classroomID int64, update *NeuronPolicyUpdate) (*NeuronPolicy, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	var after *NeuronPolicy
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.GetNeuronPolicy(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := *before
+		update.apply(&policy)
+		if err := policy.validate(); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		policy.UpdatedAt = &now
+
+		err = s.repo.UpdateNeuronPolicy(ctx, &policy)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetNeuronPolicy(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionNeuronPolicyUpdated, "classroom", classroomID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// GetExpiringNeurons retrieves what a student will lose to expiry within the
+// given time unless they spend it
+func (s *Service) GetExpiringNeurons(ctx context.Context, classroomID, studentID int64, within time.Duration) (*ExpiringNeurons, error) {
+	if _, err := s.repo.GetClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	if within <= 0 {
+		within = defaultExpiringWithin
+	}
+
+	lots, err := s.repo.GetStudentLots(ctx, classroomID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(within)
+	expiring := &ExpiringNeurons{Lots: []*NeuronLot{}}
+	for _, lot := range lots {
+		if lot.ExpiresAt.After(cutoff) {
+			break
+		}
+		expiring.Total += lot.Remaining
+		expiring.Lots = append(expiring.Lots, lot)
+	}
+	return expiring, nil
+}
+
+// ExpireNeurons returns what remains of every expired lot to its classroom
+// pool and returns how many lots still held neurons. A lot that fails is
+// logged and left for the next run.
+func (s *Service) ExpireNeurons(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.ListExpiredLots(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+			transaction, err := s.repo.ExpireLot(ctx, id, now)
+			if err != nil || transaction == nil {
+				return nil, err
+			}
+			expired++
+			return classroomChange(transaction.ClassroomID, ActionNeuronsExpired, "neuron_transaction", transaction.ID, nil, transaction), nil
+		})
+		if err != nil {
+			log.Printf("failed to expire neuron lot %d: %v", id, err)
+			continue
+		}
+	}
+	return expired, nil
+}
+
+// EndTerm applies the classroom's end of term policy to every student's
+// balance: carrying it over, resetting it to zero or converting part of it
+func (s *Service) EndTerm(ctx context.Context, teacherID, classroomID int64) (*TermEndResult, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	var result *TermEndResult
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		policy, err := s.repo.GetNeuronPolicy(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		result = &TermEndResult{Action: policy.TermEndAction}
+		if policy.TermEndAction != TermEndCarryOver {
+			result.Batch = &TransactionBatch{
+				ClassroomID: classroomID,
+				CreatedBy:   teacherID,
+				CreatedAt:   time.Now(),
+			}
+			err = s.repo.EndTerm(ctx, result.Batch, policy)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return classroomChange(classroomID, ActionTermEnded, "classroom", classroomID, nil, result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package classroom
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNeuronPolicyUpdateKeepsRulesLeftOut(t *testing.T) {
+	stored := NeuronPolicy{ExpiresAfterDays: intPtr(30), TermEndAction: TermEndConvert, ConversionPercent: 50, AllowNegativeBalance: true}
+
+	tests := []struct {
+		name string
+		body string
+		want NeuronPolicy
+	}{
+		{
+			name: "deduction floor only",
+			body: `{"allow_negative_balance": false}`,
+			want: NeuronPolicy{ExpiresAfterDays: intPtr(30), TermEndAction: TermEndConvert, ConversionPercent: 50},
+		},
+		{
+			name: "expiry only",
+			body: `{"expires_after_days": 7}`,
+			want: NeuronPolicy{ExpiresAfterDays: intPtr(7), TermEndAction: TermEndConvert, ConversionPercent: 50, AllowNegativeBalance: true},
+		},
+		{
+			name: "turn expiry off with null",
+			body: `{"expires_after_days": null}`,
+			want: NeuronPolicy{TermEndAction: TermEndConvert, ConversionPercent: 50, AllowNegativeBalance: true},
+		},
+		{
+			name: "term end",
+			body: `{"term_end_action": "reset", "conversion_percent": 100}`,
+			want: NeuronPolicy{ExpiresAfterDays: intPtr(30), TermEndAction: TermEndReset, ConversionPercent: 100, AllowNegativeBalance: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var update NeuronPolicyUpdate
+			if err := json.Unmarshal([]byte(tt.body), &update); err != nil {
+				t.Fatalf("failed to decode update: %v", err)
+			}
+			got := stored
+			update.apply(&got)
+
+			if (got.ExpiresAfterDays == nil) != (tt.want.ExpiresAfterDays == nil) ||
+				got.ExpiresAfterDays != nil && *got.ExpiresAfterDays != *tt.want.ExpiresAfterDays {
+				t.Errorf("expected expires_after_days %v, got %v", tt.want.ExpiresAfterDays, got.ExpiresAfterDays)
+			}
+			if got.TermEndAction != tt.want.TermEndAction || got.ConversionPercent != tt.want.ConversionPercent {
+				t.Errorf("expected term end %s at %d%%, got %s at %d%%",
+					tt.want.TermEndAction, tt.want.ConversionPercent, got.TermEndAction, got.ConversionPercent)
+			}
+			if got.AllowNegativeBalance != tt.want.AllowNegativeBalance {
+				t.Errorf("expected allow_negative_balance %v, got %v", tt.want.AllowNegativeBalance, got.AllowNegativeBalance)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Errors returned when a gift breaks the classroom's gifting rules
+var (
+	ErrGiftingDisabled         = errors.ErrForbidden("gifting is disabled in this classroom")
+	ErrSelfGift                = errors.ErrBadRequest("students can't gift neurons to themselves")
+	ErrGiftDailyCapReached     = errors.ErrForbidden("daily gifting cap reached")
+	ErrGiftExceedsMaxPerGift   = errors.ErrBadRequest("gift is larger than the classroom allows")
+	ErrRecipientNotInClassroom = errors.ErrBadRequest("recipient is not in this classroom")
+)
+
+// Gift statuses
+const (
+	// GiftStatusPending gifts wait for the teacher with the neurons on hold
+	GiftStatusPending  = "pending"
+	GiftStatusGiven    = "given"
+	GiftStatusRejected = "rejected"
+)
+
+// GiftSettings are a classroom's rules for students gifting neurons to each
+// other. Gifting is disabled until a teacher turns it on.
+type GiftSettings struct {
+	ClassroomID int64 `json:"classroom_id" db:"classroom_id"`
+	Enabled     bool  `json:"enabled" db:"enabled"`
+	// DailyCap limits what a student can give per day, pending gifts
+	// included; nil means no cap. Days start at midnight UTC.
+	DailyCap *int `json:"daily_cap,omitempty" db:"daily_cap"`
+	// MaxPerGift limits a single gift; nil means no limit
+	MaxPerGift *int `json:"max_per_gift,omitempty" db:"max_per_gift"`
+	// RequiresApproval holds gifts until a teacher approves them
+	RequiresApproval bool       `json:"requires_approval" db:"requires_approval"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// Gift is neurons a student gives to a classmate
+type Gift struct {
+	ID          int64  `json:"id" db:"id"`
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	SenderID    int64  `json:"sender_id" db:"sender_id"`
+	RecipientID int64  `json:"recipient_id" db:"recipient_id"`
+	Amount      int    `json:"amount" db:"amount"`
+	Note        string `json:"note" db:"note"`
+	Status      string `json:"status" db:"status"`
+	// HoldTransactionID kept the neurons on hold while the gift was pending
+	HoldTransactionID *int64 `json:"hold_transaction_id,omitempty" db:"hold_transaction_id"`
+	// TransactionID moved the neurons to the recipient
+	TransactionID *int64     `json:"transaction_id,omitempty" db:"transaction_id"`
+	DecidedBy     *int64     `json:"decided_by,omitempty" db:"decided_by"`
+	Reason        string     `json:"reason" db:"reason"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (g *GiftSettings) validate() error {
+	if g.DailyCap != nil && *g.DailyCap <= 0 {
+		return errors.ErrBadRequest("daily cap must be positive")
+	}
+	if g.MaxPerGift != nil && *g.MaxPerGift <= 0 {
+		return errors.ErrBadRequest("max per gift must be positive")
+	}
+	return nil
+}
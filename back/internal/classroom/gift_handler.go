@@ -0,0 +1,157 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetGiftSettings(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	settings, err := h.service.GetGiftSettings(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(settings)
+}
+
+func (h *Handler) UpdateGiftSettings(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		Enabled          bool `json:"enabled"`
+		DailyCap         *int `json:"daily_cap"`
+		MaxPerGift       *int `json:"max_per_gift"`
+		RequiresApproval bool `json:"requires_approval"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	settings, err := h.service.UpdateGiftSettings(c.Context(), &GiftSettings{
+		ClassroomID:      classroomID,
+		Enabled:          input.Enabled,
+		DailyCap:         input.DailyCap,
+		MaxPerGift:       input.MaxPerGift,
+		RequiresApproval: input.RequiresApproval,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(settings)
+}
+
+func (h *Handler) GiveGift(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		RecipientID int64  `json:"recipient_id"`
+		Amount      int    `json:"amount"`
+		Note        string `json:"note"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	gift, err := h.service.GiveGift(c.Context(), u.ID, classroomID, input.RecipientID, input.Amount, input.Note)
+	if err != nil {
+		return err
+	}
+
+	// Gifts that need approval are queued for the teacher
+	if gift.Status == GiftStatusPending {
+		return c.Status(fiber.StatusAccepted).JSON(gift)
+	}
+	return c.JSON(gift)
+}
+
+func (h *Handler) ListGifts(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	// Students only see the gifts they sent or received
+	var userID *int64
+	if u.Role == "student" {
+		userID = &u.ID
+	}
+
+	gifts, err := h.service.ListGifts(c.Context(), classroomID, c.Query("status"), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(gifts)
+}
+
+func (h *Handler) ApproveGift(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, giftID, err := giftParams(c)
+	if err != nil {
+		return err
+	}
+
+	gift, err := h.service.ApproveGift(c.Context(), u.ID, classroomID, giftID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(gift)
+}
+
+func (h *Handler) RejectGift(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, giftID, err := giftParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	gift, err := h.service.RejectGift(c.Context(), u.ID, classroomID, giftID, input.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(gift)
+}
+
+func giftParams(c *fiber.Ctx) (classroomID, giftID int64, err error) {
+	classroomID, err = strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	giftID, err = strconv.ParseInt(c.Params("giftId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid gift id")
+	}
+
+	return classroomID, giftID, nil
+}
@@ -0,0 +1,276 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+const giftSettingsColumns = "classroom_id, enabled, daily_cap, max_per_gift, requires_approval, updated_at"
+
+const giftColumns = `id, classroom_id, sender_id, recipient_id, amount, note, status, hold_transaction_id,
+	transaction_id, decided_by, reason, decided_at, created_at`
+
+// GetGiftSettings returns the classroom's gifting rules, which are the
+// defaults until a teacher changes them
+func (r *PostgresRepository) GetGiftSettings(ctx context.Context, classroomID int64) (*GiftSettings, error) {
+	return getGiftSettings(ctx, r.db, classroomID)
+}
+
+func (r *PostgresRepository) UpdateGiftSettings(ctx context.Context, settings *GiftSettings) error {
+	query := `
+		INSERT INTO classroom_gift_settings (classroom_id, enabled, daily_cap, max_per_gift, requires_approval, updated_at)
+		VALUES (:classroom_id, :enabled, :daily_cap, :max_per_gift, :requires_approval, :updated_at)
+		ON CONFLICT (classroom_id) DO UPDATE
+		SET enabled = EXCLUDED.enabled, daily_cap = EXCLUDED.daily_cap, max_per_gift = EXCLUDED.max_per_gift,
+			requires_approval = EXCLUDED.requires_approval, updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.NamedExecContext(ctx, query, settings)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update gift settings: %v", err))
+	}
+	return nil
+}
+
+// GiveGift checks the gift against the classroom's rules and either moves the
+// neurons to the recipient or, when gifts need approval, puts them on hold.
+// The sender's wallet stays locked until the gift is recorded so concurrent
+// gifts can't get past the daily cap.
+func (r *PostgresRepository) GiveGift(ctx context.Context, gift *Gift) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	settings, err := getGiftSettings(ctx, tx, gift.ClassroomID)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return ErrGiftingDisabled
+	}
+	if settings.MaxPerGift != nil && gift.Amount > *settings.MaxPerGift {
+		return ErrGiftExceedsMaxPerGift
+	}
+
+	sender, err := getStudentAccount(ctx, tx, gift.ClassroomID, gift.SenderID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "SELECT 1 FROM ledger_accounts WHERE id = $1 FOR UPDATE", sender.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock sender account: %v", err))
+	}
+	recipient, err := getStudentAccount(ctx, tx, gift.ClassroomID, gift.RecipientID)
+	if err == ErrStudentNotInClassroom {
+		return ErrRecipientNotInClassroom
+	}
+	if err != nil {
+		return err
+	}
+
+	if settings.DailyCap != nil {
+		var given int
+		err = tx.GetContext(ctx, &given, `
+			SELECT COALESCE(SUM(amount), 0) FROM gifts
+			WHERE classroom_id = $1 AND sender_id = $2 AND status IN ('pending', 'given') AND created_at >= $3
+		`, gift.ClassroomID, gift.SenderID, gift.CreatedAt.UTC().Truncate(24*time.Hour))
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to sum gifts: %v", err))
+		}
+		if given+gift.Amount > *settings.DailyCap {
+			return ErrGiftDailyCapReached
+		}
+	}
+
+	transaction := &NeuronTransaction{
+		ClassroomID: gift.ClassroomID,
+		UserID:      gift.SenderID,
+		Amount:      gift.Amount,
+		RecipientID: &gift.RecipientID,
+		CreatedBy:   &gift.SenderID,
+		Note:        gift.Note,
+		CreatedAt:   gift.CreatedAt,
+	}
+	if settings.RequiresApproval {
+		hold, err := ensureLedgerAccount(ctx, tx, gift.ClassroomID, AccountTypeHold, &gift.SenderID)
+		if err != nil {
+			return err
+		}
+		transaction.TransactionType = TransactionTypeHold
+		if err := transfer(ctx, tx, transaction, sender, hold); err != nil {
+			return err
+		}
+		gift.Status = GiftStatusPending
+		gift.HoldTransactionID = &transaction.ID
+	} else {
+		transaction.TransactionType = TransactionTypeGift
+		if err := transfer(ctx, tx, transaction, sender, recipient); err != nil {
+			return err
+		}
+		gift.Status = GiftStatusGiven
+		gift.TransactionID = &transaction.ID
+	}
+
+	err = tx.GetContext(ctx, &gift.ID, `
+		INSERT INTO gifts (classroom_id, sender_id, recipient_id, amount, note, status, hold_transaction_id, transaction_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, gift.ClassroomID, gift.SenderID, gift.RecipientID, gift.Amount, gift.Note, gift.Status,
+		gift.HoldTransactionID, gift.TransactionID, gift.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create gift: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetGift(ctx context.Context, id int64) (*Gift, error) {
+	query := "SELECT " + giftColumns + " FROM gifts WHERE id = $1"
+	var gift Gift
+	err := r.db.GetContext(ctx, &gift, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("gift not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get gift: %v", err))
+	}
+	return &gift, nil
+}
+
+func (r *PostgresRepository) ListGifts(ctx context.Context, classroomID int64, status string, userID *int64) ([]*Gift, error) {
+	query := `
+		SELECT ` + giftColumns + `
+		FROM gifts
+		WHERE classroom_id = $1
+		  AND ($2 = '' OR status = $2)
+		  AND ($3::INTEGER IS NULL OR sender_id = $3 OR recipient_id = $3)
+		ORDER BY created_at DESC, id DESC
+	`
+	gifts := []*Gift{}
+	err := r.db.SelectContext(ctx, &gifts, query, classroomID, status, userID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list gifts: %v", err))
+	}
+	return gifts, nil
+}
+
+// ApproveGift releases the hold and gives the neurons to the recipient
+func (r *PostgresRepository) ApproveGift(ctx context.Context, gift *Gift) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	current, err := lockPendingGift(ctx, tx, gift.ID)
+	if err != nil {
+		return err
+	}
+	if err := releaseHold(ctx, tx, current.ClassroomID, current.SenderID, current.Amount, gift.DecidedBy, *gift.DecidedAt); err != nil {
+		return err
+	}
+
+	sender, err := ensureLedgerAccount(ctx, tx, current.ClassroomID, AccountTypeStudent, &current.SenderID)
+	if err != nil {
+		return err
+	}
+	recipient, err := getStudentAccount(ctx, tx, current.ClassroomID, current.RecipientID)
+	if err == ErrStudentNotInClassroom {
+		return ErrRecipientNotInClassroom
+	}
+	if err != nil {
+		return err
+	}
+	transaction := &NeuronTransaction{
+		ClassroomID:     current.ClassroomID,
+		UserID:          current.SenderID,
+		Amount:          current.Amount,
+		TransactionType: TransactionTypeGift,
+		RecipientID:     &current.RecipientID,
+		CreatedBy:       gift.DecidedBy,
+		Note:            current.Note,
+		CreatedAt:       *gift.DecidedAt,
+	}
+	if err := transfer(ctx, tx, transaction, sender, recipient); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE gifts
+		SET status = $1, decided_by = $2, decided_at = $3, transaction_id = $4
+		WHERE id = $5
+	`, GiftStatusGiven, gift.DecidedBy, gift.DecidedAt, transaction.ID, gift.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to approve gift: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// RejectGift gives the held neurons back to the sender
+func (r *PostgresRepository) RejectGift(ctx context.Context, gift *Gift) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	current, err := lockPendingGift(ctx, tx, gift.ID)
+	if err != nil {
+		return err
+	}
+	if err := releaseHold(ctx, tx, current.ClassroomID, current.SenderID, current.Amount, gift.DecidedBy, *gift.DecidedAt); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE gifts
+		SET status = $1, decided_by = $2, decided_at = $3, reason = $4
+		WHERE id = $5
+	`, GiftStatusRejected, gift.DecidedBy, gift.DecidedAt, gift.Reason, gift.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to reject gift: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func getGiftSettings(ctx context.Context, q sqlx.QueryerContext, classroomID int64) (*GiftSettings, error) {
+	settings := GiftSettings{ClassroomID: classroomID}
+	err := sqlx.GetContext(ctx, q, &settings, "SELECT "+giftSettingsColumns+" FROM classroom_gift_settings WHERE classroom_id = $1", classroomID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get gift settings: %v", err))
+	}
+	return &settings, nil
+}
+
+func lockPendingGift(ctx context.Context, tx *database.Tx, id int64) (*Gift, error) {
+	var gift Gift
+	err := tx.GetContext(ctx, &gift, "SELECT "+giftColumns+" FROM gifts WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("gift not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to lock gift: %v", err))
+	}
+	if gift.Status != GiftStatusPending {
+		return nil, errors.ErrConflict(fmt.Sprintf("gift is already %s", gift.Status))
+	}
+	return &gift, nil
+}
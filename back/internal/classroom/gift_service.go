@@ -0,0 +1,189 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// GetGiftSettings retrieves the classroom's gifting rules
+func (s *Service) GetGiftSettings(ctx context.Context, classroomID int64) (*GiftSettings, error) {
+	if _, err := s.repo.GetClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetGiftSettings(ctx, classroomID)
+}
+
+// UpdateGiftSettings changes the classroom's gifting rules. Pending gifts keep
+// waiting for a decision even if approval is turned off.
+func (s *Service) UpdateGiftSettings(ctx context.Context, settings *GiftSettings) (*GiftSettings, error) {
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.getActiveClassroom(ctx, settings.ClassroomID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	settings.UpdatedAt = &now
+	var after *GiftSettings
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.GetGiftSettings(ctx, settings.ClassroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.UpdateGiftSettings(ctx, settings)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetGiftSettings(ctx, settings.ClassroomID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(settings.ClassroomID, ActionGiftSettingsUpdated, "classroom", settings.ClassroomID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// GiveGift gifts neurons from one student to a classmate within the
+// classroom's rules. When gifts need approval the gift is returned pending,
+// with the neurons on hold until a teacher decides.
+func (s *Service) GiveGift(ctx context.Context, senderID, classroomID, recipientID int64, amount int, note string) (*Gift, error) {
+	// Verify that the user exists and is a student
+	sender, err := s.userService.GetUser(ctx, senderID)
+	if err != nil {
+		return nil, err
+	}
+	if sender.Role != "student" {
+		return nil, errors.ErrForbidden("only students can gift neurons")
+	}
+	if senderID == recipientID {
+		return nil, ErrSelfGift
+	}
+	if amount <= 0 {
+		return nil, ErrAmountNotPositive
+	}
+
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	// The rules, both students' enrollment and the sender's balance are
+	// checked while the sender's wallet is locked
+	gift := &Gift{
+		ClassroomID: classroomID,
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Amount:      amount,
+		Note:        note,
+		CreatedAt:   time.Now(),
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.GiveGift(ctx, gift)
+		if err != nil {
+			return nil, err
+		}
+
+		action := ActionGiftGiven
+		if gift.Status == GiftStatusPending {
+			action = ActionGiftRequested
+		}
+		return classroomChange(classroomID, action, "gift", gift.ID, nil, gift), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gift, nil
+}
+
+// ListGifts retrieves a classroom's gifts, optionally only those with the
+// given status or sent or received by one student
+func (s *Service) ListGifts(ctx context.Context, classroomID int64, status string, userID *int64) ([]*Gift, error) {
+	switch status {
+	case "", GiftStatusPending, GiftStatusGiven, GiftStatusRejected:
+	default:
+		return nil, errors.ErrBadRequest("invalid status")
+	}
+	return s.repo.ListGifts(ctx, classroomID, status, userID)
+}
+
+// ApproveGift gives the held neurons to the recipient
+func (s *Service) ApproveGift(ctx context.Context, teacherID, classroomID, giftID int64) (*Gift, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	gift, err := s.getGift(ctx, classroomID, giftID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *gift
+	now := time.Now()
+	gift.DecidedBy = &teacherID
+	gift.DecidedAt = &now
+	return s.decideGift(ctx, ActionGiftApproved, &before, func(ctx context.Context) error {
+		return s.repo.ApproveGift(ctx, gift)
+	})
+}
+
+// RejectGift gives the held neurons back to the sender
+func (s *Service) RejectGift(ctx context.Context, teacherID, classroomID, giftID int64, reason string) (*Gift, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	gift, err := s.getGift(ctx, classroomID, giftID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *gift
+	now := time.Now()
+	gift.DecidedBy = &teacherID
+	gift.DecidedAt = &now
+	gift.Reason = reason
+	return s.decideGift(ctx, ActionGiftRejected, &before, func(ctx context.Context) error {
+		return s.repo.RejectGift(ctx, gift)
+	})
+}
+
+// decideGift settles a pending gift and records it in the audit log
+func (s *Service) decideGift(ctx context.Context, action string, before *Gift, decide func(ctx context.Context) error) (*Gift, error) {
+	var after *Gift
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := decide(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetGift(ctx, before.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(before.ClassroomID, action, "gift", before.ID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (s *Service) getGift(ctx context.Context, classroomID, giftID int64) (*Gift, error) {
+	gift, err := s.repo.GetGift(ctx, giftID)
+	if err != nil {
+		return nil, err
+	}
+	if gift.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("gift not found")
+	}
+	return gift, nil
+}
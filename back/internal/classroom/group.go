@@ -0,0 +1,50 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+// ErrGroupNameTaken is returned when a classroom already has a group with the name
+var ErrGroupNameTaken = errors.ErrConflict("a group with this name already exists")
+
+// ErrNotGroupMember is returned when a student uses the wallet of a group they are not in
+var ErrNotGroupMember = errors.ErrForbidden("student is not a member of this group")
+
+// defaultTeamPrefix names the teams made by shuffling when no prefix is given
+const defaultTeamPrefix = "Team"
+
+// StudentGroup is a group of students within a classroom, such as a team or
+// a table. A student can be in several groups.
+type StudentGroup struct {
+	ID          int64         `json:"id" db:"id"`
+	ClassroomID int64         `json:"classroom_id" db:"classroom_id"`
+	Name        string        `json:"name" db:"name"`
+	MemberIDs   pq.Int64Array `json:"member_ids" db:"member_ids"`
+	// Total is the sum of the members' balances
+	Total int `json:"total" db:"total"`
+	// Balance is what the group's shared wallet holds
+	Balance   int       `json:"balance" db:"balance"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// GroupSend describes neurons sent to every member of a group
+type GroupSend struct {
+	Amount int `json:"amount"`
+	// Split divides the amount between the members instead of giving each of
+	// them the full amount
+	Split bool `json:"split"`
+	TransactionDetails
+}
+
+func (g *StudentGroup) validate() error {
+	if g.Name == "" {
+		return errors.ErrBadRequest("name is required")
+	}
+	if len(g.Name) > 100 {
+		return errors.ErrBadRequest("name must be at most 100 characters")
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) ListGroups(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	groups, err := h.service.ListGroups(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(groups)
+}
+
+func (h *Handler) CreateGroup(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		Name      string  `json:"name"`
+		MemberIDs []int64 `json:"member_ids"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	group, err := h.service.CreateGroup(c.Context(), &StudentGroup{
+		ClassroomID: classroomID,
+		Name:        input.Name,
+		MemberIDs:   input.MemberIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(group)
+}
+
+func (h *Handler) ShuffleGroups(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		Teams      int    `json:"teams"`
+		NamePrefix string `json:"name_prefix"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	groups, err := h.service.ShuffleGroups(c.Context(), classroomID, input.Teams, input.NamePrefix)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(groups)
+}
+
+func (h *Handler) RenameGroup(c *fiber.Ctx) error {
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	group, err := h.service.RenameGroup(c.Context(), classroomID, groupID, input.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(group)
+}
+
+func (h *Handler) SetGroupMembers(c *fiber.Ctx) error {
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		MemberIDs []int64 `json:"member_ids"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	group, err := h.service.SetGroupMembers(c.Context(), classroomID, groupID, input.MemberIDs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(group)
+}
+
+func (h *Handler) DeleteGroup(c *fiber.Ctx) error {
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteGroup(c.Context(), classroomID, groupID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) SendNeuronsToGroup(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input GroupSend
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	batch, err := h.service.SendNeuronsToGroup(c.Context(), u.ID, classroomID, groupID, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(batch)
+}
+
+func (h *Handler) FundGroup(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Amount int `json:"amount"`
+		TransactionDetails
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	transaction, err := h.service.FundGroup(c.Context(), u.ID, classroomID, groupID, input.Amount, input.TransactionDetails)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(transaction)
+}
+
+func (h *Handler) ContributeToGroup(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Amount int `json:"amount"`
+		TransactionDetails
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	transaction, err := h.service.ContributeToGroup(c.Context(), u.ID, classroomID, groupID, input.Amount, input.TransactionDetails)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(transaction)
+}
+
+func (h *Handler) RedeemRewardForGroup(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	rewardID, err := strconv.ParseInt(c.Params("rewardId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid reward id")
+	}
+
+	redemption, err := h.service.RedeemRewardForGroup(c.Context(), u.ID, classroomID, groupID, rewardID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(redemption)
+}
+
+func (h *Handler) ListGroupTransactions(c *fiber.Ctx) error {
+	classroomID, groupID, err := groupParams(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.service.ListGroupTransactions(c.Context(), classroomID, groupID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(page)
+}
+
+func groupParams(c *fiber.Ctx) (classroomID, groupID int64, err error) {
+	classroomID, err = strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	groupID, err = strconv.ParseInt(c.Params("groupId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid group id")
+	}
+
+	return classroomID, groupID, nil
+}
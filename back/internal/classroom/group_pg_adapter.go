@@ -0,0 +1,235 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+const studentGroupQuery = `
+	SELECT g.id, g.classroom_id, g.name, g.created_at,
+		   ARRAY(SELECT m.user_id FROM student_group_members m WHERE m.group_id = g.id ORDER BY m.user_id) AS member_ids,
+		   COALESCE((
+			   SELECT SUM(la.balance)
+			   FROM student_group_members m
+			   JOIN ledger_accounts la ON la.classroom_id = g.classroom_id AND la.user_id = m.user_id AND la.account_type = 'student'
+			   WHERE m.group_id = g.id
+		   ), 0) AS total,
+		   COALESCE((SELECT la.balance FROM ledger_accounts la WHERE la.group_id = g.id), 0) AS balance
+	FROM student_groups g
+`
+
+// CreateGroups creates one or more groups with their members in one transaction
+func (r *PostgresRepository) CreateGroups(ctx context.Context, groups []*StudentGroup) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	for _, group := range groups {
+		err = tx.GetContext(ctx, &group.ID, `
+			INSERT INTO student_groups (classroom_id, name, created_at)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, group.ClassroomID, group.Name, group.CreatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return ErrGroupNameTaken
+			}
+			return errors.ErrDatabase(fmt.Sprintf("failed to create group: %v", err))
+		}
+
+		if err := setGroupMembers(ctx, tx, group.ID, group.MemberIDs); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetGroup(ctx context.Context, id int64) (*StudentGroup, error) {
+	var group StudentGroup
+	err := r.db.GetContext(ctx, &group, studentGroupQuery+"WHERE g.id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("group not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get group: %v", err))
+	}
+	return &group, nil
+}
+
+func (r *PostgresRepository) ListGroups(ctx context.Context, classroomID int64) ([]*StudentGroup, error) {
+	groups := []*StudentGroup{}
+	err := r.db.SelectContext(ctx, &groups, studentGroupQuery+"WHERE g.classroom_id = $1 ORDER BY g.name, g.id", classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list groups: %v", err))
+	}
+	return groups, nil
+}
+
+func (r *PostgresRepository) RenameGroup(ctx context.Context, id int64, name string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE student_groups SET name = $2 WHERE id = $1", id, name)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrGroupNameTaken
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to rename group: %v", err))
+	}
+	return nil
+}
+
+// SetGroupMembers replaces the members of a group
+func (r *PostgresRepository) SetGroupMembers(ctx context.Context, id int64, memberIDs []int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM student_group_members WHERE group_id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to clear group members: %v", err))
+	}
+	if err := setGroupMembers(ctx, tx, id, memberIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) DeleteGroup(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// The ledger keeps referring to a group once its wallet has been used
+	var hasWallet bool
+	err = tx.GetContext(ctx, &hasWallet, "SELECT EXISTS(SELECT 1 FROM ledger_accounts WHERE group_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check group wallet: %v", err))
+	}
+	if hasWallet {
+		return errors.ErrConflict("group has used its wallet and cannot be deleted")
+	}
+	var hasAllowance bool
+	err = tx.GetContext(ctx, &hasAllowance, "SELECT EXISTS(SELECT 1 FROM allowance_rules WHERE group_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check group allowances: %v", err))
+	}
+	if hasAllowance {
+		return errors.ErrConflict("group is used by an allowance")
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM student_group_members WHERE group_id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to clear group members: %v", err))
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM student_groups WHERE id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to delete group: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// FundGroup moves neurons from the classroom pool to the group's wallet
+func (r *PostgresRepository) FundGroup(ctx context.Context, transaction *NeuronTransaction) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	pool, err := getClassroomAccount(ctx, tx, transaction.ClassroomID, AccountTypeClassroom)
+	if err != nil {
+		return err
+	}
+	wallet, err := ensureGroupAccount(ctx, tx, transaction.ClassroomID, *transaction.GroupID)
+	if err != nil {
+		return err
+	}
+	if err := transfer(ctx, tx, transaction, pool, wallet); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// ContributeToGroup moves neurons from a member's wallet to the group's wallet
+func (r *PostgresRepository) ContributeToGroup(ctx context.Context, transaction *NeuronTransaction) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	if err := checkGroupMember(ctx, tx, *transaction.GroupID, transaction.UserID); err != nil {
+		return err
+	}
+	student, err := getStudentAccount(ctx, tx, transaction.ClassroomID, transaction.UserID)
+	if err != nil {
+		return err
+	}
+	wallet, err := ensureGroupAccount(ctx, tx, transaction.ClassroomID, *transaction.GroupID)
+	if err != nil {
+		return err
+	}
+	if err := transfer(ctx, tx, transaction, student, wallet); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// checkGroupMember makes sure the student is in the group, locking the
+// membership so the student can't be removed while their group spends
+func checkGroupMember(ctx context.Context, tx *database.Tx, groupID, studentID int64) error {
+	var member bool
+	err := tx.GetContext(ctx, &member, `
+		SELECT EXISTS(SELECT 1 FROM student_group_members WHERE group_id = $1 AND user_id = $2 FOR SHARE)
+	`, groupID, studentID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check group membership: %v", err))
+	}
+	if !member {
+		return ErrNotGroupMember
+	}
+	return nil
+}
+
+func setGroupMembers(ctx context.Context, tx *database.Tx, groupID int64, memberIDs []int64) error {
+	if len(memberIDs) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO student_group_members (group_id, user_id)
+		SELECT $1, unnest($2::integer[])
+	`, groupID, pq.Array(memberIDs))
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to add group members: %v", err))
+	}
+	return nil
+}
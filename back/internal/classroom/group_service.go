@@ -0,0 +1,405 @@
+package classroom
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// ListGroups retrieves the student groups of a classroom
+func (s *Service) ListGroups(ctx context.Context, classroomID int64) ([]*StudentGroup, error) {
+	return s.repo.ListGroups(ctx, classroomID)
+}
+
+// CreateGroup creates a group, optionally with its first members
+func (s *Service) CreateGroup(ctx context.Context, group *StudentGroup) (*StudentGroup, error) {
+	if err := group.validate(); err != nil {
+		return nil, err
+	}
+
+	classroom, err := s.getActiveClassroom(ctx, group.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+	memberIDs, err := enrolledMembers(classroom, group.MemberIDs)
+	if err != nil {
+		return nil, err
+	}
+	group.MemberIDs = memberIDs
+	group.CreatedAt = time.Now()
+
+	var created *StudentGroup
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateGroups(ctx, []*StudentGroup{group})
+		if err != nil {
+			return nil, err
+		}
+
+		created, err = s.repo.GetGroup(ctx, group.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(group.ClassroomID, ActionGroupCreated, "group", group.ID, nil, created), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// RenameGroup changes the name of a group
+func (s *Service) RenameGroup(ctx context.Context, classroomID, groupID int64, name string) (*StudentGroup, error) {
+	if err := (&StudentGroup{Name: name}).validate(); err != nil {
+		return nil, err
+	}
+
+	return s.changeGroup(ctx, ActionGroupRenamed, classroomID, groupID, func(ctx context.Context) error {
+		return s.repo.RenameGroup(ctx, groupID, name)
+	})
+}
+
+// SetGroupMembers replaces the students in a group
+func (s *Service) SetGroupMembers(ctx context.Context, classroomID, groupID int64, memberIDs []int64) (*StudentGroup, error) {
+	classroom, err := s.getActiveClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+	memberIDs, err = enrolledMembers(classroom, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.changeGroup(ctx, ActionGroupMembersChanged, classroomID, groupID, func(ctx context.Context) error {
+		return s.repo.SetGroupMembers(ctx, groupID, memberIDs)
+	})
+}
+
+// DeleteGroup deletes a group; its members stay in the classroom
+func (s *Service) DeleteGroup(ctx context.Context, classroomID, groupID int64) error {
+	_, err := s.changeGroup(ctx, ActionGroupDeleted, classroomID, groupID, func(ctx context.Context) error {
+		return s.repo.DeleteGroup(ctx, groupID)
+	})
+	return err
+}
+
+// ShuffleGroups deals every student of the classroom into new teams. Teams
+// differ in size by at most one, and stronger and weaker balances are spread
+// across them so their totals stay close.
+func (s *Service) ShuffleGroups(ctx context.Context, classroomID int64, teams int, namePrefix string) ([]*StudentGroup, error) {
+	if namePrefix == "" {
+		namePrefix = defaultTeamPrefix
+	}
+
+	classroom, err := s.getActiveClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+	if teams < 2 {
+		return nil, errors.ErrBadRequest("at least 2 teams are required")
+	}
+	if teams > len(classroom.Students) {
+		return nil, errors.ErrBadRequest("there are fewer students than teams")
+	}
+
+	// Shuffle first so that students with equal balances land in random teams
+	students := make([]*Student, len(classroom.Students))
+	copy(students, classroom.Students)
+	rand.Shuffle(len(students), func(i, j int) { students[i], students[j] = students[j], students[i] })
+	sort.SliceStable(students, func(i, j int) bool { return students[i].Neurons > students[j].Neurons })
+
+	now := time.Now()
+	groups := make([]*StudentGroup, teams)
+	for i := range groups {
+		groups[i] = &StudentGroup{
+			ClassroomID: classroomID,
+			Name:        fmt.Sprintf("%s %d", namePrefix, i+1),
+			CreatedAt:   now,
+		}
+		if err := groups[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	// Snake draft: 1..n, then n..1, and so on
+	for i, student := range students {
+		round, pick := i/teams, i%teams
+		if round%2 == 1 {
+			pick = teams - 1 - pick
+		}
+		groups[pick].MemberIDs = append(groups[pick].MemberIDs, student.ID)
+	}
+
+	var shuffled []*StudentGroup
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateGroups(ctx, groups)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range groups {
+			created, err := s.repo.GetGroup(ctx, group.ID)
+			if err != nil {
+				return nil, err
+			}
+			shuffled = append(shuffled, created)
+		}
+		return classroomChange(classroomID, ActionGroupsShuffled, "classroom", classroomID, nil, shuffled), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shuffled, nil
+}
+
+// SendNeuronsToGroup rewards every member of a group through a bulk send,
+// either giving each of them the amount or splitting it between them. A
+// split that doesn't divide evenly gives the remainder to random members.
+func (s *Service) SendNeuronsToGroup(ctx context.Context, teacherID, classroomID, groupID int64, send GroupSend) (*TransactionBatch, error) {
+	group, err := s.getGroup(ctx, classroomID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(group.MemberIDs) == 0 {
+		return nil, errors.ErrBadRequest("group has no members")
+	}
+
+	category, err := s.resolveCategory(ctx, classroomID, send.TransactionDetails)
+	if err != nil {
+		return nil, err
+	}
+	if send.Amount == 0 && category != nil {
+		send.Amount = category.DefaultAmount
+	}
+	if send.Amount <= 0 {
+		return nil, ErrAmountNotPositive
+	}
+
+	members := len(group.MemberIDs)
+	transfers := make([]BulkTransfer, 0, members)
+	if send.Split {
+		if send.Amount < members {
+			return nil, errors.ErrBadRequest(fmt.Sprintf("amount must be at least %d to split between the group", members))
+		}
+		share, remainder := send.Amount/members, send.Amount%members
+		for _, i := range rand.Perm(members) {
+			amount := share
+			if remainder > 0 {
+				amount++
+				remainder--
+			}
+			transfers = append(transfers, BulkTransfer{StudentID: group.MemberIDs[i], Amount: amount})
+		}
+	} else {
+		for _, memberID := range group.MemberIDs {
+			transfers = append(transfers, BulkTransfer{StudentID: memberID, Amount: send.Amount})
+		}
+	}
+
+	return s.SendNeuronsBulk(ctx, teacherID, classroomID, transfers, 0, send.TransactionDetails)
+}
+
+// FundGroup moves neurons from the classroom pool to a group's shared wallet.
+// Funding counts against the teacher's daily send limit like any other send.
+func (s *Service) FundGroup(ctx context.Context, teacherID, classroomID, groupID int64, amount int, details TransactionDetails) (*NeuronTransaction, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	if _, err := s.getGroup(ctx, classroomID, groupID); err != nil {
+		return nil, err
+	}
+
+	category, err := s.resolveCategory(ctx, classroomID, details)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 && category != nil {
+		amount = category.DefaultAmount
+	}
+	if amount <= 0 {
+		return nil, ErrAmountNotPositive
+	}
+
+	transaction := &NeuronTransaction{
+		ClassroomID:     classroomID,
+		UserID:          teacherID,
+		Amount:          amount,
+		TransactionType: TransactionTypeGroupFunding,
+		CategoryID:      details.CategoryID,
+		GroupID:         &groupID,
+		CreatedBy:       &teacherID,
+		Note:            details.Note,
+		CreatedAt:       time.Now(),
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		if err := s.checkSendAllowance(ctx, classroomID, teacherID, amount); err != nil {
+			return nil, err
+		}
+
+		err := s.repo.FundGroup(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionGroupFunded, "neuron_transaction", transaction.ID, nil, transaction), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// ContributeToGroup moves neurons from a student's wallet to the shared
+// wallet of a group they are in
+func (s *Service) ContributeToGroup(ctx context.Context, studentID, classroomID, groupID int64, amount int, details TransactionDetails) (*NeuronTransaction, error) {
+	student, err := s.userService.GetUser(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Role != "student" {
+		return nil, errors.ErrForbidden("only students can contribute to a group")
+	}
+	if amount <= 0 {
+		return nil, ErrAmountNotPositive
+	}
+
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	if _, err := s.getGroup(ctx, classroomID, groupID); err != nil {
+		return nil, err
+	}
+	if _, err := s.resolveCategory(ctx, classroomID, details); err != nil {
+		return nil, err
+	}
+
+	// Membership and the student's balance are checked under lock
+	transaction := &NeuronTransaction{
+		ClassroomID:     classroomID,
+		UserID:          studentID,
+		Amount:          amount,
+		TransactionType: TransactionTypeContribution,
+		CategoryID:      details.CategoryID,
+		GroupID:         &groupID,
+		CreatedBy:       &studentID,
+		Note:            details.Note,
+		CreatedAt:       time.Now(),
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.ContributeToGroup(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionGroupContribution, "neuron_transaction", transaction.ID, nil, transaction), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// RedeemRewardForGroup spends a group's shared wallet on a reward on behalf
+// of one of its members. Rewards that need approval can't be redeemed this
+// way, since holds are kept per student.
+func (s *Service) RedeemRewardForGroup(ctx context.Context, studentID, classroomID, groupID, rewardID int64) (*NeuronTransaction, error) {
+	if _, err := s.getGroup(ctx, classroomID, groupID); err != nil {
+		return nil, err
+	}
+
+	reward, err := s.GetReward(ctx, classroomID, rewardID)
+	if err != nil {
+		return nil, err
+	}
+	if reward.RequiresApproval {
+		return nil, errors.ErrBadRequest("rewards that require approval can't be redeemed from a group wallet")
+	}
+
+	return s.redeemReward(ctx, studentID, classroomID, rewardID, &groupID)
+}
+
+// ListGroupTransactions retrieves the transactions of a group's shared wallet
+func (s *Service) ListGroupTransactions(ctx context.Context, classroomID, groupID int64, filter TransactionFilter) (*TransactionPage, error) {
+	if _, err := s.getGroup(ctx, classroomID, groupID); err != nil {
+		return nil, err
+	}
+
+	filter.ClassroomID = &classroomID
+	filter.GroupID = &groupID
+	if err := validateTransactionFilter(&filter); err != nil {
+		return nil, err
+	}
+	return s.repo.ListNeuronTransactions(ctx, filter)
+}
+
+// changeGroup applies a change to a group and records it in the audit log.
+// The group returned is nil once it is deleted.
+func (s *Service) changeGroup(ctx context.Context, action string, classroomID, groupID int64, change func(ctx context.Context) error) (*StudentGroup, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	var after *StudentGroup
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.getGroup(ctx, classroomID, groupID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = change(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if action != ActionGroupDeleted {
+			after, err = s.repo.GetGroup(ctx, groupID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return classroomChange(classroomID, action, "group", groupID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (s *Service) getGroup(ctx context.Context, classroomID, groupID int64) (*StudentGroup, error) {
+	group, err := s.repo.GetGroup(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("group not found")
+	}
+	return group, nil
+}
+
+// enrolledMembers deduplicates the member IDs, making sure they are all
+// students of the classroom
+func enrolledMembers(classroom *ClassroomWithData, memberIDs []int64) ([]int64, error) {
+	enrolled := make(map[int64]bool, len(classroom.Students))
+	for _, student := range classroom.Students {
+		enrolled[student.ID] = true
+	}
+
+	members := make([]int64, 0, len(memberIDs))
+	seen := make(map[int64]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		if !enrolled[id] {
+			return nil, errors.ErrBadRequest(fmt.Sprintf("student %d is not in this classroom", id))
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		members = append(members, id)
+	}
+	return members, nil
+}
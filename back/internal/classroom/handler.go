@@ -2,48 +2,132 @@ package classroom
 
 import (
 	"strconv"
+	"time"
 
-	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/neurons/internal/idempotency"
 	"github.com/Abraxas-365/toolkit/pkg/errors"
-	"github.com/Abraxas-365/toolkit/pkg/lucia"
 	"github.com/gofiber/fiber/v2"
 )
 
 type Handler struct {
-	service     Servicer
-	userService user.Servicer
+	service            Servicer
+	policy             *authz.Policy
+	idempotencyService idempotency.Servicer
 }
 
-func NewHandler(service Servicer) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Servicer, policy *authz.Policy, idempotencyService idempotency.Servicer) *Handler {
+	return &Handler{
+		service:            service,
+		policy:             policy,
+		idempotencyService: idempotencyService,
+	}
 }
 
 func (h *Handler) RegisterRoutes(app *fiber.App) {
 	classroomGroup := app.Group("/classrooms")
 
+	// Access rules, evaluated against the classroom in the :id parameter
+	owner := h.policy.TeacherOwnsClassroom("id")
+	staff := h.policy.StaffCan("id", PermissionView)
+	sender := h.policy.StaffCan("id", PermissionSendNeurons)
+	manager := h.policy.StaffCan("id", PermissionManageClassroom)
+	treasurer := h.policy.StaffCan("id", PermissionManagePool)
+	deductor := h.policy.StaffCan("id", PermissionDeductNeurons)
+	enrolled := h.policy.StudentInClassroom("id")
+	idempotent := idempotency.Middleware(h.idempotencyService)
+
 	// Routes that require authentication
-	classroomGroup.Use(lucia.RequireAuth)
-	classroomGroup.Post("/", h.CreateClassroom)
-	classroomGroup.Get("/:id", h.GetClassroom)
-	classroomGroup.Put("/:id", h.UpdateClassroom)
-	classroomGroup.Delete("/:id", h.DeleteClassroom)
-	classroomGroup.Get("/", h.ListClassrooms)
-	classroomGroup.Post("/:id/students", h.AddStudentToClassroom)
-	classroomGroup.Delete("/:id/students/:studentId", h.RemoveStudentFromClassroom)
-	classroomGroup.Put("/:id/neurons", h.UpdateAvailableNeurons)
-	classroomGroup.Get("/:id/students", h.GetClassroomStudents)
-	classroomGroup.Post("/:id/send-neurons", h.SendNeurons)
-	classroomGroup.Get("/:id/user-neurons/:userId", h.GetUserNeurons)
-	classroomGroup.Get("/user", h.ListUserClassrooms)
-	classroomGroup.Post("/:id/return-neurons", h.ReturnNeuronsToClassroom)
+	classroomGroup.Use(h.policy.Authenticate)
+	classroomGroup.Post("/", authz.Require(authz.HasRole("teacher")), h.CreateClassroom)
+	classroomGroup.Get("/", authz.Require(authz.HasRole("teacher")), h.ListClassrooms)
+	classroomGroup.Get("/user", authz.Require(authz.Authenticated), h.ListUserClassrooms)
+	classroomGroup.Post("/join", authz.Require(authz.HasRole("student")), h.JoinClassroom)
+	classroomGroup.Get("/:id", authz.Require(staff, enrolled), h.GetClassroom)
+	classroomGroup.Put("/:id", authz.Require(manager), h.UpdateClassroom)
+	classroomGroup.Delete("/:id", authz.Require(owner), h.ArchiveClassroom)
+	classroomGroup.Post("/:id/archive", authz.Require(owner), h.ArchiveClassroom)
+	classroomGroup.Post("/:id/restore", authz.Require(owner), h.RestoreClassroom)
+	classroomGroup.Get("/:id/staff", authz.Require(staff), h.ListStaff)
+	classroomGroup.Post("/:id/staff", authz.Require(owner), h.InviteStaffMember)
+	classroomGroup.Post("/:id/staff/accept", authz.Require(authz.HasRole("teacher")), h.AcceptStaffInvitation)
+	classroomGroup.Put("/:id/staff/:userId", authz.Require(owner), h.UpdateStaffMember)
+	classroomGroup.Delete("/:id/staff/:userId", authz.Require(owner, authz.Self("userId")), h.RemoveStaffMember)
+	classroomGroup.Post("/:id/transfer-ownership", authz.Require(owner), h.TransferOwnership)
+	classroomGroup.Get("/:id/audit", authz.Require(owner), h.ListAuditLog)
+	classroomGroup.Post("/:id/students", authz.Require(manager), h.AddStudentToClassroom)
+	classroomGroup.Delete("/:id/students/:studentId", authz.Require(manager), h.RemoveStudentFromClassroom)
+	classroomGroup.Get("/:id/join-code", authz.Require(manager), h.GetJoinCode)
+	classroomGroup.Post("/:id/join-code", authz.Require(manager), h.GenerateJoinCode)
+	classroomGroup.Put("/:id/join-code", authz.Require(manager), h.UpdateJoinCode)
+	classroomGroup.Get("/:id/enrollment-requests", authz.Require(manager), h.ListEnrollmentRequests)
+	classroomGroup.Post("/:id/enrollment-requests/approve", authz.Require(manager), h.ApproveEnrollmentRequests)
+	classroomGroup.Post("/:id/enrollment-requests/:requestId/approve", authz.Require(manager), h.ApproveEnrollmentRequest)
+	classroomGroup.Post("/:id/enrollment-requests/:requestId/reject", authz.Require(manager), h.RejectEnrollmentRequest)
+	classroomGroup.Put("/:id/neurons", authz.Require(treasurer), h.UpdateAvailableNeurons)
+	classroomGroup.Get("/:id/students", authz.Require(staff, enrolled), h.GetClassroomStudents)
+	classroomGroup.Get("/:id/groups", authz.Require(staff, enrolled), h.ListGroups)
+	classroomGroup.Post("/:id/groups", authz.Require(manager), h.CreateGroup)
+	classroomGroup.Post("/:id/groups/shuffle", authz.Require(manager), h.ShuffleGroups)
+	classroomGroup.Put("/:id/groups/:groupId", authz.Require(manager), h.RenameGroup)
+	classroomGroup.Put("/:id/groups/:groupId/members", authz.Require(manager), h.SetGroupMembers)
+	classroomGroup.Delete("/:id/groups/:groupId", authz.Require(manager), h.DeleteGroup)
+	classroomGroup.Post("/:id/groups/:groupId/send-neurons", authz.Require(sender), idempotent, h.SendNeuronsToGroup)
+	classroomGroup.Post("/:id/groups/:groupId/fund", authz.Require(sender), idempotent, h.FundGroup)
+	classroomGroup.Post("/:id/groups/:groupId/contribute", authz.Require(enrolled), idempotent, h.ContributeToGroup)
+	classroomGroup.Post("/:id/groups/:groupId/rewards/:rewardId/redeem", authz.Require(enrolled), idempotent, h.RedeemRewardForGroup)
+	classroomGroup.Get("/:id/groups/:groupId/transactions", authz.Require(staff, enrolled), h.ListGroupTransactions)
+	classroomGroup.Post("/:id/send-neurons", authz.Require(sender), idempotent, h.SendNeurons)
+	classroomGroup.Post("/:id/send-neurons/bulk", authz.Require(sender), idempotent, h.SendNeuronsBulk)
+	classroomGroup.Get("/:id/user-neurons/:userId", authz.Require(staff, authz.All(enrolled, authz.Self("userId"))), h.GetUserNeurons)
+	classroomGroup.Get("/:id/user-neurons/:userId/expiring", authz.Require(staff, authz.All(enrolled, authz.Self("userId"))), h.GetExpiringNeurons)
+	classroomGroup.Post("/:id/return-neurons", authz.Require(enrolled), idempotent, h.ReturnNeuronsToClassroom)
+	classroomGroup.Post("/:id/deduct-neurons", authz.Require(deductor), idempotent, h.DeductNeurons)
+	classroomGroup.Get("/:id/gift-settings", authz.Require(staff, enrolled), h.GetGiftSettings)
+	classroomGroup.Put("/:id/gift-settings", authz.Require(manager), h.UpdateGiftSettings)
+	classroomGroup.Get("/:id/gifts", authz.Require(staff, enrolled), h.ListGifts)
+	classroomGroup.Post("/:id/gifts", authz.Require(enrolled), idempotent, h.GiveGift)
+	classroomGroup.Post("/:id/gifts/:giftId/approve", authz.Require(manager), h.ApproveGift)
+	classroomGroup.Post("/:id/gifts/:giftId/reject", authz.Require(manager), h.RejectGift)
+	classroomGroup.Get("/:id/neuron-policy", authz.Require(staff, enrolled), h.GetNeuronPolicy)
+	classroomGroup.Put("/:id/neuron-policy", authz.Require(manager), h.UpdateNeuronPolicy)
+	classroomGroup.Post("/:id/end-term", authz.Require(manager), idempotent, h.EndTerm)
+	classroomGroup.Get("/:id/allowances", authz.Require(staff), h.ListAllowanceRules)
+	classroomGroup.Post("/:id/allowances", authz.Require(sender), h.CreateAllowanceRule)
+	classroomGroup.Put("/:id/allowances/:allowanceId", authz.Require(sender), h.UpdateAllowanceRule)
+	classroomGroup.Delete("/:id/allowances/:allowanceId", authz.Require(sender), h.DeleteAllowanceRule)
+	classroomGroup.Get("/:id/allowances/:allowanceId/runs", authz.Require(staff), h.ListAllowanceRuns)
+	classroomGroup.Get("/:id/ledger/reconcile", authz.Require(staff), h.ReconcileLedger)
+	classroomGroup.Get("/:id/transactions", authz.Require(staff), h.ListClassroomTransactions)
+	classroomGroup.Post("/:id/transactions/:txId/reverse", authz.Require(manager), h.ReverseTransaction)
+	classroomGroup.Get("/:id/categories", authz.Require(staff, enrolled), h.ListCategories)
+	classroomGroup.Post("/:id/categories", authz.Require(manager), h.CreateCategory)
+	classroomGroup.Put("/:id/categories/:categoryId", authz.Require(manager), h.UpdateCategory)
+	classroomGroup.Delete("/:id/categories/:categoryId", authz.Require(manager), h.DeleteCategory)
+	classroomGroup.Get("/:id/rewards", authz.Require(staff, enrolled), h.ListRewards)
+	classroomGroup.Post("/:id/rewards", authz.Require(manager), h.CreateReward)
+	classroomGroup.Get("/:id/rewards/:rewardId", authz.Require(staff, enrolled), h.GetReward)
+	classroomGroup.Put("/:id/rewards/:rewardId", authz.Require(manager), h.UpdateReward)
+	classroomGroup.Delete("/:id/rewards/:rewardId", authz.Require(manager), h.DeleteReward)
+	classroomGroup.Post("/:id/rewards/:rewardId/redeem", authz.Require(enrolled), idempotent, h.RedeemReward)
+	classroomGroup.Get("/:id/redemption-requests", authz.Require(staff, enrolled), h.ListRedemptionRequests)
+	classroomGroup.Post("/:id/redemption-requests/:requestId/approve", authz.Require(manager), h.ApproveRedemptionRequest)
+	classroomGroup.Post("/:id/redemption-requests/:requestId/reject", authz.Require(manager), h.RejectRedemptionRequest)
+	classroomGroup.Post("/:id/redemption-requests/:requestId/fulfill", authz.Require(manager), h.FulfillRedemptionRequest)
+	classroomGroup.Get("/:id/badges", authz.Require(staff, enrolled), h.ListBadges)
+	classroomGroup.Post("/:id/badges", authz.Require(manager), h.CreateBadge)
+	classroomGroup.Put("/:id/badges/:badgeId", authz.Require(manager), h.UpdateBadge)
+	classroomGroup.Delete("/:id/badges/:badgeId", authz.Require(manager), h.DeleteBadge)
+	classroomGroup.Post("/:id/badges/:badgeId/award", authz.Require(sender), h.AwardBadge)
+	classroomGroup.Get("/:id/badge-awards", authz.Require(staff, enrolled), h.ListBadgeAwards)
+	classroomGroup.Get("/:id/students/:studentId/categories", authz.Require(staff, authz.All(enrolled, authz.Self("studentId"))), h.GetStudentCategoryTotals)
+
+	app.Get("/users/me/transactions", h.policy.Authenticate, h.ListMyTransactions)
+	app.Get("/users/me/badges", h.policy.Authenticate, h.ListMyBadges)
 }
 
 func (h *Handler) CreateClassroom(c *fiber.Ctx) error {
-	session := lucia.GetSession(c)
-	u, err := h.userService.GetUserByAuthUserID(c.Context(), session.UserID)
-	if err != nil {
-		return err
-	}
+	u := authz.CurrentUser(c)
 
 	var input struct {
 		Name string `json:"name"`
@@ -80,13 +164,31 @@ func (h *Handler) UpdateClassroom(c *fiber.Ctx) error {
 		return errors.ErrBadRequest("invalid classroom id")
 	}
 
-	var input Classroom
+	// Only the name can be edited; the teacher and neurons have their own operations
+	var input struct {
+		Name string `json:"name"`
+	}
 	if err := c.BodyParser(&input); err != nil {
 		return errors.ErrBadRequest("invalid input")
 	}
-	input.ID = id
 
-	_, err = h.service.UpdateClassroom(c.Context(), &input)
+	_, err = h.service.UpdateClassroom(c.Context(), &Classroom{ID: id, Name: input.Name})
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// ArchiveClassroom is a soft delete: the classroom and its history are kept
+// and can be restored until an admin purges them
+func (h *Handler) ArchiveClassroom(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	err = h.service.ArchiveClassroom(c.Context(), id)
 	if err != nil {
 		return err
 	}
@@ -94,13 +196,13 @@ func (h *Handler) UpdateClassroom(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusOK)
 }
 
-func (h *Handler) DeleteClassroom(c *fiber.Ctx) error {
+func (h *Handler) RestoreClassroom(c *fiber.Ctx) error {
 	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
 		return errors.ErrBadRequest("invalid classroom id")
 	}
 
-	err = h.service.DeleteClassroom(c.Context(), id)
+	err = h.service.RestoreClassroom(c.Context(), id)
 	if err != nil {
 		return err
 	}
@@ -109,10 +211,12 @@ func (h *Handler) DeleteClassroom(c *fiber.Ctx) error {
 }
 
 func (h *Handler) ListClassrooms(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	offset, _ := strconv.Atoi(c.Query("offset", "0"))
 
-	classrooms, err := h.service.ListClassrooms(c.Context(), limit, offset)
+	classrooms, err := h.service.ListClassrooms(c.Context(), u.OrganizationID, c.QueryBool("archived"), limit, offset)
 	if err != nil {
 		return err
 	}
@@ -161,6 +265,8 @@ func (h *Handler) RemoveStudentFromClassroom(c *fiber.Ctx) error {
 }
 
 func (h *Handler) UpdateAvailableNeurons(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
 	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
 		return errors.ErrBadRequest("invalid classroom id")
@@ -173,7 +279,7 @@ func (h *Handler) UpdateAvailableNeurons(c *fiber.Ctx) error {
 		return errors.ErrBadRequest("invalid input")
 	}
 
-	err = h.service.UpdateAvailableNeurons(c.Context(), classroomID, input.Neurons)
+	err = h.service.UpdateAvailableNeurons(c.Context(), u.ID, classroomID, input.Neurons)
 	if err != nil {
 		return err
 	}
@@ -196,11 +302,7 @@ func (h *Handler) GetClassroomStudents(c *fiber.Ctx) error {
 }
 
 func (h *Handler) SendNeurons(c *fiber.Ctx) error {
-	session := lucia.GetSession(c)
-	u, err := h.userService.GetUserByAuthUserID(c.Context(), session.UserID)
-	if err != nil {
-		return err
-	}
+	u := authz.CurrentUser(c)
 
 	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
@@ -210,12 +312,13 @@ func (h *Handler) SendNeurons(c *fiber.Ctx) error {
 	var input struct {
 		StudentID int64 `json:"student_id"`
 		Amount    int   `json:"amount"`
+		TransactionDetails
 	}
 	if err := c.BodyParser(&input); err != nil {
 		return errors.ErrBadRequest("invalid input")
 	}
 
-	err = h.service.SendNeurons(c.Context(), u.ID, classroomID, input.StudentID, input.Amount)
+	err = h.service.SendNeurons(c.Context(), u.ID, classroomID, input.StudentID, input.Amount, input.TransactionDetails)
 	if err != nil {
 		return err
 	}
@@ -223,6 +326,31 @@ func (h *Handler) SendNeurons(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusOK)
 }
 
+func (h *Handler) SendNeuronsBulk(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		Transfers  []BulkTransfer `json:"transfers"`
+		AmountEach int            `json:"amount_each"`
+		TransactionDetails
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	batch, err := h.service.SendNeuronsBulk(c.Context(), u.ID, classroomID, input.Transfers, input.AmountEach, input.TransactionDetails)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(batch)
+}
+
 func (h *Handler) GetUserNeurons(c *fiber.Ctx) error {
 	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
@@ -243,16 +371,12 @@ func (h *Handler) GetUserNeurons(c *fiber.Ctx) error {
 }
 
 func (h *Handler) ListUserClassrooms(c *fiber.Ctx) error {
-	session := lucia.GetSession(c)
-	u, err := h.userService.GetUserByAuthUserID(c.Context(), session.UserID)
-	if err != nil {
-		return err
-	}
+	u := authz.CurrentUser(c)
 
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	offset, _ := strconv.Atoi(c.Query("offset", "0"))
 
-	classrooms, err := h.service.ListUserClassrooms(c.Context(), u.ID, u.Role, limit, offset)
+	classrooms, err := h.service.ListUserClassrooms(c.Context(), u.ID, u.Role, c.QueryBool("archived"), limit, offset)
 	if err != nil {
 		return err
 	}
@@ -261,28 +385,303 @@ func (h *Handler) ListUserClassrooms(c *fiber.Ctx) error {
 }
 
 func (h *Handler) ReturnNeuronsToClassroom(c *fiber.Ctx) error {
-	session := lucia.GetSession(c)
-	u, err := h.userService.GetUserByAuthUserID(c.Context(), session.UserID)
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		Amount int `json:"amount"`
+		TransactionDetails
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	err = h.service.ReturnNeuronsToClassroom(c.Context(), u.ID, classroomID, input.Amount, input.TransactionDetails)
 	if err != nil {
 		return err
 	}
 
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) DeductNeurons(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
 	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
 		return errors.ErrBadRequest("invalid classroom id")
 	}
 
 	var input struct {
-		Amount int `json:"amount"`
+		StudentID  int64  `json:"student_id"`
+		Amount     int    `json:"amount"`
+		Reason     string `json:"reason"`
+		CategoryID *int64 `json:"category_id"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	transaction, err := h.service.DeductNeurons(c.Context(), u.ID, classroomID, input.StudentID, input.Amount,
+		TransactionDetails{CategoryID: input.CategoryID, Note: input.Reason})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(transaction)
+}
+
+func (h *Handler) ReconcileLedger(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	reconciliation, err := h.service.ReconcileLedger(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reconciliation)
+}
+
+func (h *Handler) ListClassroomTransactions(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return err
+	}
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		id, err := strconv.ParseInt(categoryID, 10, 64)
+		if err != nil {
+			return errors.ErrBadRequest("invalid category id")
+		}
+		filter.CategoryID = &id
+	}
+	if studentID := c.Query("student_id"); studentID != "" {
+		id, err := strconv.ParseInt(studentID, 10, 64)
+		if err != nil {
+			return errors.ErrBadRequest("invalid student id")
+		}
+		filter.UserID = &id
+	}
+
+	page, err := h.service.ListClassroomTransactions(c.Context(), classroomID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(page)
+}
+
+func (h *Handler) ListMyTransactions(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return err
+	}
+	if classroomID := c.Query("classroom_id"); classroomID != "" {
+		id, err := strconv.ParseInt(classroomID, 10, 64)
+		if err != nil {
+			return errors.ErrBadRequest("invalid classroom id")
+		}
+		filter.ClassroomID = &id
+	}
+
+	page, err := h.service.ListUserTransactions(c.Context(), u.ID, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(page)
+}
+
+func (h *Handler) ReverseTransaction(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	transactionID, err := strconv.ParseInt(c.Params("txId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid transaction id")
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	reversal, err := h.service.ReverseTransaction(c.Context(), u.ID, classroomID, transactionID, input.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reversal)
+}
+
+func (h *Handler) ListCategories(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	categories, err := h.service.ListCategories(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(categories)
+}
+
+func (h *Handler) CreateCategory(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		Name          string `json:"name"`
+		DefaultAmount int    `json:"default_amount"`
 	}
 	if err := c.BodyParser(&input); err != nil {
 		return errors.ErrBadRequest("invalid input")
 	}
 
-	err = h.service.ReturnNeuronsToClassroom(c.Context(), u.ID, classroomID, input.Amount)
+	category, err := h.service.CreateCategory(c.Context(), classroomID, input.Name, input.DefaultAmount)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(category)
+}
+
+func (h *Handler) UpdateCategory(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	categoryID, err := strconv.ParseInt(c.Params("categoryId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid category id")
+	}
+
+	var input struct {
+		Name          string `json:"name"`
+		DefaultAmount int    `json:"default_amount"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	category, err := h.service.UpdateCategory(c.Context(), classroomID, categoryID, input.Name, input.DefaultAmount)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(category)
+}
+
+func (h *Handler) DeleteCategory(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	categoryID, err := strconv.ParseInt(c.Params("categoryId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid category id")
+	}
+
+	err = h.service.DeleteCategory(c.Context(), classroomID, categoryID)
 	if err != nil {
 		return err
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
+
+func (h *Handler) GetStudentCategoryTotals(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	studentID, err := strconv.ParseInt(c.Params("studentId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid student id")
+	}
+
+	totals, err := h.service.GetStudentCategoryTotals(c.Context(), classroomID, studentID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(totals)
+}
+
+// parseTransactionFilter reads the filters shared by the transaction history endpoints
+func parseTransactionFilter(c *fiber.Ctx) (TransactionFilter, error) {
+	filter := TransactionFilter{
+		TransactionType: c.Query("type"),
+		Cursor:          c.Query("cursor"),
+		Ascending:       c.Query("sort") == "asc",
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit", "20"))
+
+	var err error
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount, err = queryInt(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = queryInt(c, "max_amount"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// queryTime parses an optional RFC 3339 query parameter
+func queryTime(c *fiber.Ctx, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.ErrBadRequest("invalid " + name)
+	}
+	return &t, nil
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(c *fiber.Ctx, name string) (*int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, errors.ErrBadRequest("invalid " + name)
+	}
+	return &n, nil
+}
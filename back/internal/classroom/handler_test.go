@@ -0,0 +1,243 @@
+package classroom
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/Abraxas-365/toolkit/pkg/lucia"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Actors of the access matrix, all evaluated against classroom 1
+const (
+	actorOwner        = "owner"
+	actorCoTeacher    = "co_teacher"
+	actorAssistant    = "assistant"
+	actorOtherTeacher = "other_teacher"
+	actorStudent      = "student"
+	actorOtherStudent = "other_student"
+	actorAnonymous    = "anonymous"
+)
+
+var actors = []string{actorOwner, actorCoTeacher, actorAssistant, actorOtherTeacher, actorStudent, actorOtherStudent, actorAnonymous}
+
+var actorUsers = map[string]*user.User{
+	actorOwner:        {ID: 10, Role: "teacher"},
+	actorCoTeacher:    {ID: 11, Role: "teacher"},
+	actorAssistant:    {ID: 12, Role: "teacher"},
+	actorOtherTeacher: {ID: 13, Role: "teacher"},
+	actorStudent:      {ID: 20, Role: "student"},
+	actorOtherStudent: {ID: 21, Role: "student"},
+}
+
+// Student 22 is a classmate of actorStudent
+var enrolledStudents = map[int64]bool{20: true, 22: true}
+
+var staffRoles = map[int64]string{
+	10: StaffRoleOwner,
+	11: StaffRoleCoTeacher,
+	12: StaffRoleAssistant,
+}
+
+type stubUserService struct {
+	user.Servicer
+}
+
+func (stubUserService) GetUserByAuthUserID(ctx context.Context, authUserID string) (*user.User, error) {
+	u, ok := actorUsers[authUserID]
+	if !ok {
+		return nil, errors.ErrNotFound("user not found")
+	}
+	return u, nil
+}
+
+type stubClassroomStore struct{}
+
+func (stubClassroomStore) GetClassroomTeacherID(ctx context.Context, classroomID int64) (int64, error) {
+	if classroomID != 1 {
+		return 0, errors.ErrNotFound("classroom not found")
+	}
+	return actorUsers[actorOwner].ID, nil
+}
+
+func (stubClassroomStore) IsStudentInClassroom(ctx context.Context, classroomID, studentID int64) (bool, error) {
+	return classroomID == 1 && enrolledStudents[studentID], nil
+}
+
+func (stubClassroomStore) HasClassroomPermission(ctx context.Context, classroomID, userID int64, permission string) (bool, error) {
+	role, ok := staffRoles[userID]
+	if classroomID != 1 || !ok {
+		return false, nil
+	}
+	member := &StaffMember{Role: role, Status: StaffStatusActive}
+	return member.Can(permission), nil
+}
+
+// newAccessTestApp serves the classroom routes with a service that panics when
+// called; a panic is answered with 200 because it means access was granted.
+func newAccessTestApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: errors.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if recover() != nil {
+				err = c.SendStatus(fiber.StatusOK)
+			}
+		}()
+		if actor := c.Get("X-Test-Actor"); actor != "" {
+			c.Locals("session", &lucia.UserSession{UserID: actor})
+		}
+		return c.Next()
+	})
+
+	policy := authz.NewPolicy(stubUserService{}, stubClassroomStore{})
+	NewHandler(struct{ Servicer }{}, policy, nil).RegisterRoutes(app)
+	return app
+}
+
+func TestRouteAccess(t *testing.T) {
+	everyone := []string{actorOwner, actorCoTeacher, actorAssistant, actorOtherTeacher, actorStudent, actorOtherStudent}
+	teachers := []string{actorOwner, actorCoTeacher, actorAssistant, actorOtherTeacher}
+	students := []string{actorStudent, actorOtherStudent}
+	owner := []string{actorOwner}
+	managers := []string{actorOwner, actorCoTeacher}
+	staff := []string{actorOwner, actorCoTeacher, actorAssistant}
+	staffAndEnrolled := []string{actorOwner, actorCoTeacher, actorAssistant, actorStudent}
+	enrolled := []string{actorStudent}
+
+	tests := []struct {
+		method  string
+		path    string
+		allowed []string
+	}{
+		{"POST", "/classrooms", teachers},
+		{"GET", "/classrooms", teachers},
+		{"GET", "/classrooms/user", everyone},
+		{"POST", "/classrooms/join", students},
+		{"GET", "/classrooms/1", staffAndEnrolled},
+		{"PUT", "/classrooms/1", managers},
+		{"DELETE", "/classrooms/1", owner},
+		{"POST", "/classrooms/1/archive", owner},
+		{"POST", "/classrooms/1/restore", owner},
+		{"GET", "/classrooms/1/staff", staff},
+		{"POST", "/classrooms/1/staff", owner},
+		{"POST", "/classrooms/1/staff/accept", teachers},
+		{"PUT", "/classrooms/1/staff/11", owner},
+		{"DELETE", "/classrooms/1/staff/11", []string{actorOwner, actorCoTeacher}},
+		{"DELETE", "/classrooms/1/staff/12", []string{actorOwner, actorAssistant}},
+		{"POST", "/classrooms/1/transfer-ownership", owner},
+		{"GET", "/classrooms/1/audit", owner},
+		{"POST", "/classrooms/1/students", managers},
+		{"DELETE", "/classrooms/1/students/22", managers},
+		{"GET", "/classrooms/1/join-code", managers},
+		{"POST", "/classrooms/1/join-code", managers},
+		{"PUT", "/classrooms/1/join-code", managers},
+		{"GET", "/classrooms/1/enrollment-requests", managers},
+		{"POST", "/classrooms/1/enrollment-requests/approve", managers},
+		{"POST", "/classrooms/1/enrollment-requests/2/approve", managers},
+		{"POST", "/classrooms/1/enrollment-requests/2/reject", managers},
+		{"PUT", "/classrooms/1/neurons", managers},
+		{"GET", "/classrooms/1/students", staffAndEnrolled},
+		{"GET", "/classrooms/1/groups", staffAndEnrolled},
+		{"POST", "/classrooms/1/groups", managers},
+		{"POST", "/classrooms/1/groups/shuffle", managers},
+		{"PUT", "/classrooms/1/groups/5", managers},
+		{"PUT", "/classrooms/1/groups/5/members", managers},
+		{"DELETE", "/classrooms/1/groups/5", managers},
+		{"POST", "/classrooms/1/groups/5/send-neurons", staff},
+		{"POST", "/classrooms/1/groups/5/fund", staff},
+		{"POST", "/classrooms/1/groups/5/contribute", enrolled},
+		{"POST", "/classrooms/1/groups/5/rewards/3/redeem", enrolled},
+		{"GET", "/classrooms/1/groups/5/transactions", staffAndEnrolled},
+		{"POST", "/classrooms/1/send-neurons", staff},
+		{"POST", "/classrooms/1/send-neurons/bulk", staff},
+		{"GET", "/classrooms/1/user-neurons/20", staffAndEnrolled},
+		{"GET", "/classrooms/1/user-neurons/22", staff},
+		{"GET", "/classrooms/1/user-neurons/20/expiring", staffAndEnrolled},
+		{"GET", "/classrooms/1/user-neurons/22/expiring", staff},
+		{"POST", "/classrooms/1/return-neurons", enrolled},
+		{"POST", "/classrooms/1/deduct-neurons", managers},
+		{"GET", "/classrooms/1/gift-settings", staffAndEnrolled},
+		{"PUT", "/classrooms/1/gift-settings", managers},
+		{"GET", "/classrooms/1/gifts", staffAndEnrolled},
+		{"POST", "/classrooms/1/gifts", enrolled},
+		{"POST", "/classrooms/1/gifts/4/approve", managers},
+		{"POST", "/classrooms/1/gifts/4/reject", managers},
+		{"GET", "/classrooms/1/neuron-policy", staffAndEnrolled},
+		{"PUT", "/classrooms/1/neuron-policy", managers},
+		{"POST", "/classrooms/1/end-term", managers},
+		{"GET", "/classrooms/1/allowances", staff},
+		{"POST", "/classrooms/1/allowances", staff},
+		{"PUT", "/classrooms/1/allowances/7", staff},
+		{"DELETE", "/classrooms/1/allowances/7", staff},
+		{"GET", "/classrooms/1/allowances/7/runs", staff},
+		{"GET", "/classrooms/1/ledger/reconcile", staff},
+		{"GET", "/classrooms/1/transactions", staff},
+		{"POST", "/classrooms/1/transactions/9/reverse", managers},
+		{"GET", "/classrooms/1/categories", staffAndEnrolled},
+		{"POST", "/classrooms/1/categories", managers},
+		{"PUT", "/classrooms/1/categories/2", managers},
+		{"DELETE", "/classrooms/1/categories/2", managers},
+		{"GET", "/classrooms/1/rewards", staffAndEnrolled},
+		{"POST", "/classrooms/1/rewards", managers},
+		{"GET", "/classrooms/1/rewards/3", staffAndEnrolled},
+		{"PUT", "/classrooms/1/rewards/3", managers},
+		{"DELETE", "/classrooms/1/rewards/3", managers},
+		{"POST", "/classrooms/1/rewards/3/redeem", enrolled},
+		{"GET", "/classrooms/1/redemption-requests", staffAndEnrolled},
+		{"POST", "/classrooms/1/redemption-requests/8/approve", managers},
+		{"POST", "/classrooms/1/redemption-requests/8/reject", managers},
+		{"POST", "/classrooms/1/redemption-requests/8/fulfill", managers},
+		{"GET", "/classrooms/1/badges", staffAndEnrolled},
+		{"POST", "/classrooms/1/badges", managers},
+		{"PUT", "/classrooms/1/badges/6", managers},
+		{"DELETE", "/classrooms/1/badges/6", managers},
+		{"POST", "/classrooms/1/badges/6/award", staff},
+		{"GET", "/classrooms/1/badge-awards", staffAndEnrolled},
+		{"GET", "/classrooms/1/students/20/categories", staffAndEnrolled},
+		{"GET", "/classrooms/1/students/22/categories", staff},
+		{"GET", "/users/me/transactions", everyone},
+		{"GET", "/users/me/badges", everyone},
+	}
+
+	app := newAccessTestApp()
+	for _, tt := range tests {
+		allowed := make(map[string]bool, len(tt.allowed))
+		for _, actor := range tt.allowed {
+			allowed[actor] = true
+		}
+
+		for _, actor := range actors {
+			t.Run(tt.method+" "+tt.path+" as "+actor, func(t *testing.T) {
+				req := httptest.NewRequest(tt.method, tt.path, nil)
+				if actor != actorAnonymous {
+					req.Header.Set("X-Test-Actor", actor)
+				}
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatalf("request failed: %v", err)
+				}
+
+				// Handlers that get past the access rules either reach the
+				// service or reject the empty body, never with 401 or 403
+				got := resp.StatusCode
+				if got != fiber.StatusUnauthorized && got != fiber.StatusForbidden {
+					got = fiber.StatusOK
+				}
+				want := fiber.StatusForbidden
+				switch {
+				case actor == actorAnonymous:
+					want = fiber.StatusUnauthorized
+				case allowed[actor]:
+					want = fiber.StatusOK
+				}
+				if got != want {
+					t.Errorf("expected %d, got %d", want, resp.StatusCode)
+				}
+			})
+		}
+	}
+}
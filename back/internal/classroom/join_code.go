@@ -0,0 +1,113 @@
+package classroom
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Errors returned when a join code can't be used
+var (
+	ErrInvalidJoinCode  = errors.ErrNotFound("invalid join code")
+	ErrJoiningDisabled  = errors.ErrForbidden("joining this classroom is disabled")
+	ErrJoinCodeExpired  = errors.ErrForbidden("join code has expired")
+	ErrJoinCodeUsedUp   = errors.ErrForbidden("join code has reached its usage limit")
+	ErrAlreadyEnrolled  = errors.ErrConflict("already enrolled in this classroom")
+	ErrEnrollmentExists = errors.ErrConflict("enrollment request already pending")
+)
+
+// Enrollment statuses returned when joining a classroom
+const (
+	EnrollmentStatusEnrolled = "enrolled"
+	EnrollmentStatusPending  = "pending"
+)
+
+const (
+	joinCodeLength = 8
+	// joinCodeAlphabet leaves out characters that are easy to confuse (0/O, 1/I/L)
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+// JoinCode lets students enroll themselves in a classroom
+type JoinCode struct {
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	Code        string `json:"code" db:"code"`
+	Enabled     bool   `json:"enabled" db:"enabled"`
+	// RequiresApproval makes joins wait for the teacher instead of enrolling right away
+	RequiresApproval bool       `json:"requires_approval" db:"requires_approval"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	// MaxUses caps how many students can join with the code; nil means no cap
+	MaxUses   *int      `json:"max_uses,omitempty" db:"max_uses"`
+	Uses      int       `json:"uses" db:"uses"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// JoinCodeUpdate changes some of a join code's settings; settings left out are kept
+type JoinCodeUpdate struct {
+	Enabled          *bool               `json:"enabled"`
+	RequiresApproval *bool               `json:"requires_approval"`
+	ExpiresAt        Optional[time.Time] `json:"expires_at"`
+	MaxUses          Optional[int]       `json:"max_uses"`
+}
+
+// apply merges the update into the stored settings
+func (u *JoinCodeUpdate) apply(j *JoinCode) {
+	if u.Enabled != nil {
+		j.Enabled = *u.Enabled
+	}
+	if u.RequiresApproval != nil {
+		j.RequiresApproval = *u.RequiresApproval
+	}
+	u.ExpiresAt.apply(&j.ExpiresAt)
+	u.MaxUses.apply(&j.MaxUses)
+}
+
+// JoinResult tells a student whether joining enrolled them or is waiting for approval
+type JoinResult struct {
+	ClassroomID int64  `json:"classroom_id"`
+	Status      string `json:"status"`
+}
+
+// check reports why the code can't be used right now, if it can't
+func (j *JoinCode) check(now time.Time) error {
+	if !j.Enabled {
+		return ErrJoiningDisabled
+	}
+	if j.ExpiresAt != nil && !now.Before(*j.ExpiresAt) {
+		return ErrJoinCodeExpired
+	}
+	if j.MaxUses != nil && j.Uses >= *j.MaxUses {
+		return ErrJoinCodeUsedUp
+	}
+	return nil
+}
+
+func (j *JoinCode) validate() error {
+	if j.MaxUses != nil && *j.MaxUses <= 0 {
+		return errors.ErrBadRequest("max uses must be positive")
+	}
+	return nil
+}
+
+// newJoinCode generates a random, human-typeable code
+func newJoinCode() (string, error) {
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	code := make([]byte, joinCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.ErrUnexpected("failed to generate join code")
+		}
+		code[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeJoinCode accepts codes typed in lowercase or with spaces and dashes
+func normalizeJoinCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
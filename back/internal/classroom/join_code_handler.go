@@ -0,0 +1,98 @@
+package classroom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// joinCodeInput is the editable part of a join code
+type joinCodeInput struct {
+	RequiresApproval bool       `json:"requires_approval"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	MaxUses          *int       `json:"max_uses"`
+}
+
+func (h *Handler) GetJoinCode(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	joinCode, err := h.service.GetJoinCode(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(joinCode)
+}
+
+func (h *Handler) GenerateJoinCode(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input joinCodeInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ErrBadRequest("invalid input")
+		}
+	}
+
+	joinCode, err := h.service.GenerateJoinCode(c.Context(), &JoinCode{
+		ClassroomID:      classroomID,
+		RequiresApproval: input.RequiresApproval,
+		ExpiresAt:        input.ExpiresAt,
+		MaxUses:          input.MaxUses,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(joinCode)
+}
+
+func (h *Handler) UpdateJoinCode(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	// Only the settings in the body are changed
+	var update JoinCodeUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	joinCode, err := h.service.UpdateJoinCode(c.Context(), classroomID, &update)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(joinCode)
+}
+
+func (h *Handler) JoinClassroom(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	result, err := h.service.JoinClassroom(c.Context(), u.ID, input.Code)
+	if err != nil {
+		return err
+	}
+
+	if result.Status == EnrollmentStatusPending {
+		return c.Status(fiber.StatusAccepted).JSON(result)
+	}
+	return c.JSON(result)
+}
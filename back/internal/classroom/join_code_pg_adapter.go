@@ -0,0 +1,151 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+const joinCodeColumns = "classroom_id, code, enabled, requires_approval, expires_at, max_uses, uses, created_at"
+
+// errJoinCodeTaken is returned when a generated code collides with another classroom's
+var errJoinCodeTaken = errors.ErrConflict("join code already taken")
+
+// SaveJoinCode creates the classroom's join code or replaces it with a new one,
+// resetting its usage count
+func (r *PostgresRepository) SaveJoinCode(ctx context.Context, joinCode *JoinCode) error {
+	query := `
+		INSERT INTO classroom_join_codes (classroom_id, code, enabled, requires_approval, expires_at, max_uses, uses, created_at)
+		VALUES (:classroom_id, :code, :enabled, :requires_approval, :expires_at, :max_uses, 0, :created_at)
+		ON CONFLICT (classroom_id) DO UPDATE
+		SET code = EXCLUDED.code, enabled = EXCLUDED.enabled, requires_approval = EXCLUDED.requires_approval,
+			expires_at = EXCLUDED.expires_at, max_uses = EXCLUDED.max_uses, uses = 0, created_at = EXCLUDED.created_at
+	`
+	_, err := r.db.NamedExecContext(ctx, query, joinCode)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errJoinCodeTaken
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to save join code: %v", err))
+	}
+	joinCode.Uses = 0
+	return nil
+}
+
+func (r *PostgresRepository) GetJoinCode(ctx context.Context, classroomID int64) (*JoinCode, error) {
+	query := "SELECT " + joinCodeColumns + " FROM classroom_join_codes WHERE classroom_id = $1"
+	var joinCode JoinCode
+	err := r.db.GetContext(ctx, &joinCode, query, classroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("classroom has no join code")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get join code: %v", err))
+	}
+	return &joinCode, nil
+}
+
+// UpdateJoinCode changes the join settings without changing the code itself
+func (r *PostgresRepository) UpdateJoinCode(ctx context.Context, joinCode *JoinCode) error {
+	query := `
+		UPDATE classroom_join_codes
+		SET enabled = :enabled, requires_approval = :requires_approval, expires_at = :expires_at, max_uses = :max_uses
+		WHERE classroom_id = :classroom_id
+	`
+	_, err := r.db.NamedExecContext(ctx, query, joinCode)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update join code: %v", err))
+	}
+	return nil
+}
+
+// JoinClassroom uses a join code to enroll the student, or to file an
+// enrollment request when the classroom requires approval. Codes of
+// classrooms in other organizations are treated as unknown classrooms.
+func (r *PostgresRepository) JoinClassroom(ctx context.Context, code string, studentID, organizationID int64, now time.Time) (*JoinResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// Lock the code so concurrent joins can't exceed its usage cap
+	var joinCode JoinCode
+	err = tx.GetContext(ctx, &joinCode, "SELECT "+joinCodeColumns+" FROM classroom_join_codes WHERE code = $1 FOR UPDATE", code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidJoinCode
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get join code: %v", err))
+	}
+
+	var classroom struct {
+		OrganizationID int64 `db:"organization_id"`
+		Archived       bool  `db:"archived"`
+	}
+	err = tx.GetContext(ctx, &classroom, `
+		SELECT organization_id, archived_at IS NOT NULL AS archived FROM classrooms WHERE id = $1
+	`, joinCode.ClassroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get classroom: %v", err))
+	}
+	if classroom.OrganizationID != organizationID {
+		return nil, errors.ErrNotFound("classroom not found")
+	}
+	if err := joinCode.check(now); err != nil {
+		return nil, err
+	}
+	if classroom.Archived {
+		return nil, ErrClassroomArchived
+	}
+
+	var enrolled bool
+	err = tx.GetContext(ctx, &enrolled, `
+		SELECT EXISTS(SELECT 1 FROM users_classrooms WHERE classroom_id = $1 AND user_id = $2)
+	`, joinCode.ClassroomID, studentID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to check enrollment: %v", err))
+	}
+	if enrolled {
+		return nil, ErrAlreadyEnrolled
+	}
+
+	result := &JoinResult{ClassroomID: joinCode.ClassroomID}
+	if joinCode.RequiresApproval {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO enrollment_requests (classroom_id, user_id, status, created_at)
+			VALUES ($1, $2, 'pending', $3)
+			ON CONFLICT (classroom_id, user_id) WHERE status = 'pending' DO NOTHING
+		`, joinCode.ClassroomID, studentID, now)
+		if err != nil {
+			return nil, errors.ErrDatabase(fmt.Sprintf("failed to create enrollment request: %v", err))
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, ErrEnrollmentExists
+		}
+		result.Status = EnrollmentStatusPending
+	} else {
+		_, err = tx.ExecContext(ctx, "INSERT INTO users_classrooms (user_id, classroom_id) VALUES ($1, $2)", studentID, joinCode.ClassroomID)
+		if err != nil {
+			return nil, errors.ErrDatabase(fmt.Sprintf("failed to add student to classroom: %v", err))
+		}
+		if _, err := ensureLedgerAccount(ctx, tx, joinCode.ClassroomID, AccountTypeStudent, &studentID); err != nil {
+			return nil, err
+		}
+		result.Status = EnrollmentStatusEnrolled
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE classroom_join_codes SET uses = uses + 1 WHERE classroom_id = $1", joinCode.ClassroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to update join code usage: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return result, nil
+}
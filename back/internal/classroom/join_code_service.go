@@ -0,0 +1,125 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// maxJoinCodeAttempts bounds retries when a generated code is already taken
+const maxJoinCodeAttempts = 5
+
+// GenerateJoinCode issues a new join code for the classroom, replacing the previous one
+func (s *Service) GenerateJoinCode(ctx context.Context, joinCode *JoinCode) (*JoinCode, error) {
+	if err := joinCode.validate(); err != nil {
+		return nil, err
+	}
+
+	// Verify that the classroom exists and is not archived
+	_, err := s.getActiveClassroom(ctx, joinCode.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	joinCode.Enabled = true
+	joinCode.CreatedAt = time.Now()
+	for attempt := 0; attempt < maxJoinCodeAttempts; attempt++ {
+		joinCode.Code, err = newJoinCode()
+		if err != nil {
+			return nil, err
+		}
+		err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+			err := s.repo.SaveJoinCode(ctx, joinCode)
+			if err != nil {
+				return nil, err
+			}
+			return classroomChange(joinCode.ClassroomID, ActionJoinCodeGenerated, "classroom", joinCode.ClassroomID, nil, joinCode), nil
+		})
+		if err != errJoinCodeTaken {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return joinCode, nil
+}
+
+// GetJoinCode retrieves the classroom's join code and settings
+func (s *Service) GetJoinCode(ctx context.Context, classroomID int64) (*JoinCode, error) {
+	return s.repo.GetJoinCode(ctx, classroomID)
+}
+
+// UpdateJoinCode changes whether and how students can join with the current
+// code. Settings the update leaves out keep their current value.
+func (s *Service) UpdateJoinCode(ctx context.Context, classroomID int64, update *JoinCodeUpdate) (*JoinCode, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	var after *JoinCode
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		// The code must have been generated first
+		before, err := s.repo.GetJoinCode(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		joinCode := *before
+		update.apply(&joinCode)
+		if err := joinCode.validate(); err != nil {
+			return nil, err
+		}
+
+		err = s.repo.UpdateJoinCode(ctx, &joinCode)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetJoinCode(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionJoinCodeUpdated, "classroom", classroomID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// JoinClassroom enrolls a student in the classroom the code belongs to
+func (s *Service) JoinClassroom(ctx context.Context, studentID int64, code string) (*JoinResult, error) {
+	// Verify that the user exists and is a student
+	student, err := s.userService.GetUser(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Role != "student" {
+		return nil, errors.ErrForbidden("only students can join classrooms")
+	}
+
+	code = normalizeJoinCode(code)
+	if code == "" {
+		return nil, errors.ErrBadRequest("join code is required")
+	}
+
+	var result *JoinResult
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		// Only classrooms of the student's organization can be joined
+		result, err = s.repo.JoinClassroom(ctx, code, studentID, student.OrganizationID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(result.ClassroomID, ActionStudentJoined, "user", studentID, nil, result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package classroom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJoinCodeUpdateKeepsSettingsLeftOut(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxUses := 30
+	stored := JoinCode{Enabled: true, RequiresApproval: true, ExpiresAt: &expiresAt, MaxUses: &maxUses}
+
+	tests := []struct {
+		name string
+		body string
+		want JoinCode
+	}{
+		{
+			name: "disable only",
+			body: `{"enabled": false}`,
+			want: JoinCode{Enabled: false, RequiresApproval: true, ExpiresAt: &expiresAt, MaxUses: &maxUses},
+		},
+		{
+			name: "clear expiry with null",
+			body: `{"expires_at": null}`,
+			want: JoinCode{Enabled: true, RequiresApproval: true, MaxUses: &maxUses},
+		},
+		{
+			name: "change cap and approval",
+			body: `{"max_uses": 5, "requires_approval": false}`,
+			want: JoinCode{Enabled: true, ExpiresAt: &expiresAt, MaxUses: intPtr(5)},
+		},
+		{
+			name: "empty body",
+			body: `{}`,
+			want: stored,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var update JoinCodeUpdate
+			if err := json.Unmarshal([]byte(tt.body), &update); err != nil {
+				t.Fatalf("failed to decode update: %v", err)
+			}
+			got := stored
+			update.apply(&got)
+
+			if got.Enabled != tt.want.Enabled || got.RequiresApproval != tt.want.RequiresApproval {
+				t.Errorf("expected enabled %v and requires_approval %v, got %v and %v",
+					tt.want.Enabled, tt.want.RequiresApproval, got.Enabled, got.RequiresApproval)
+			}
+			if (got.ExpiresAt == nil) != (tt.want.ExpiresAt == nil) || got.ExpiresAt != nil && !got.ExpiresAt.Equal(*tt.want.ExpiresAt) {
+				t.Errorf("expected expires_at %v, got %v", tt.want.ExpiresAt, got.ExpiresAt)
+			}
+			if (got.MaxUses == nil) != (tt.want.MaxUses == nil) || got.MaxUses != nil && *got.MaxUses != *tt.want.MaxUses {
+				t.Errorf("expected max_uses %v, got %v", tt.want.MaxUses, got.MaxUses)
+			}
+		})
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,83 @@
+package classroom
+
+import "time"
+
+// Ledger account types
+const (
+	// AccountTypeMint is the external account neurons are created from and destroyed into
+	AccountTypeMint = "mint"
+	// AccountTypeClassroom is the classroom pool teachers send neurons from
+	AccountTypeClassroom = "classroom"
+	// AccountTypeStudent is a student's wallet within a classroom
+	AccountTypeStudent = "student"
+	// AccountTypeHold keeps a student's neurons reserved for pending redemption requests
+	AccountTypeHold = "hold"
+	// AccountTypeGroup is the wallet a student group shares
+	AccountTypeGroup = "group"
+)
+
+// LedgerAccount is an account in a classroom's double-entry ledger.
+// Balance is materialized from the account's postings.
+type LedgerAccount struct {
+	ID          int64     `json:"id" db:"id"`
+	ClassroomID int64     `json:"classroom_id" db:"classroom_id"`
+	UserID      *int64    `json:"user_id,omitempty" db:"user_id"`
+	GroupID     *int64    `json:"group_id,omitempty" db:"group_id"`
+	AccountType string    `json:"account_type" db:"account_type"`
+	Balance     int       `json:"balance" db:"balance"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// LedgerEntry is an immutable journal entry whose postings always sum to zero
+type LedgerEntry struct {
+	ID            int64            `json:"id" db:"id"`
+	ClassroomID   int64            `json:"classroom_id" db:"classroom_id"`
+	TransactionID *int64           `json:"transaction_id,omitempty" db:"transaction_id"`
+	Description   string           `json:"description" db:"description"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	Postings      []*LedgerPosting `json:"postings" db:"-"`
+}
+
+// LedgerPosting debits (negative amount) or credits (positive amount) an account
+type LedgerPosting struct {
+	ID        int64 `json:"id" db:"id"`
+	EntryID   int64 `json:"entry_id" db:"entry_id"`
+	AccountID int64 `json:"account_id" db:"account_id"`
+	Amount    int   `json:"amount" db:"amount"`
+	// AllowNegative lets the posting take the account below zero, for
+	// deductions in classrooms that allow negative balances
+	AllowNegative bool `json:"-" db:"-"`
+}
+
+// AccountReconciliation compares an account's materialized balance with the sum of its postings
+type AccountReconciliation struct {
+	LedgerAccount
+	PostedBalance int  `json:"posted_balance" db:"posted_balance"`
+	Balanced      bool `json:"balanced" db:"-"`
+}
+
+// LedgerReconciliation is the result of checking a classroom's books
+type LedgerReconciliation struct {
+	ClassroomID int64                    `json:"classroom_id"`
+	Accounts    []*AccountReconciliation `json:"accounts"`
+	// UnbalancedEntries lists journal entries whose postings do not sum to zero
+	UnbalancedEntries []int64 `json:"unbalanced_entries"`
+	// Total is the sum of all account balances, which must be zero
+	Total      int  `json:"total"`
+	Reconciled bool `json:"reconciled"`
+}
+
+// NeuronStats summarizes neurons across every classroom
+type NeuronStats struct {
+	Classrooms int `json:"classrooms" db:"classrooms"`
+	// Minted is the total created by the classroom mints, net of what was destroyed
+	Minted int `json:"minted" db:"minted"`
+	// InPools is the total waiting in classroom pools
+	InPools int `json:"in_pools" db:"in_pools"`
+	// InWallets is the total held by students, including neurons on hold
+	InWallets int `json:"in_wallets" db:"in_wallets"`
+	// InGroups is the total held by group wallets
+	InGroups int `json:"in_groups" db:"in_groups"`
+	// Transactions counts neuron transactions by type
+	Transactions map[string]int `json:"transactions" db:"-"`
+}
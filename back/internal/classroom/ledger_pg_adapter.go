@@ -0,0 +1,279 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+// ensureLedgerAccount opens the account if it does not exist yet and returns it
+func ensureLedgerAccount(ctx context.Context, tx *database.Tx, classroomID int64, accountType string, userID *int64) (*LedgerAccount, error) {
+	query := `
+		INSERT INTO ledger_accounts (classroom_id, user_id, account_type, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (classroom_id, account_type, (COALESCE(user_id, 0)), (COALESCE(group_id, 0)))
+		DO UPDATE SET account_type = EXCLUDED.account_type
+		RETURNING id, classroom_id, user_id, group_id, account_type, balance, created_at
+	`
+	var account LedgerAccount
+	err := tx.GetContext(ctx, &account, query, classroomID, userID, accountType, time.Now())
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to open ledger account: %v", err))
+	}
+	return &account, nil
+}
+
+// ensureGroupAccount opens the group's wallet if it does not exist yet and returns it
+func ensureGroupAccount(ctx context.Context, tx *database.Tx, classroomID, groupID int64) (*LedgerAccount, error) {
+	query := `
+		INSERT INTO ledger_accounts (classroom_id, group_id, account_type, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (classroom_id, account_type, (COALESCE(user_id, 0)), (COALESCE(group_id, 0)))
+		DO UPDATE SET account_type = EXCLUDED.account_type
+		RETURNING id, classroom_id, user_id, group_id, account_type, balance, created_at
+	`
+	var account LedgerAccount
+	err := tx.GetContext(ctx, &account, query, classroomID, groupID, AccountTypeGroup, time.Now())
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to open group wallet: %v", err))
+	}
+	return &account, nil
+}
+
+// getClassroomAccount returns one of the classroom-level accounts (pool or mint)
+func getClassroomAccount(ctx context.Context, q sqlx.QueryerContext, classroomID int64, accountType string) (*LedgerAccount, error) {
+	query := `
+		SELECT id, classroom_id, user_id, account_type, balance, created_at
+		FROM ledger_accounts
+		WHERE classroom_id = $1 AND account_type = $2 AND user_id IS NULL
+	`
+	var account LedgerAccount
+	err := sqlx.GetContext(ctx, q, &account, query, classroomID, accountType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("classroom not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get %s account: %v", accountType, err))
+	}
+	return &account, nil
+}
+
+// getStudentAccount returns the wallet of a student currently enrolled in the classroom
+func getStudentAccount(ctx context.Context, q sqlx.QueryerContext, classroomID, studentID int64) (*LedgerAccount, error) {
+	query := `
+		SELECT la.id, la.classroom_id, la.user_id, la.account_type, la.balance, la.created_at
+		FROM ledger_accounts la
+		JOIN users_classrooms uc ON uc.classroom_id = la.classroom_id AND uc.user_id = la.user_id
+		WHERE la.classroom_id = $1 AND la.user_id = $2 AND la.account_type = 'student'
+	`
+	var account LedgerAccount
+	err := sqlx.GetContext(ctx, q, &account, query, classroomID, studentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStudentNotInClassroom
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get student account: %v", err))
+	}
+	return &account, nil
+}
+
+// postLedgerEntry writes a balanced journal entry and applies its postings to
+// the materialized account balances. Accounts are updated in ID order so
+// concurrent entries always take row locks in the same order, and no account
+// other than the mint may go below zero unless the posting allows it. Neurons
+// leaving a student's wallet and holds spend their lots, except on expiry,
+// which closes the lots itself.
+func postLedgerEntry(ctx context.Context, tx *database.Tx, entry *LedgerEntry) error {
+	sum := 0
+	for _, posting := range entry.Postings {
+		sum += posting.Amount
+	}
+	if len(entry.Postings) < 2 || sum != 0 {
+		return errors.ErrUnexpected("ledger entry is not balanced")
+	}
+	sort.Slice(entry.Postings, func(i, j int) bool {
+		return entry.Postings[i].AccountID < entry.Postings[j].AccountID
+	})
+
+	err := tx.GetContext(ctx, &entry.ID, `
+		INSERT INTO ledger_entries (classroom_id, transaction_id, description, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, entry.ClassroomID, entry.TransactionID, entry.Description, entry.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create ledger entry: %v", err))
+	}
+
+	var spenders []int64
+	spent := map[int64]int{}
+	for _, posting := range entry.Postings {
+		var account LedgerAccount
+		err := tx.GetContext(ctx, &account, `
+			UPDATE ledger_accounts
+			SET balance = balance + $1
+			WHERE id = $2 AND (account_type = 'mint' OR $3 OR balance + $1 >= 0)
+			RETURNING id, classroom_id, user_id, account_type, balance, created_at
+		`, posting.Amount, posting.AccountID, posting.AllowNegative)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return insufficientNeuronsError(ctx, tx, posting.AccountID)
+			}
+			return errors.ErrDatabase(fmt.Sprintf("failed to update ledger account balance: %v", err))
+		}
+		if account.AccountType == AccountTypeStudent || account.AccountType == AccountTypeHold {
+			if _, ok := spent[*account.UserID]; !ok {
+				spenders = append(spenders, *account.UserID)
+			}
+			spent[*account.UserID] -= posting.Amount
+		}
+
+		posting.EntryID = entry.ID
+		err = tx.GetContext(ctx, &posting.ID, `
+			INSERT INTO ledger_postings (entry_id, account_id, amount)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, posting.EntryID, posting.AccountID, posting.Amount)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to create ledger posting: %v", err))
+		}
+	}
+
+	if entry.Description == TransactionTypeExpiry {
+		return nil
+	}
+	for _, studentID := range spenders {
+		if spent[studentID] > 0 {
+			if err := spendLots(ctx, tx, entry.ClassroomID, studentID, spent[studentID], entry.TransactionID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func insufficientNeuronsError(ctx context.Context, tx *database.Tx, accountID int64) error {
+	var accountType string
+	err := tx.GetContext(ctx, &accountType, "SELECT account_type FROM ledger_accounts WHERE id = $1", accountID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to get ledger account: %v", err))
+	}
+	switch accountType {
+	case AccountTypeStudent:
+		return ErrInsufficientStudentNeurons
+	case AccountTypeGroup:
+		return ErrInsufficientGroupNeurons
+	}
+	return ErrInsufficientClassroomNeurons
+}
+
+// transfer records the neuron transaction and posts the matching journal entry moving
+// transaction.Amount from one account to another. Assignments open a lot when
+// the classroom's neurons expire.
+func transfer(ctx context.Context, tx *database.Tx, transaction *NeuronTransaction, from, to *LedgerAccount) error {
+	if err := recordNeuronTransaction(ctx, tx, transaction); err != nil {
+		return err
+	}
+
+	err := postLedgerEntry(ctx, tx, &LedgerEntry{
+		ClassroomID:   transaction.ClassroomID,
+		TransactionID: &transaction.ID,
+		Description:   transaction.TransactionType,
+		CreatedAt:     transaction.CreatedAt,
+		Postings: []*LedgerPosting{
+			{AccountID: from.ID, Amount: -transaction.Amount},
+			{AccountID: to.ID, Amount: transaction.Amount},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if transaction.TransactionType == TransactionTypeAssignment {
+		return grantLot(ctx, tx, transaction)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ReconcileLedger(ctx context.Context, classroomID int64) (*LedgerReconciliation, error) {
+	reconciliation := &LedgerReconciliation{ClassroomID: classroomID}
+
+	err := r.db.SelectContext(ctx, &reconciliation.Accounts, `
+		SELECT la.id, la.classroom_id, la.user_id, la.group_id, la.account_type, la.balance, la.created_at,
+			   COALESCE(SUM(lp.amount), 0) AS posted_balance
+		FROM ledger_accounts la
+		LEFT JOIN ledger_postings lp ON lp.account_id = la.id
+		WHERE la.classroom_id = $1
+		GROUP BY la.id
+		ORDER BY la.id
+	`, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to reconcile ledger accounts: %v", err))
+	}
+
+	err = r.db.SelectContext(ctx, &reconciliation.UnbalancedEntries, `
+		SELECT le.id
+		FROM ledger_entries le
+		JOIN ledger_postings lp ON lp.entry_id = le.id
+		WHERE le.classroom_id = $1
+		GROUP BY le.id
+		HAVING SUM(lp.amount) <> 0
+		ORDER BY le.id
+	`, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to reconcile ledger entries: %v", err))
+	}
+
+	reconciliation.Reconciled = len(reconciliation.UnbalancedEntries) == 0
+	for _, account := range reconciliation.Accounts {
+		account.Balanced = account.Balance == account.PostedBalance
+		reconciliation.Total += account.Balance
+		if !account.Balanced {
+			reconciliation.Reconciled = false
+		}
+	}
+	if reconciliation.Total != 0 {
+		reconciliation.Reconciled = false
+	}
+
+	return reconciliation, nil
+}
+
+func (r *PostgresRepository) GetNeuronStats(ctx context.Context) (*NeuronStats, error) {
+	var stats NeuronStats
+	err := r.db.GetContext(ctx, &stats, `
+		SELECT (SELECT COUNT(*) FROM classrooms) AS classrooms,
+			   COALESCE(-SUM(balance) FILTER (WHERE account_type = 'mint'), 0) AS minted,
+			   COALESCE(SUM(balance) FILTER (WHERE account_type = 'classroom'), 0) AS in_pools,
+			   COALESCE(SUM(balance) FILTER (WHERE account_type IN ('student', 'hold')), 0) AS in_wallets,
+			   COALESCE(SUM(balance) FILTER (WHERE account_type = 'group'), 0) AS in_groups
+		FROM ledger_accounts
+	`)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get neuron stats: %v", err))
+	}
+
+	var counts []struct {
+		TransactionType string `db:"transaction_type"`
+		Count           int    `db:"count"`
+	}
+	err = r.db.SelectContext(ctx, &counts, `
+		SELECT transaction_type, COUNT(*) AS count
+		FROM neuron_transactions
+		GROUP BY transaction_type
+	`)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to count transactions: %v", err))
+	}
+	stats.Transactions = make(map[string]int, len(counts))
+	for _, count := range counts {
+		stats.Transactions[count.TransactionType] = count.Count
+	}
+
+	return &stats, nil
+}
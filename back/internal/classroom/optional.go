@@ -0,0 +1,34 @@
+package classroom
+
+import "encoding/json"
+
+// Optional is a nullable setting in a partial update. It tells a field left
+// out of the request, which keeps the stored value, from an explicit null,
+// which clears it.
+type Optional[T any] struct {
+	// Set reports whether the field was in the request
+	Set   bool
+	Value *T
+}
+
+// UnmarshalJSON is only called for fields present in the request
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
+
+// apply overwrites the stored value if the field was in the request
+func (o Optional[T]) apply(stored **T) {
+	if o.Set {
+		*stored = o.Value
+	}
+}
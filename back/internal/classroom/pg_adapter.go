@@ -4,49 +4,85 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/Abraxas-365/neurons/internal/database"
 	"github.com/Abraxas-365/toolkit/pkg/errors"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+const neuronTransactionColumns = "id, classroom_id, user_id, amount, transaction_type, category_id, reward_id, reverses_id, batch_id, group_id, recipient_id, created_by, note, created_at"
+
 type PostgresRepository struct {
-	db *sqlx.DB
+	db *database.DB
 }
 
 func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
-	return &PostgresRepository{db: db}
+	return &PostgresRepository{db: database.New(db)}
 }
 
 func (r *PostgresRepository) CreateClassroom(ctx context.Context, classroom *Classroom) (*ClassroomWithData, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO classrooms (name, teacher_id, available_neurons, created_at)
-		VALUES (:name, :teacher_id, :available_neurons, :created_at)
+		INSERT INTO classrooms (name, teacher_id, organization_id, created_at)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id
 	`
-	rows, err := r.db.NamedQueryContext(ctx, query, classroom)
+	err = tx.GetContext(ctx, &classroom.ID, query, classroom.Name, classroom.TeacherID, classroom.OrganizationID, classroom.CreatedAt)
 	if err != nil {
 		return nil, errors.ErrDatabase(fmt.Sprintf("failed to create classroom: %v", err))
 	}
-	defer rows.Close()
 
-	if rows.Next() {
-		err = rows.Scan(&classroom.ID)
+	if err := addClassroomOwner(ctx, tx, classroom.ID, classroom.TeacherID, classroom.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	// Open the classroom's mint and pool accounts
+	mint, err := ensureLedgerAccount(ctx, tx, classroom.ID, AccountTypeMint, nil)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := ensureLedgerAccount(ctx, tx, classroom.ID, AccountTypeClassroom, nil)
+	if err != nil {
+		return nil, err
+	}
+	if classroom.AvailableNeurons > 0 {
+		err = postLedgerEntry(ctx, tx, &LedgerEntry{
+			ClassroomID: classroom.ID,
+			Description: "opening balance",
+			CreatedAt:   classroom.CreatedAt,
+			Postings: []*LedgerPosting{
+				{AccountID: mint.ID, Amount: -classroom.AvailableNeurons},
+				{AccountID: pool.ID, Amount: classroom.AvailableNeurons},
+			},
+		})
 		if err != nil {
-			return nil, errors.ErrDatabase(fmt.Sprintf("failed to scan classroom ID: %v", err))
+			return nil, err
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+
 	return r.GetClassroom(ctx, classroom.ID)
 }
 
 func (r *PostgresRepository) GetClassroom(ctx context.Context, id int64) (*ClassroomWithData, error) {
 	query := `
-		SELECT c.id, c.name, c.teacher_id, c.available_neurons, c.created_at,
+		SELECT c.id, c.name, c.teacher_id, c.organization_id, COALESCE(pool.balance, 0) AS available_neurons, c.created_at, c.archived_at,
 			   u.id AS "teacher.id", u.name AS "teacher.name", u.email AS "teacher.email", 
 			   u.role AS "teacher.role", u.created_at AS "teacher.created_at"
 		FROM classrooms c
 		JOIN users u ON c.teacher_id = u.id
+		LEFT JOIN ledger_accounts pool ON pool.classroom_id = c.id AND pool.account_type = 'classroom'
 		WHERE c.id = $1
 	`
 	var classroomWithData ClassroomWithData
@@ -64,13 +100,32 @@ func (r *PostgresRepository) GetClassroom(ctx context.Context, id int64) (*Class
 	}
 	classroomWithData.Students = students
 
+	groups, err := r.ListGroups(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	classroomWithData.Groups = groups
+
 	return &classroomWithData, nil
 }
 
+func (r *PostgresRepository) GetClassroomTeacherID(ctx context.Context, classroomID int64) (int64, error) {
+	query := "SELECT teacher_id FROM classrooms WHERE id = $1"
+	var teacherID int64
+	err := r.db.GetContext(ctx, &teacherID, query, classroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.ErrNotFound("classroom not found")
+		}
+		return 0, errors.ErrDatabase(fmt.Sprintf("failed to get classroom teacher: %v", err))
+	}
+	return teacherID, nil
+}
+
 func (r *PostgresRepository) UpdateClassroom(ctx context.Context, classroom *Classroom) (*ClassroomWithData, error) {
 	query := `
 		UPDATE classrooms
-		SET name = :name, teacher_id = :teacher_id, available_neurons = :available_neurons
+		SET name = :name
 		WHERE id = :id
 	`
 	_, err := r.db.NamedExecContext(ctx, query, classroom)
@@ -81,27 +136,132 @@ func (r *PostgresRepository) UpdateClassroom(ctx context.Context, classroom *Cla
 	return r.GetClassroom(ctx, classroom.ID)
 }
 
-func (r *PostgresRepository) DeleteClassroom(ctx context.Context, id int64) error {
-	query := "DELETE FROM classrooms WHERE id = $1"
-	_, err := r.db.ExecContext(ctx, query, id)
+// ArchiveClassroom makes a classroom read-only and hides it from listings
+func (r *PostgresRepository) ArchiveClassroom(ctx context.Context, id int64, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE classrooms SET archived_at = $2 WHERE id = $1 AND archived_at IS NULL", id, at)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to delete classroom: %v", err))
+		return errors.ErrDatabase(fmt.Sprintf("failed to archive classroom: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return r.classroomStateConflict(ctx, id, "classroom is already archived")
 	}
 	return nil
 }
 
-func (r *PostgresRepository) ListClassrooms(ctx context.Context, limit, offset int) ([]*ClassroomWithData, error) {
+// RestoreClassroom brings an archived classroom back
+func (r *PostgresRepository) RestoreClassroom(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE classrooms SET archived_at = NULL WHERE id = $1 AND archived_at IS NOT NULL", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to restore classroom: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return r.classroomStateConflict(ctx, id, "classroom is not archived")
+	}
+	return nil
+}
+
+// classroomStateConflict explains why a classroom update matched no row
+func (r *PostgresRepository) classroomStateConflict(ctx context.Context, id int64, msg string) error {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM classrooms WHERE id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check classroom: %v", err))
+	}
+	if !exists {
+		return errors.ErrNotFound("classroom not found")
+	}
+	return errors.ErrConflict(msg)
+}
+
+// purgedTables lists every table holding classroom data, in an order that
+// deletes rows before the rows they reference
+var purgedTables = []struct{ table, condition string }{
+	{"badge_awards", "classroom_id = $1"},
+	{"badges", "classroom_id = $1"},
+	{"allowance_runs", "rule_id IN (SELECT id FROM allowance_rules WHERE classroom_id = $1)"},
+	{"allowance_rules", "classroom_id = $1"},
+	{"neuron_lots", "classroom_id = $1"},
+	{"classroom_neuron_policies", "classroom_id = $1"},
+	{"ledger_postings", "entry_id IN (SELECT id FROM ledger_entries WHERE classroom_id = $1)"},
+	{"ledger_entries", "classroom_id = $1"},
+	{"redemption_requests", "classroom_id = $1"},
+	{"gifts", "classroom_id = $1"},
+	{"neuron_transactions", "classroom_id = $1 AND reverses_id IS NOT NULL"},
+	{"neuron_transactions", "classroom_id = $1"},
+	{"neuron_transaction_batches", "classroom_id = $1"},
+	{"transaction_categories", "classroom_id = $1"},
+	{"rewards", "classroom_id = $1"},
+	{"ledger_accounts", "classroom_id = $1"},
+	{"student_group_members", "group_id IN (SELECT id FROM student_groups WHERE classroom_id = $1)"},
+	{"student_groups", "classroom_id = $1"},
+	{"users_classrooms", "classroom_id = $1"},
+	{"enrollment_requests", "classroom_id = $1"},
+	{"classroom_join_codes", "classroom_id = $1"},
+	{"classroom_gift_settings", "classroom_id = $1"},
+	{"classroom_staff", "classroom_id = $1"},
+	{"classrooms", "id = $1"},
+}
+
+// PurgeClassroom permanently deletes a classroom that was archived before the
+// cutoff, along with its whole history. The audit log is kept.
+func (r *PostgresRepository) PurgeClassroom(ctx context.Context, id int64, archivedBefore time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var archivedAt *time.Time
+	err = tx.GetContext(ctx, &archivedAt, "SELECT archived_at FROM classrooms WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("classroom not found")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock classroom: %v", err))
+	}
+	if archivedAt == nil {
+		return errors.ErrConflict("only archived classrooms can be purged")
+	}
+	if !archivedAt.Before(archivedBefore) {
+		return errors.ErrConflict("classroom is still within its retention period")
+	}
+
+	// The ledger is immutable except while a purge is running in this transaction
+	_, err = tx.ExecContext(ctx, "SET LOCAL neurons.purging = 'on'")
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to start purge: %v", err))
+	}
+	for _, t := range purgedTables {
+		_, err = tx.ExecContext(ctx, "DELETE FROM "+t.table+" WHERE "+t.condition, id)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to purge %s: %v", t.table, err))
+		}
+	}
+	_, err = tx.ExecContext(ctx, "SET LOCAL neurons.purging = 'off'")
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to finish purge: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListClassrooms(ctx context.Context, organizationID int64, archived bool, limit, offset int) ([]*ClassroomWithData, error) {
 	query := `
-		SELECT c.id, c.name, c.teacher_id, c.available_neurons, c.created_at,
+		SELECT c.id, c.name, c.teacher_id, c.organization_id, COALESCE(pool.balance, 0) AS available_neurons, c.created_at, c.archived_at,
 			   u.id AS "teacher.id", u.name AS "teacher.name", u.email AS "teacher.email", 
 			   u.role AS "teacher.role", u.created_at AS "teacher.created_at"
 		FROM classrooms c
 		JOIN users u ON c.teacher_id = u.id
+		LEFT JOIN ledger_accounts pool ON pool.classroom_id = c.id AND pool.account_type = 'classroom'
+		WHERE c.organization_id = $1 AND (c.archived_at IS NOT NULL) = $4
 		ORDER BY c.id
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 	var classroomsWithData []*ClassroomWithData
-	err := r.db.SelectContext(ctx, &classroomsWithData, query, limit, offset)
+	err := r.db.SelectContext(ctx, &classroomsWithData, query, organizationID, limit, offset, archived)
 	if err != nil {
 		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list classrooms: %v", err))
 	}
@@ -112,53 +272,137 @@ func (r *PostgresRepository) ListClassrooms(ctx context.Context, limit, offset i
 			return nil, err
 		}
 		classroomWithData.Students = students
+
+		groups, err := r.ListGroups(ctx, classroomWithData.ID)
+		if err != nil {
+			return nil, err
+		}
+		classroomWithData.Groups = groups
 	}
 
 	return classroomsWithData, nil
 }
 
 func (r *PostgresRepository) AddStudentToClassroom(ctx context.Context, classroomID, studentID int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO users_classrooms (user_id, classroom_id)
 		VALUES ($1, $2)
 	`
-	_, err := r.db.ExecContext(ctx, query, studentID, classroomID)
+	_, err = tx.ExecContext(ctx, query, studentID, classroomID)
 	if err != nil {
 		return errors.ErrDatabase(fmt.Sprintf("failed to add student to classroom: %v", err))
 	}
+
+	// Open the student's wallet, or reuse it if they were enrolled before
+	if _, err := ensureLedgerAccount(ctx, tx, classroomID, AccountTypeStudent, &studentID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
 	return nil
 }
 
 func (r *PostgresRepository) RemoveStudentFromClassroom(ctx context.Context, classroomID, studentID int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
 	query := `
 		DELETE FROM users_classrooms
 		WHERE user_id = $1 AND classroom_id = $2
 	`
-	_, err := r.db.ExecContext(ctx, query, studentID, classroomID)
+	_, err = tx.ExecContext(ctx, query, studentID, classroomID)
 	if err != nil {
 		return errors.ErrDatabase(fmt.Sprintf("failed to remove student from classroom: %v", err))
 	}
+
+	// The student leaves the classroom's groups too
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM student_group_members
+		WHERE user_id = $1 AND group_id IN (SELECT id FROM student_groups WHERE classroom_id = $2)
+	`, studentID, classroomID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to remove student from groups: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
 	return nil
 }
 
-func (r *PostgresRepository) UpdateAvailableNeurons(ctx context.Context, classroomID int64, neurons int) error {
-	query := `
-		UPDATE classrooms
-		SET available_neurons = $1
-		WHERE id = $2
-	`
-	_, err := r.db.ExecContext(ctx, query, neurons, classroomID)
+// UpdateAvailableNeurons sets the classroom pool to the given amount by minting
+// the difference into it, or returning the excess to the mint. The caller
+// accounts for the difference in the budget the pool is drawn from.
+func (r *PostgresRepository) UpdateAvailableNeurons(ctx context.Context, classroomID int64, neurons int) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to update available neurons: %v", err))
+		return 0, errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
 	}
-	return nil
+	defer tx.Rollback()
+
+	// Lock the pool so the difference is computed against its latest balance
+	var pool LedgerAccount
+	err = tx.GetContext(ctx, &pool, `
+		SELECT id, classroom_id, user_id, account_type, balance, created_at
+		FROM ledger_accounts
+		WHERE classroom_id = $1 AND account_type = 'classroom'
+		FOR UPDATE
+	`, classroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.ErrNotFound("classroom not found")
+		}
+		return 0, errors.ErrDatabase(fmt.Sprintf("failed to lock classroom account: %v", err))
+	}
+	mint, err := getClassroomAccount(ctx, tx, classroomID, AccountTypeMint)
+	if err != nil {
+		return 0, err
+	}
+
+	delta := neurons - pool.Balance
+	if delta == 0 {
+		return 0, nil
+	}
+	description := "allocate"
+	if delta < 0 {
+		description = "deallocate"
+	}
+	err = postLedgerEntry(ctx, tx, &LedgerEntry{
+		ClassroomID: classroomID,
+		Description: description,
+		CreatedAt:   time.Now(),
+		Postings: []*LedgerPosting{
+			{AccountID: mint.ID, Amount: -delta},
+			{AccountID: pool.ID, Amount: delta},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return delta, nil
 }
 
 func (r *PostgresRepository) GetClassroomStudents(ctx context.Context, classroomID int64) ([]*Student, error) {
 	query := `
-		SELECT u.id, u.name, u.email, u.role, u.created_at, uc.neurons
+		SELECT u.id, u.name, u.email, u.role, u.created_at, COALESCE(la.balance, 0) AS neurons
 		FROM users u
 		JOIN users_classrooms uc ON u.id = uc.user_id
+		LEFT JOIN ledger_accounts la ON la.classroom_id = uc.classroom_id AND la.user_id = uc.user_id AND la.account_type = 'student'
 		WHERE uc.classroom_id = $1 AND u.role = 'student'
 	`
 	var students []*Student
@@ -184,62 +428,96 @@ func (r *PostgresRepository) IsStudentInClassroom(ctx context.Context, classroom
 	return exists, nil
 }
 
-func (r *PostgresRepository) TransferNeurons(ctx context.Context, classroomID, studentID int64, amount int) error {
+func (r *PostgresRepository) TransferNeurons(ctx context.Context, transaction *NeuronTransaction) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
 	}
 	defer tx.Rollback()
 
-	// Decrease classroom neurons
-	_, err = tx.ExecContext(ctx, `
-		UPDATE classrooms
-		SET available_neurons = available_neurons - $1
-		WHERE id = $2
-	`, amount, classroomID)
+	pool, err := getClassroomAccount(ctx, tx, transaction.ClassroomID, AccountTypeClassroom)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to decrease classroom neurons: %v", err))
+		return err
 	}
-
-	// Increase student neurons
-	_, err = tx.ExecContext(ctx, `
-		UPDATE users_classrooms
-		SET neurons = neurons + $1
-		WHERE classroom_id = $2 AND user_id = $3
-	`, amount, classroomID, studentID)
+	student, err := getStudentAccount(ctx, tx, transaction.ClassroomID, transaction.UserID)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to increase student neurons: %v", err))
+		return err
 	}
 
-	return tx.Commit()
+	// Balances are checked while the account rows are locked by the postings
+	if err := transfer(ctx, tx, transaction, pool, student); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
 }
 
-func (r *PostgresRepository) RecordNeuronTransaction(ctx context.Context, transaction *NeuronTransaction) error {
-	query := `
-		INSERT INTO neuron_transactions (classroom_id, user_id, amount, transaction_type, created_at)
-		VALUES (:classroom_id, :user_id, :amount, :transaction_type, :created_at)
+// TransferNeuronsBulk applies every transaction of the batch from the classroom
+// pool, all or nothing
+func (r *PostgresRepository) TransferNeuronsBulk(ctx context.Context, batch *TransactionBatch) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// Lock the pool and check the whole batch against it before moving anything
+	var pool LedgerAccount
+	err = tx.GetContext(ctx, &pool, `
+		SELECT id, classroom_id, user_id, account_type, balance, created_at
+		FROM ledger_accounts
+		WHERE classroom_id = $1 AND account_type = 'classroom'
+		FOR UPDATE
+	`, batch.ClassroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("classroom not found")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock classroom account: %v", err))
+	}
+	total := 0
+	for _, transaction := range batch.Transactions {
+		total += transaction.Amount
+	}
+	if pool.Balance < total {
+		return ErrInsufficientClassroomNeurons
+	}
+
+	err = tx.GetContext(ctx, &batch.ID, `
+		INSERT INTO neuron_transaction_batches (classroom_id, created_by, created_at)
+		VALUES ($1, $2, $3)
 		RETURNING id
-	`
-	rows, err := r.db.NamedQueryContext(ctx, query, transaction)
+	`, batch.ClassroomID, batch.CreatedBy, batch.CreatedAt)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to record neuron transaction: %v", err))
+		return errors.ErrDatabase(fmt.Sprintf("failed to create transaction batch: %v", err))
 	}
-	defer rows.Close()
 
-	if rows.Next() {
-		err = rows.Scan(&transaction.ID)
+	for _, transaction := range batch.Transactions {
+		student, err := getStudentAccount(ctx, tx, batch.ClassroomID, transaction.UserID)
 		if err != nil {
-			return errors.ErrDatabase(fmt.Sprintf("failed to scan transaction ID: %v", err))
+			return err
+		}
+		transaction.BatchID = &batch.ID
+		if err := transfer(ctx, tx, transaction, &pool, student); err != nil {
+			return err
 		}
 	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
 	return nil
 }
 
 func (r *PostgresRepository) GetUserNeurons(ctx context.Context, userID, classroomID int64) (int, error) {
 	query := `
-		SELECT neurons
-		FROM users_classrooms
-		WHERE user_id = $1 AND classroom_id = $2
+		SELECT COALESCE(la.balance, 0)
+		FROM users_classrooms uc
+		LEFT JOIN ledger_accounts la ON la.classroom_id = uc.classroom_id AND la.user_id = uc.user_id AND la.account_type = 'student'
+		WHERE uc.user_id = $1 AND uc.classroom_id = $2
 	`
 	var neurons int
 	err := r.db.GetContext(ctx, &neurons, query, userID, classroomID)
@@ -252,35 +530,130 @@ func (r *PostgresRepository) GetUserNeurons(ctx context.Context, userID, classro
 	return neurons, nil
 }
 
-func (r *PostgresRepository) ListUserClassrooms(ctx context.Context, userID int64, role string, limit, offset int) ([]*ClassroomWithData, error) {
+// ListNeuronTransactions returns one page of transactions matching the filter,
+// using keyset pagination on (created_at, id)
+func (r *PostgresRepository) ListNeuronTransactions(ctx context.Context, filter TransactionFilter) (*TransactionPage, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, values ...interface{}) {
+		for _, value := range values {
+			args = append(args, value)
+			condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if filter.ClassroomID != nil {
+		addCondition("classroom_id = ?", *filter.ClassroomID)
+	}
+	if filter.UserID != nil {
+		addCondition("(user_id = ? OR recipient_id = ?)", *filter.UserID, *filter.UserID)
+	}
+	if filter.GroupID != nil {
+		addCondition("group_id = ?", *filter.GroupID)
+	}
+	if filter.CategoryID != nil {
+		addCondition("category_id = ?", *filter.CategoryID)
+	}
+	if filter.TransactionType != "" {
+		addCondition("transaction_type = ?", filter.TransactionType)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < ?", *filter.To)
+	}
+	if filter.MinAmount != nil {
+		addCondition("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("amount <= ?", *filter.MaxAmount)
+	}
+
+	order := "DESC"
+	comparison := "<"
+	if filter.Ascending {
+		order = "ASC"
+		comparison = ">"
+	}
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		addCondition("(created_at, id) "+comparison+" (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	query := `
+		SELECT ` + neuronTransactionColumns + `
+		FROM neuron_transactions
+	`
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Fetch one extra row to know whether there is a next page
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT $%d", order, order, len(args))
+
+	transactions := []*NeuronTransaction{}
+	err := r.db.SelectContext(ctx, &transactions, query, args...)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list neuron transactions: %v", err))
+	}
+
+	page := &TransactionPage{Transactions: transactions}
+	if len(transactions) > filter.Limit {
+		page.Transactions = transactions[:filter.Limit]
+		page.NextCursor = encodeCursor(page.Transactions[filter.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *PostgresRepository) ListUserClassrooms(ctx context.Context, userID int64, role string, archived bool, limit, offset int) ([]*ClassroomWithData, error) {
 	var query string
 	var args []interface{}
+	var enrollments map[int64]*EnrollmentRequest
 
 	if role == "teacher" {
 		query = `
-			SELECT c.id, c.name, c.teacher_id, c.available_neurons, c.created_at,
+			SELECT c.id, c.name, c.teacher_id, c.organization_id, COALESCE(pool.balance, 0) AS available_neurons, c.created_at, c.archived_at,
 				   u.id AS "teacher.id", u.name AS "teacher.name", u.email AS "teacher.email", 
 				   u.role AS "teacher.role", u.created_at AS "teacher.created_at"
 			FROM classrooms c
 			JOIN users u ON c.teacher_id = u.id
-			WHERE c.teacher_id = $1
+			LEFT JOIN ledger_accounts pool ON pool.classroom_id = c.id AND pool.account_type = 'classroom'
+			WHERE c.id IN (SELECT classroom_id FROM classroom_staff WHERE user_id = $1 AND status = 'active')
+			  AND (c.archived_at IS NOT NULL) = $4
 			ORDER BY c.created_at DESC
 			LIMIT $2 OFFSET $3
 		`
-		args = []interface{}{userID, limit, offset}
+		args = []interface{}{userID, limit, offset, archived}
 	} else if role == "student" {
 		query = `
-			SELECT c.id, c.name, c.teacher_id, c.available_neurons, c.created_at,
+			SELECT c.id, c.name, c.teacher_id, c.organization_id, COALESCE(pool.balance, 0) AS available_neurons, c.created_at, c.archived_at,
 				   u.id AS "teacher.id", u.name AS "teacher.name", u.email AS "teacher.email", 
 				   u.role AS "teacher.role", u.created_at AS "teacher.created_at"
 			FROM classrooms c
 			JOIN users u ON c.teacher_id = u.id
-			JOIN users_classrooms uc ON c.id = uc.classroom_id
-			WHERE uc.user_id = $1
+			LEFT JOIN ledger_accounts pool ON pool.classroom_id = c.id AND pool.account_type = 'classroom'
+			WHERE (c.id IN (SELECT classroom_id FROM users_classrooms WHERE user_id = $1)
+			   OR c.id = ANY($4))
+			  AND (c.archived_at IS NOT NULL) = $5
 			ORDER BY c.created_at DESC
 			LIMIT $2 OFFSET $3
 		`
-		args = []interface{}{userID, limit, offset}
+		// Students also see the classrooms they asked to join, with the request's status
+		requests, err := r.getOpenEnrollmentRequests(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = requests
+		requested := make([]int64, 0, len(requests))
+		for classroomID := range requests {
+			requested = append(requested, classroomID)
+		}
+		args = []interface{}{userID, limit, offset, pq.Array(requested), archived}
 	} else {
 		return nil, errors.ErrBadRequest("invalid role")
 	}
@@ -292,42 +665,341 @@ func (r *PostgresRepository) ListUserClassrooms(ctx context.Context, userID int6
 	}
 
 	for _, classroomWithData := range classroomsWithData {
+		// The roster is only visible once the student is enrolled
+		if enrollment, ok := enrollments[classroomWithData.ID]; ok {
+			classroomWithData.Enrollment = enrollment
+			classroomWithData.Students = []*Student{}
+			classroomWithData.Groups = []*StudentGroup{}
+			continue
+		}
+
 		students, err := r.GetClassroomStudents(ctx, classroomWithData.ID)
 		if err != nil {
 			return nil, err
 		}
 		classroomWithData.Students = students
+
+		groups, err := r.ListGroups(ctx, classroomWithData.ID)
+		if err != nil {
+			return nil, err
+		}
+		classroomWithData.Groups = groups
 	}
 
 	return classroomsWithData, nil
 }
 
-func (r *PostgresRepository) TransferNeuronsToClassroom(ctx context.Context, classroomID, studentID int64, amount int) error {
+func (r *PostgresRepository) TransferNeuronsToClassroom(ctx context.Context, transaction *NeuronTransaction) error {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
 	}
 	defer tx.Rollback()
 
-	// Increase classroom neurons
-	_, err = tx.ExecContext(ctx, `
-        UPDATE classrooms
-        SET available_neurons = available_neurons + $1
-        WHERE id = $2
-    `, amount, classroomID)
+	pool, err := getClassroomAccount(ctx, tx, transaction.ClassroomID, AccountTypeClassroom)
+	if err != nil {
+		return err
+	}
+	student, err := getStudentAccount(ctx, tx, transaction.ClassroomID, transaction.UserID)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to increase classroom neurons: %v", err))
+		return err
 	}
 
-	// Decrease student neurons
-	_, err = tx.ExecContext(ctx, `
-        UPDATE users_classrooms
-        SET neurons = neurons - $1
-        WHERE classroom_id = $2 AND user_id = $3
-    `, amount, classroomID, studentID)
+	if err := transfer(ctx, tx, transaction, student, pool); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// DeductNeurons takes neurons from a student back to the classroom pool. The
+// student's balance may only go below zero if the classroom's policy allows it.
+func (r *PostgresRepository) DeductNeurons(ctx context.Context, transaction *NeuronTransaction) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	policy, err := getNeuronPolicy(ctx, tx, transaction.ClassroomID)
+	if err != nil {
+		return err
+	}
+	pool, err := getClassroomAccount(ctx, tx, transaction.ClassroomID, AccountTypeClassroom)
+	if err != nil {
+		return err
+	}
+	student, err := getStudentAccount(ctx, tx, transaction.ClassroomID, transaction.UserID)
 	if err != nil {
-		return errors.ErrDatabase(fmt.Sprintf("failed to decrease student neurons: %v", err))
+		return err
 	}
 
-	return tx.Commit()
+	if err := recordNeuronTransaction(ctx, tx, transaction); err != nil {
+		return err
+	}
+	err = postLedgerEntry(ctx, tx, &LedgerEntry{
+		ClassroomID:   transaction.ClassroomID,
+		TransactionID: &transaction.ID,
+		Description:   transaction.TransactionType,
+		CreatedAt:     transaction.CreatedAt,
+		Postings: []*LedgerPosting{
+			{AccountID: student.ID, Amount: -transaction.Amount, AllowNegative: policy.AllowNegativeBalance},
+			{AccountID: pool.ID, Amount: transaction.Amount},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetNeuronTransaction(ctx context.Context, id int64) (*NeuronTransaction, error) {
+	query := "SELECT " + neuronTransactionColumns + " FROM neuron_transactions WHERE id = $1"
+	var transaction NeuronTransaction
+	err := r.db.GetContext(ctx, &transaction, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("transaction not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get neuron transaction: %v", err))
+	}
+	return &transaction, nil
+}
+
+// ReverseNeuronTransaction records the reversal and posts a journal entry that
+// undoes the original one, refusing to reverse a transaction twice
+func (r *PostgresRepository) ReverseNeuronTransaction(ctx context.Context, reversal *NeuronTransaction) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// Lock the original so concurrent reversals of it are serialized
+	var original struct {
+		ID       int64  `db:"id"`
+		RewardID *int64 `db:"reward_id"`
+	}
+	err = tx.GetContext(ctx, &original, `
+		SELECT id, reward_id FROM neuron_transactions
+		WHERE id = $1 AND classroom_id = $2
+		FOR UPDATE
+	`, *reversal.ReversesID, reversal.ClassroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("transaction not found")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock neuron transaction: %v", err))
+	}
+
+	var reversed bool
+	err = tx.GetContext(ctx, &reversed, "SELECT EXISTS(SELECT 1 FROM neuron_transactions WHERE reverses_id = $1)", original.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check transaction reversal: %v", err))
+	}
+	if reversed {
+		return errors.ErrConflict("transaction has already been reversed")
+	}
+
+	var postings []*LedgerPosting
+	err = tx.SelectContext(ctx, &postings, `
+		SELECT lp.id, lp.entry_id, lp.account_id, lp.amount
+		FROM ledger_postings lp
+		JOIN ledger_entries le ON le.id = lp.entry_id
+		WHERE le.transaction_id = $1
+	`, original.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to get transaction postings: %v", err))
+	}
+	if len(postings) == 0 {
+		return errors.ErrBadRequest("transaction predates the ledger and cannot be reversed")
+	}
+
+	if err := recordNeuronTransaction(ctx, tx, reversal); err != nil {
+		return err
+	}
+
+	entry := &LedgerEntry{
+		ClassroomID:   reversal.ClassroomID,
+		TransactionID: &reversal.ID,
+		Description:   reversal.TransactionType,
+		CreatedAt:     reversal.CreatedAt,
+	}
+	for _, posting := range postings {
+		entry.Postings = append(entry.Postings, &LedgerPosting{AccountID: posting.AccountID, Amount: -posting.Amount})
+	}
+	if err := postLedgerEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	// A refunded redemption puts the reward back in stock and closes the
+	// request it settled, so the queue doesn't show it as delivered
+	if original.RewardID != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE rewards SET stock = stock + 1 WHERE id = $1 AND stock IS NOT NULL", *original.RewardID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to restock reward: %v", err))
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE redemption_requests SET status = $1
+			WHERE redemption_transaction_id = $2
+		`, RedemptionStatusRefunded, original.ID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to refund redemption request: %v", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) CreateCategory(ctx context.Context, category *TransactionCategory) error {
+	query := `
+		INSERT INTO transaction_categories (classroom_id, name, default_amount, created_at)
+		VALUES (:classroom_id, :name, :default_amount, :created_at)
+		RETURNING id
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, category)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create category: %v", err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&category.ID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to scan category ID: %v", err))
+		}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetCategory(ctx context.Context, id int64) (*TransactionCategory, error) {
+	query := `
+		SELECT id, classroom_id, name, default_amount, created_at
+		FROM transaction_categories
+		WHERE id = $1
+	`
+	var category TransactionCategory
+	err := r.db.GetContext(ctx, &category, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("category not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get category: %v", err))
+	}
+	return &category, nil
+}
+
+func (r *PostgresRepository) ListCategories(ctx context.Context, classroomID int64) ([]*TransactionCategory, error) {
+	query := `
+		SELECT id, classroom_id, name, default_amount, created_at
+		FROM transaction_categories
+		WHERE classroom_id = $1
+		ORDER BY name
+	`
+	categories := []*TransactionCategory{}
+	err := r.db.SelectContext(ctx, &categories, query, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list categories: %v", err))
+	}
+	return categories, nil
+}
+
+func (r *PostgresRepository) UpdateCategory(ctx context.Context, category *TransactionCategory) error {
+	query := `
+		UPDATE transaction_categories
+		SET name = :name, default_amount = :default_amount
+		WHERE id = :id
+	`
+	_, err := r.db.NamedExecContext(ctx, query, category)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update category: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) DeleteCategory(ctx context.Context, id int64) error {
+	var used bool
+	err := r.db.GetContext(ctx, &used, "SELECT EXISTS(SELECT 1 FROM neuron_transactions WHERE category_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check category usage: %v", err))
+	}
+	if used {
+		return errors.ErrConflict("category is used by existing transactions")
+	}
+	err = r.db.GetContext(ctx, &used, "SELECT EXISTS(SELECT 1 FROM allowance_rules WHERE category_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check category usage: %v", err))
+	}
+	if used {
+		return errors.ErrConflict("category is used by an allowance")
+	}
+	err = r.db.GetContext(ctx, &used, "SELECT EXISTS(SELECT 1 FROM badges WHERE rule_category_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check category usage: %v", err))
+	}
+	if used {
+		return errors.ErrConflict("category is used by a badge")
+	}
+
+	_, err = r.db.ExecContext(ctx, "DELETE FROM transaction_categories WHERE id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to delete category: %v", err))
+	}
+	return nil
+}
+
+// GetStudentCategoryTotals sums the neurons a student was assigned per category,
+// leaving out assignments that were reversed
+func (r *PostgresRepository) GetStudentCategoryTotals(ctx context.Context, classroomID, studentID int64) ([]*CategoryTotal, error) {
+	query := `
+		SELECT nt.category_id, COALESCE(tc.name, '') AS name, COUNT(*) AS count, SUM(nt.amount) AS total
+		FROM neuron_transactions nt
+		LEFT JOIN transaction_categories tc ON tc.id = nt.category_id
+		WHERE nt.classroom_id = $1 AND nt.user_id = $2 AND nt.transaction_type = 'assignment'
+		  AND NOT EXISTS (SELECT 1 FROM neuron_transactions r WHERE r.reverses_id = nt.id)
+		GROUP BY nt.category_id, tc.name
+		ORDER BY total DESC
+	`
+	totals := []*CategoryTotal{}
+	err := r.db.SelectContext(ctx, &totals, query, classroomID, studentID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get student category totals: %v", err))
+	}
+	return totals, nil
+}
+
+// recordNeuronTransaction inserts the transaction and awards the badges it earns
+func recordNeuronTransaction(ctx context.Context, q sqlx.ExtContext, transaction *NeuronTransaction) error {
+	query := `
+		INSERT INTO neuron_transactions (classroom_id, user_id, amount, transaction_type, category_id, reward_id, reverses_id, batch_id, group_id, recipient_id, created_by, note, created_at)
+		VALUES (:classroom_id, :user_id, :amount, :transaction_type, :category_id, :reward_id, :reverses_id, :batch_id, :group_id, :recipient_id, :created_by, :note, :created_at)
+		RETURNING id
+	`
+	rows, err := sqlx.NamedQueryContext(ctx, q, query, transaction)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to record neuron transaction: %v", err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&transaction.ID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to scan transaction ID: %v", err))
+		}
+	}
+	// The connection must be free before awarding badges in the same transaction
+	if err := rows.Close(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to record neuron transaction: %v", err))
+	}
+	return awardBadges(ctx, q, transaction)
 }
@@ -0,0 +1,330 @@
+package classroom
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// openTestDB migrates a fresh schema in the database at TEST_DATABASE_URL and
+// drops it when the test ends. Tests that need Postgres are skipped without it.
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sqlx.Connect("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	defer admin.Close()
+
+	schema := fmt.Sprintf("neurons_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := sqlx.Connect("postgres", dbURL)
+		if err != nil {
+			t.Errorf("failed to connect to database: %v", err)
+			return
+		}
+		defer admin.Close()
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("failed to drop schema: %v", err)
+		}
+	})
+
+	separator := "?"
+	if strings.Contains(dbURL, "?") {
+		separator = "&"
+	}
+	db, err := sqlx.Connect("postgres", dbURL+separator+"search_path="+schema)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, migration := range migrations {
+		script, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", migration, err)
+		}
+		if _, err := db.Exec(string(script)); err != nil {
+			t.Fatalf("failed to apply %s: %v", migration, err)
+		}
+	}
+	return db
+}
+
+// createTestUser inserts a user of the oldest organization along with their auth user
+func createTestUser(t *testing.T, db *sqlx.DB, name, role string) int64 {
+	t.Helper()
+	authUserID := fmt.Sprintf("%s-%d", name, time.Now().UnixNano())
+	email := authUserID + "@example.com"
+	if _, err := db.Exec("INSERT INTO auth_user (id, email) VALUES ($1, $2)", authUserID, email); err != nil {
+		t.Fatalf("failed to create auth user: %v", err)
+	}
+	var id int64
+	err := db.Get(&id, `
+		INSERT INTO users (auth_user_id, name, email, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, authUserID, name, email, role)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return id
+}
+
+type transferFixture struct {
+	repo        *PostgresRepository
+	db          *sqlx.DB
+	classroomID int64
+	studentID   int64
+}
+
+// newTransferFixture creates a classroom with the given pool and one enrolled
+// student holding the given wallet balance
+func newTransferFixture(t *testing.T, pool, wallet int) *transferFixture {
+	t.Helper()
+	ctx := context.Background()
+	db := openTestDB(t)
+	repo := NewPostgresRepository(db)
+
+	teacherID := createTestUser(t, db, "teacher", "teacher")
+	studentID := createTestUser(t, db, "student", "student")
+	var organizationID int64
+	if err := db.Get(&organizationID, "SELECT organization_id FROM users WHERE id = $1", teacherID); err != nil {
+		t.Fatalf("failed to get organization: %v", err)
+	}
+
+	classroom, err := repo.CreateClassroom(ctx, &Classroom{
+		Name:             "Concurrency",
+		TeacherID:        teacherID,
+		OrganizationID:   organizationID,
+		AvailableNeurons: pool + wallet,
+		CreatedAt:        time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create classroom: %v", err)
+	}
+	if err := repo.AddStudentToClassroom(ctx, classroom.ID, studentID); err != nil {
+		t.Fatalf("failed to enroll student: %v", err)
+	}
+
+	f := &transferFixture{repo: repo, db: db, classroomID: classroom.ID, studentID: studentID}
+	if wallet > 0 {
+		if err := repo.TransferNeurons(ctx, f.transaction(TransactionTypeAssignment, wallet)); err != nil {
+			t.Fatalf("failed to fund wallet: %v", err)
+		}
+	}
+	return f
+}
+
+func (f *transferFixture) transaction(transactionType string, amount int) *NeuronTransaction {
+	return &NeuronTransaction{
+		ClassroomID:     f.classroomID,
+		UserID:          f.studentID,
+		Amount:          amount,
+		TransactionType: transactionType,
+		CreatedAt:       time.Now(),
+	}
+}
+
+func (f *transferFixture) balances(t *testing.T) (pool, wallet int) {
+	t.Helper()
+	ctx := context.Background()
+	classroom, err := f.repo.GetClassroom(ctx, f.classroomID)
+	if err != nil {
+		t.Fatalf("failed to get classroom: %v", err)
+	}
+	wallet, err = f.repo.GetUserNeurons(ctx, f.studentID, f.classroomID)
+	if err != nil {
+		t.Fatalf("failed to get wallet: %v", err)
+	}
+	return classroom.AvailableNeurons, wallet
+}
+
+// netSent sums what the recorded transactions moved from the pool to the student
+func (f *transferFixture) netSent(t *testing.T) int {
+	t.Helper()
+	var net int
+	err := f.db.Get(&net, `
+		SELECT COALESCE(SUM(CASE transaction_type WHEN 'assignment' THEN amount WHEN 'return' THEN -amount END), 0)
+		FROM neuron_transactions
+		WHERE classroom_id = $1 AND user_id = $2
+	`, f.classroomID, f.studentID)
+	if err != nil {
+		t.Fatalf("failed to sum transactions: %v", err)
+	}
+	return net
+}
+
+type transferResults struct {
+	mu        sync.Mutex
+	succeeded map[string]int
+	rejected  map[string]int
+	negative  bool
+}
+
+// hammer runs every transfer at once while watching the balances, and reports
+// how many transfers of each type went through or were turned down
+func (f *transferFixture) hammer(t *testing.T, transfers []*NeuronTransaction) *transferResults {
+	t.Helper()
+	ctx := context.Background()
+	results := &transferResults{succeeded: map[string]int{}, rejected: map[string]int{}}
+
+	expected := map[string]error{
+		TransactionTypeAssignment: ErrInsufficientClassroomNeurons,
+		TransactionTypeReturn:     ErrInsufficientStudentNeurons,
+	}
+
+	done := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			var negative bool
+			err := f.db.Get(&negative, `
+				SELECT EXISTS(SELECT 1 FROM ledger_accounts WHERE classroom_id = $1 AND account_type <> 'mint' AND balance < 0)
+			`, f.classroomID)
+			if err == nil && negative {
+				results.mu.Lock()
+				results.negative = true
+				results.mu.Unlock()
+			}
+		}
+	}()
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, transaction := range transfers {
+		wg.Add(1)
+		go func(transaction *NeuronTransaction) {
+			defer wg.Done()
+			<-start
+			var err error
+			if transaction.TransactionType == TransactionTypeReturn {
+				err = f.repo.TransferNeuronsToClassroom(ctx, transaction)
+			} else {
+				err = f.repo.TransferNeurons(ctx, transaction)
+			}
+
+			results.mu.Lock()
+			defer results.mu.Unlock()
+			switch err {
+			case nil:
+				results.succeeded[transaction.TransactionType]++
+			case expected[transaction.TransactionType]:
+				results.rejected[transaction.TransactionType]++
+			default:
+				t.Errorf("%s of %d: unexpected error %v", transaction.TransactionType, transaction.Amount, err)
+			}
+		}(transaction)
+	}
+	close(start)
+	wg.Wait()
+	close(done)
+	<-watched
+
+	if results.negative {
+		t.Error("a balance went below zero")
+	}
+	return results
+}
+
+func TestConcurrentSendsCannotOverdrawPool(t *testing.T) {
+	f := newTransferFixture(t, 100, 0)
+
+	var transfers []*NeuronTransaction
+	for i := 0; i < 40; i++ {
+		transfers = append(transfers, f.transaction(TransactionTypeAssignment, 5))
+	}
+	results := f.hammer(t, transfers)
+
+	if results.succeeded[TransactionTypeAssignment] != 20 {
+		t.Errorf("expected 20 sends to succeed, got %d", results.succeeded[TransactionTypeAssignment])
+	}
+	if results.rejected[TransactionTypeAssignment] != 20 {
+		t.Errorf("expected 20 sends to be rejected, got %d", results.rejected[TransactionTypeAssignment])
+	}
+	pool, wallet := f.balances(t)
+	if pool != 0 || wallet != 100 {
+		t.Errorf("expected pool 0 and wallet 100, got pool %d and wallet %d", pool, wallet)
+	}
+	if net := f.netSent(t); net != wallet {
+		t.Errorf("transactions sum to %d but the wallet holds %d", net, wallet)
+	}
+}
+
+func TestConcurrentReturnsCannotOverdrawWallet(t *testing.T) {
+	f := newTransferFixture(t, 0, 100)
+
+	var transfers []*NeuronTransaction
+	for i := 0; i < 40; i++ {
+		transfers = append(transfers, f.transaction(TransactionTypeReturn, 5))
+	}
+	results := f.hammer(t, transfers)
+
+	if results.succeeded[TransactionTypeReturn] != 20 {
+		t.Errorf("expected 20 returns to succeed, got %d", results.succeeded[TransactionTypeReturn])
+	}
+	if results.rejected[TransactionTypeReturn] != 20 {
+		t.Errorf("expected 20 returns to be rejected, got %d", results.rejected[TransactionTypeReturn])
+	}
+	pool, wallet := f.balances(t)
+	if pool != 100 || wallet != 0 {
+		t.Errorf("expected pool 100 and wallet 0, got pool %d and wallet %d", pool, wallet)
+	}
+	if net := f.netSent(t); net != wallet {
+		t.Errorf("transactions sum to %d but the wallet holds %d", net, wallet)
+	}
+}
+
+func TestConcurrentSendsAndReturns(t *testing.T) {
+	const pool, wallet = 30, 30
+	f := newTransferFixture(t, pool, wallet)
+
+	var transfers []*NeuronTransaction
+	for i := 0; i < 30; i++ {
+		transfers = append(transfers,
+			f.transaction(TransactionTypeAssignment, 7),
+			f.transaction(TransactionTypeReturn, 7),
+		)
+	}
+	results := f.hammer(t, transfers)
+
+	sent := results.succeeded[TransactionTypeAssignment] - results.succeeded[TransactionTypeReturn]
+	gotPool, gotWallet := f.balances(t)
+	if gotPool != pool-sent*7 || gotWallet != wallet+sent*7 {
+		t.Errorf("expected pool %d and wallet %d, got pool %d and wallet %d", pool-sent*7, wallet+sent*7, gotPool, gotWallet)
+	}
+	if gotPool < 0 || gotWallet < 0 {
+		t.Errorf("balances went below zero: pool %d, wallet %d", gotPool, gotWallet)
+	}
+	if net := f.netSent(t); net != gotWallet {
+		t.Errorf("transactions sum to %d but the wallet holds %d", net, gotWallet)
+	}
+}
@@ -2,22 +2,111 @@ package classroom
 
 import (
 	"context"
+	"time"
 )
 
 type DBRepository interface {
 	CreateClassroom(ctx context.Context, classroom *Classroom) (*ClassroomWithData, error)
 	GetClassroom(ctx context.Context, id int64) (*ClassroomWithData, error)
+	GetClassroomTeacherID(ctx context.Context, classroomID int64) (int64, error)
 	UpdateClassroom(ctx context.Context, classroom *Classroom) (*ClassroomWithData, error)
-	DeleteClassroom(ctx context.Context, id int64) error
-	ListClassrooms(ctx context.Context, limit, offset int) ([]*ClassroomWithData, error)
+	ArchiveClassroom(ctx context.Context, id int64, at time.Time) error
+	RestoreClassroom(ctx context.Context, id int64) error
+	PurgeClassroom(ctx context.Context, id int64, archivedBefore time.Time) error
+	ListClassrooms(ctx context.Context, organizationID int64, archived bool, limit, offset int) ([]*ClassroomWithData, error)
 	AddStudentToClassroom(ctx context.Context, classroomID, studentID int64) error
 	RemoveStudentFromClassroom(ctx context.Context, classroomID, studentID int64) error
-	UpdateAvailableNeurons(ctx context.Context, classroomID int64, neurons int) error
+	// UpdateAvailableNeurons sets the classroom pool and returns how much it grew
+	UpdateAvailableNeurons(ctx context.Context, classroomID int64, neurons int) (int, error)
 	GetClassroomStudents(ctx context.Context, classroomID int64) ([]*Student, error)
 	IsStudentInClassroom(ctx context.Context, classroomID, studentID int64) (bool, error)
-	TransferNeurons(ctx context.Context, classroomID, studentID int64, amount int) error
-	RecordNeuronTransaction(ctx context.Context, transaction *NeuronTransaction) error
+	TransferNeurons(ctx context.Context, transaction *NeuronTransaction) error
+	TransferNeuronsBulk(ctx context.Context, batch *TransactionBatch) error
 	GetUserNeurons(ctx context.Context, userID, classroomID int64) (int, error)
-	ListUserClassrooms(ctx context.Context, userID int64, role string, limit, offset int) ([]*ClassroomWithData, error)
-	TransferNeuronsToClassroom(ctx context.Context, classroomID, studentID int64, amount int) error
+	GetNeuronTransaction(ctx context.Context, id int64) (*NeuronTransaction, error)
+	ReverseNeuronTransaction(ctx context.Context, reversal *NeuronTransaction) error
+	ListNeuronTransactions(ctx context.Context, filter TransactionFilter) (*TransactionPage, error)
+	CreateCategory(ctx context.Context, category *TransactionCategory) error
+	GetCategory(ctx context.Context, id int64) (*TransactionCategory, error)
+	ListCategories(ctx context.Context, classroomID int64) ([]*TransactionCategory, error)
+	UpdateCategory(ctx context.Context, category *TransactionCategory) error
+	DeleteCategory(ctx context.Context, id int64) error
+	GetStudentCategoryTotals(ctx context.Context, classroomID, studentID int64) ([]*CategoryTotal, error)
+	CreateReward(ctx context.Context, reward *Reward) error
+	GetReward(ctx context.Context, id int64) (*Reward, error)
+	ListRewards(ctx context.Context, classroomID int64) ([]*Reward, error)
+	UpdateReward(ctx context.Context, reward *Reward) error
+	DeleteReward(ctx context.Context, id int64) error
+	RedeemReward(ctx context.Context, redemption *NeuronTransaction) error
+	CreateRedemptionRequest(ctx context.Context, request *RedemptionRequest) error
+	GetRedemptionRequest(ctx context.Context, id int64) (*RedemptionRequest, error)
+	ListRedemptionRequests(ctx context.Context, classroomID int64, status string, userID *int64) ([]*RedemptionRequest, error)
+	ApproveRedemptionRequest(ctx context.Context, request *RedemptionRequest) error
+	CloseRedemptionRequest(ctx context.Context, request *RedemptionRequest) error
+	FulfillRedemptionRequest(ctx context.Context, id int64, fulfilledAt time.Time) error
+	ListExpiredRedemptionRequests(ctx context.Context, now time.Time) ([]int64, error)
+	SaveJoinCode(ctx context.Context, joinCode *JoinCode) error
+	GetJoinCode(ctx context.Context, classroomID int64) (*JoinCode, error)
+	UpdateJoinCode(ctx context.Context, joinCode *JoinCode) error
+	JoinClassroom(ctx context.Context, code string, studentID, organizationID int64, now time.Time) (*JoinResult, error)
+	GetEnrollmentRequest(ctx context.Context, id int64) (*EnrollmentRequest, error)
+	ListEnrollmentRequests(ctx context.Context, classroomID int64, status string) ([]*EnrollmentRequest, error)
+	ApproveEnrollmentRequests(ctx context.Context, classroomID int64, ids []int64, decidedBy int64, decidedAt time.Time) error
+	RejectEnrollmentRequest(ctx context.Context, request *EnrollmentRequest) error
+	GetStaffMember(ctx context.Context, classroomID, userID int64) (*StaffMember, error)
+	ListStaff(ctx context.Context, classroomID int64) ([]*StaffMember, error)
+	HasClassroomPermission(ctx context.Context, classroomID, userID int64, permission string) (bool, error)
+	InviteStaffMember(ctx context.Context, member *StaffMember) error
+	AcceptStaffInvitation(ctx context.Context, classroomID, userID int64, at time.Time) error
+	UpdateStaffMember(ctx context.Context, member *StaffMember) error
+	RemoveStaffMember(ctx context.Context, classroomID, userID int64) error
+	TransferOwnership(ctx context.Context, classroomID, fromID, toID int64) error
+	// CheckDailySendLimit must run in the transaction that records the send
+	CheckDailySendLimit(ctx context.Context, classroomID, userID int64, amount int, since time.Time) error
+	ListUserClassrooms(ctx context.Context, userID int64, role string, archived bool, limit, offset int) ([]*ClassroomWithData, error)
+	TransferNeuronsToClassroom(ctx context.Context, transaction *NeuronTransaction) error
+	// DeductNeurons takes neurons from a student back to the classroom pool
+	DeductNeurons(ctx context.Context, transaction *NeuronTransaction) error
+	ReconcileLedger(ctx context.Context, classroomID int64) (*LedgerReconciliation, error)
+	GetNeuronStats(ctx context.Context) (*NeuronStats, error)
+	ReassignClassroom(ctx context.Context, classroomID, teacherID int64, at time.Time) error
+	CreateGroups(ctx context.Context, groups []*StudentGroup) error
+	GetGroup(ctx context.Context, id int64) (*StudentGroup, error)
+	ListGroups(ctx context.Context, classroomID int64) ([]*StudentGroup, error)
+	RenameGroup(ctx context.Context, id int64, name string) error
+	SetGroupMembers(ctx context.Context, id int64, memberIDs []int64) error
+	DeleteGroup(ctx context.Context, id int64) error
+	FundGroup(ctx context.Context, transaction *NeuronTransaction) error
+	ContributeToGroup(ctx context.Context, transaction *NeuronTransaction) error
+	GetGiftSettings(ctx context.Context, classroomID int64) (*GiftSettings, error)
+	UpdateGiftSettings(ctx context.Context, settings *GiftSettings) error
+	GiveGift(ctx context.Context, gift *Gift) error
+	GetGift(ctx context.Context, id int64) (*Gift, error)
+	ListGifts(ctx context.Context, classroomID int64, status string, userID *int64) ([]*Gift, error)
+	ApproveGift(ctx context.Context, gift *Gift) error
+	RejectGift(ctx context.Context, gift *Gift) error
+	CreateAllowanceRule(ctx context.Context, rule *AllowanceRule) error
+	GetAllowanceRule(ctx context.Context, id int64) (*AllowanceRule, error)
+	ListAllowanceRules(ctx context.Context, classroomID int64) ([]*AllowanceRule, error)
+	UpdateAllowanceRule(ctx context.Context, rule *AllowanceRule) error
+	DeleteAllowanceRule(ctx context.Context, id int64) error
+	ListAllowanceRuns(ctx context.Context, ruleID int64, limit, offset int) ([]*AllowanceRun, error)
+	ListDueAllowanceRules(ctx context.Context, now time.Time) ([]int64, error)
+	ClaimAllowanceRun(ctx context.Context, ruleID int64, scheduledFor time.Time, next *time.Time) (bool, error)
+	RecordAllowanceRun(ctx context.Context, run *AllowanceRun) error
+	GetNeuronPolicy(ctx context.Context, classroomID int64) (*NeuronPolicy, error)
+	UpdateNeuronPolicy(ctx context.Context, policy *NeuronPolicy) error
+	GetStudentLots(ctx context.Context, classroomID, studentID int64) ([]*NeuronLot, error)
+	ListExpiredLots(ctx context.Context, now time.Time) ([]int64, error)
+	ExpireLot(ctx context.Context, id int64, now time.Time) (*NeuronTransaction, error)
+	EndTerm(ctx context.Context, batch *TransactionBatch, policy *NeuronPolicy) error
+	CreateBadge(ctx context.Context, badge *Badge) error
+	GetBadge(ctx context.Context, classroomID, id int64) (*Badge, error)
+	ListBadges(ctx context.Context, classroomID int64) ([]*Badge, error)
+	UpdateBadge(ctx context.Context, badge *Badge) error
+	DeleteBadge(ctx context.Context, id int64) error
+	AwardBadge(ctx context.Context, award *BadgeAward) error
+	GetBadgeAward(ctx context.Context, id int64) (*BadgeAward, error)
+	ListBadgeAwards(ctx context.Context, classroomID int64, userID *int64) ([]*BadgeAward, error)
+	ListUserBadgeAwards(ctx context.Context, userID int64) ([]*BadgeAward, error)
 }
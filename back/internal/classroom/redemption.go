@@ -0,0 +1,35 @@
+package classroom
+
+import "time"
+
+// Redemption request statuses
+const (
+	RedemptionStatusPending   = "pending"
+	RedemptionStatusApproved  = "approved"
+	RedemptionStatusRejected  = "rejected"
+	RedemptionStatusFulfilled = "fulfilled"
+	RedemptionStatusExpired   = "expired"
+	// RedemptionStatusRefunded is set when the redemption transaction is reversed
+	RedemptionStatusRefunded = "refunded"
+)
+
+// RedemptionRequest is a student's request to redeem a reward that needs the
+// teacher's approval. The cost stays on hold until the request is decided.
+type RedemptionRequest struct {
+	ID          int64  `json:"id" db:"id"`
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	RewardID    int64  `json:"reward_id" db:"reward_id"`
+	UserID      int64  `json:"user_id" db:"user_id"`
+	Amount      int    `json:"amount" db:"amount"`
+	Status      string `json:"status" db:"status"`
+	// HoldTransactionID moved the neurons on hold
+	HoldTransactionID int64 `json:"hold_transaction_id" db:"hold_transaction_id"`
+	// RedemptionTransactionID spent the neurons once approved
+	RedemptionTransactionID *int64     `json:"redemption_transaction_id,omitempty" db:"redemption_transaction_id"`
+	DecidedBy               *int64     `json:"decided_by,omitempty" db:"decided_by"`
+	Reason                  string     `json:"reason" db:"reason"`
+	ExpiresAt               time.Time  `json:"expires_at" db:"expires_at"`
+	DecidedAt               *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	FulfilledAt             *time.Time `json:"fulfilled_at,omitempty" db:"fulfilled_at"`
+	CreatedAt               time.Time  `json:"created_at" db:"created_at"`
+}
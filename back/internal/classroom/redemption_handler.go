@@ -0,0 +1,98 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) ListRedemptionRequests(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	// Students only see their own requests
+	var userID *int64
+	if u.Role == "student" {
+		userID = &u.ID
+	}
+
+	requests, err := h.service.ListRedemptionRequests(c.Context(), classroomID, c.Query("status"), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(requests)
+}
+
+func (h *Handler) ApproveRedemptionRequest(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, requestID, err := redemptionRequestParams(c)
+	if err != nil {
+		return err
+	}
+
+	request, err := h.service.ApproveRedemptionRequest(c.Context(), u.ID, classroomID, requestID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(request)
+}
+
+func (h *Handler) RejectRedemptionRequest(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, requestID, err := redemptionRequestParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	request, err := h.service.RejectRedemptionRequest(c.Context(), u.ID, classroomID, requestID, input.Reason)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(request)
+}
+
+func (h *Handler) FulfillRedemptionRequest(c *fiber.Ctx) error {
+	classroomID, requestID, err := redemptionRequestParams(c)
+	if err != nil {
+		return err
+	}
+
+	request, err := h.service.FulfillRedemptionRequest(c.Context(), classroomID, requestID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(request)
+}
+
+func redemptionRequestParams(c *fiber.Ctx) (int64, int64, error) {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	requestID, err := strconv.ParseInt(c.Params("requestId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid redemption request id")
+	}
+
+	return classroomID, requestID, nil
+}
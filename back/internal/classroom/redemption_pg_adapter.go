@@ -0,0 +1,260 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+const redemptionRequestColumns = `id, classroom_id, reward_id, user_id, amount, status, hold_transaction_id,
+	redemption_transaction_id, decided_by, reason, expires_at, decided_at, fulfilled_at, created_at`
+
+// CreateRedemptionRequest reserves a unit of the reward and puts its cost on
+// hold from the student's wallet
+func (r *PostgresRepository) CreateRedemptionRequest(ctx context.Context, request *RedemptionRequest) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	reward, err := takeRewardUnit(ctx, tx, request.RewardID, request.ClassroomID, request.UserID)
+	if err != nil {
+		return err
+	}
+	request.Amount = reward.Cost
+
+	student, err := getStudentAccount(ctx, tx, request.ClassroomID, request.UserID)
+	if err != nil {
+		return err
+	}
+	hold, err := ensureLedgerAccount(ctx, tx, request.ClassroomID, AccountTypeHold, &request.UserID)
+	if err != nil {
+		return err
+	}
+	holdTransaction := &NeuronTransaction{
+		ClassroomID:     request.ClassroomID,
+		UserID:          request.UserID,
+		Amount:          request.Amount,
+		TransactionType: TransactionTypeHold,
+		CreatedBy:       &request.UserID,
+		Note:            reward.Name,
+		CreatedAt:       request.CreatedAt,
+	}
+	if err := transfer(ctx, tx, holdTransaction, student, hold); err != nil {
+		return err
+	}
+	request.HoldTransactionID = holdTransaction.ID
+
+	err = tx.GetContext(ctx, &request.ID, `
+		INSERT INTO redemption_requests (classroom_id, reward_id, user_id, amount, status, hold_transaction_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, request.ClassroomID, request.RewardID, request.UserID, request.Amount, request.Status,
+		request.HoldTransactionID, request.ExpiresAt, request.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create redemption request: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetRedemptionRequest(ctx context.Context, id int64) (*RedemptionRequest, error) {
+	query := "SELECT " + redemptionRequestColumns + " FROM redemption_requests WHERE id = $1"
+	var request RedemptionRequest
+	err := r.db.GetContext(ctx, &request, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("redemption request not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get redemption request: %v", err))
+	}
+	return &request, nil
+}
+
+func (r *PostgresRepository) ListRedemptionRequests(ctx context.Context, classroomID int64, status string, userID *int64) ([]*RedemptionRequest, error) {
+	query := `
+		SELECT ` + redemptionRequestColumns + `
+		FROM redemption_requests
+		WHERE classroom_id = $1
+		  AND ($2 = '' OR status = $2)
+		  AND ($3::INTEGER IS NULL OR user_id = $3)
+		ORDER BY created_at DESC, id DESC
+	`
+	requests := []*RedemptionRequest{}
+	err := r.db.SelectContext(ctx, &requests, query, classroomID, status, userID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list redemption requests: %v", err))
+	}
+	return requests, nil
+}
+
+// ApproveRedemptionRequest releases the hold and spends it on the reward
+func (r *PostgresRepository) ApproveRedemptionRequest(ctx context.Context, request *RedemptionRequest) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	current, err := lockPendingRedemptionRequest(ctx, tx, request.ID, *request.DecidedAt)
+	if err != nil {
+		return err
+	}
+	if err := releaseHold(ctx, tx, current.ClassroomID, current.UserID, current.Amount, request.DecidedBy, *request.DecidedAt); err != nil {
+		return err
+	}
+
+	student, err := getStudentAccount(ctx, tx, current.ClassroomID, current.UserID)
+	if err != nil {
+		return err
+	}
+	pool, err := getClassroomAccount(ctx, tx, current.ClassroomID, AccountTypeClassroom)
+	if err != nil {
+		return err
+	}
+	redemption := &NeuronTransaction{
+		ClassroomID:     current.ClassroomID,
+		UserID:          current.UserID,
+		Amount:          current.Amount,
+		TransactionType: TransactionTypeRedemption,
+		RewardID:        &current.RewardID,
+		CreatedBy:       request.DecidedBy,
+		CreatedAt:       *request.DecidedAt,
+	}
+	if err := transfer(ctx, tx, redemption, student, pool); err != nil {
+		return err
+	}
+	request.RedemptionTransactionID = &redemption.ID
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE redemption_requests
+		SET status = $1, decided_by = $2, decided_at = $3, redemption_transaction_id = $4
+		WHERE id = $5
+	`, RedemptionStatusApproved, request.DecidedBy, request.DecidedAt, redemption.ID, request.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to approve redemption request: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// CloseRedemptionRequest rejects or expires a pending request, giving the held
+// neurons back to the student and the reward unit back to the stock
+func (r *PostgresRepository) CloseRedemptionRequest(ctx context.Context, request *RedemptionRequest) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// A pending request can be closed even after its deadline
+	current, err := lockPendingRedemptionRequest(ctx, tx, request.ID, time.Time{})
+	if err != nil {
+		return err
+	}
+	if err := releaseHold(ctx, tx, current.ClassroomID, current.UserID, current.Amount, request.DecidedBy, *request.DecidedAt); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE rewards SET stock = stock + 1 WHERE id = $1 AND stock IS NOT NULL", current.RewardID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to restock reward: %v", err))
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE redemption_requests
+		SET status = $1, decided_by = $2, decided_at = $3, reason = $4
+		WHERE id = $5
+	`, request.Status, request.DecidedBy, request.DecidedAt, request.Reason, request.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to close redemption request: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) FulfillRedemptionRequest(ctx context.Context, id int64, fulfilledAt time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE redemption_requests
+		SET status = $1, fulfilled_at = $2
+		WHERE id = $3 AND status = $4
+	`, RedemptionStatusFulfilled, fulfilledAt, id, RedemptionStatusApproved)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to fulfill redemption request: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrConflict("only approved redemption requests can be fulfilled")
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListExpiredRedemptionRequests(ctx context.Context, now time.Time) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM redemption_requests
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY id
+	`
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, query, RedemptionStatusPending, now)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list expired redemption requests: %v", err))
+	}
+	return ids, nil
+}
+
+// lockPendingRedemptionRequest locks the request and makes sure it can still be
+// decided; a zero deadline skips the expiry check
+func lockPendingRedemptionRequest(ctx context.Context, tx *database.Tx, id int64, deadline time.Time) (*RedemptionRequest, error) {
+	var request RedemptionRequest
+	err := tx.GetContext(ctx, &request, "SELECT "+redemptionRequestColumns+" FROM redemption_requests WHERE id = $1 FOR UPDATE", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("redemption request not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to lock redemption request: %v", err))
+	}
+	if request.Status != RedemptionStatusPending {
+		return nil, errors.ErrConflict(fmt.Sprintf("redemption request is already %s", request.Status))
+	}
+	if !deadline.IsZero() && !deadline.Before(request.ExpiresAt) {
+		return nil, errors.ErrConflict("redemption request has expired")
+	}
+	return &request, nil
+}
+
+// releaseHold moves held neurons back to the student's wallet; releasedBy is
+// nil when the hold expired on its own
+func releaseHold(ctx context.Context, tx *database.Tx, classroomID, studentID int64, amount int, releasedBy *int64, at time.Time) error {
+	hold, err := ensureLedgerAccount(ctx, tx, classroomID, AccountTypeHold, &studentID)
+	if err != nil {
+		return err
+	}
+	student, err := ensureLedgerAccount(ctx, tx, classroomID, AccountTypeStudent, &studentID)
+	if err != nil {
+		return err
+	}
+	release := &NeuronTransaction{
+		ClassroomID:     classroomID,
+		UserID:          studentID,
+		Amount:          amount,
+		TransactionType: TransactionTypeRelease,
+		CreatedBy:       releasedBy,
+		CreatedAt:       at,
+	}
+	return transfer(ctx, tx, release, hold, student)
+}
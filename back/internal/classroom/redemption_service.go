@@ -0,0 +1,196 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// RequestRedemption asks the teacher to approve a reward, putting its cost on hold
+func (s *Service) RequestRedemption(ctx context.Context, studentID, classroomID, rewardID int64) (*RedemptionRequest, error) {
+	// Verify that the user exists and is a student
+	student, err := s.userService.GetUser(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Role != "student" {
+		return nil, errors.ErrForbidden("only students can redeem rewards")
+	}
+
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	reward, err := s.GetReward(ctx, classroomID, rewardID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !reward.IsAvailable(now) {
+		return nil, ErrRewardNotAvailable
+	}
+	if !reward.RequiresApproval {
+		return nil, errors.ErrBadRequest("reward does not require approval; redeem it directly")
+	}
+
+	request := &RedemptionRequest{
+		ClassroomID: classroomID,
+		RewardID:    rewardID,
+		UserID:      studentID,
+		Status:      RedemptionStatusPending,
+		ExpiresAt:   now.Add(time.Duration(reward.ApprovalTTLHours) * time.Hour),
+		CreatedAt:   now,
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateRedemptionRequest(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionRedemptionRequested, "redemption_request", request.ID, nil, request), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ListRedemptionRequests retrieves a classroom's redemption requests, optionally
+// only those with the given status or from one student
+func (s *Service) ListRedemptionRequests(ctx context.Context, classroomID int64, status string, userID *int64) ([]*RedemptionRequest, error) {
+	switch status {
+	case "", RedemptionStatusPending, RedemptionStatusApproved, RedemptionStatusRejected,
+		RedemptionStatusFulfilled, RedemptionStatusExpired, RedemptionStatusRefunded:
+	default:
+		return nil, errors.ErrBadRequest("invalid status")
+	}
+	return s.repo.ListRedemptionRequests(ctx, classroomID, status, userID)
+}
+
+// ApproveRedemptionRequest spends the held neurons on the reward
+func (s *Service) ApproveRedemptionRequest(ctx context.Context, teacherID, classroomID, requestID int64) (*RedemptionRequest, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	request, err := s.getRedemptionRequest(ctx, classroomID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *request
+	now := time.Now()
+	request.DecidedBy = &teacherID
+	request.DecidedAt = &now
+	return s.decideRedemptionRequest(ctx, ActionRedemptionApproved, &before, func(ctx context.Context) error {
+		return s.repo.ApproveRedemptionRequest(ctx, request)
+	})
+}
+
+// RejectRedemptionRequest gives the held neurons back to the student
+func (s *Service) RejectRedemptionRequest(ctx context.Context, teacherID, classroomID, requestID int64, reason string) (*RedemptionRequest, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	request, err := s.getRedemptionRequest(ctx, classroomID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *request
+	now := time.Now()
+	request.Status = RedemptionStatusRejected
+	request.DecidedBy = &teacherID
+	request.DecidedAt = &now
+	request.Reason = reason
+	return s.decideRedemptionRequest(ctx, ActionRedemptionRejected, &before, func(ctx context.Context) error {
+		return s.repo.CloseRedemptionRequest(ctx, request)
+	})
+}
+
+// FulfillRedemptionRequest marks an approved reward as handed over to the student
+func (s *Service) FulfillRedemptionRequest(ctx context.Context, classroomID, requestID int64) (*RedemptionRequest, error) {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	request, err := s.getRedemptionRequest(ctx, classroomID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decideRedemptionRequest(ctx, ActionRedemptionFulfilled, request, func(ctx context.Context) error {
+		return s.repo.FulfillRedemptionRequest(ctx, requestID, time.Now())
+	})
+}
+
+// ExpireRedemptionRequests releases the holds of pending requests past their
+// deadline and returns how many were expired
+func (s *Service) ExpireRedemptionRequests(ctx context.Context) (int, error) {
+	now := time.Now()
+	ids, err := s.repo.ListExpiredRedemptionRequests(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		request, err := s.repo.GetRedemptionRequest(ctx, id)
+		if err != nil {
+			return expired, err
+		}
+		_, err = s.decideRedemptionRequest(ctx, ActionRedemptionExpired, request, func(ctx context.Context) error {
+			return s.repo.CloseRedemptionRequest(ctx, &RedemptionRequest{
+				ID:        id,
+				Status:    RedemptionStatusExpired,
+				DecidedAt: &now,
+				Reason:    "request expired",
+			})
+		})
+		if err != nil {
+			// The teacher may have decided it in the meantime
+			if apiErr, ok := err.(errors.ApiError); ok && apiErr.Type == "Conflict" {
+				continue
+			}
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// decideRedemptionRequest moves a request along and records its new state in
+// the audit log
+func (s *Service) decideRedemptionRequest(ctx context.Context, action string, before *RedemptionRequest, decide func(ctx context.Context) error) (*RedemptionRequest, error) {
+	var after *RedemptionRequest
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := decide(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.repo.GetRedemptionRequest(ctx, before.ID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(before.ClassroomID, action, "redemption_request", before.ID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (s *Service) getRedemptionRequest(ctx context.Context, classroomID, requestID int64) (*RedemptionRequest, error) {
+	request, err := s.repo.GetRedemptionRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("redemption request not found")
+	}
+	return request, nil
+}
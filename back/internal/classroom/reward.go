@@ -0,0 +1,71 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Errors returned when a reward cannot be redeemed
+var (
+	ErrRewardOutOfStock   = errors.ErrConflict("reward is out of stock")
+	ErrRewardLimitReached = errors.ErrConflict("student has reached the redemption limit for this reward")
+	ErrRewardNotAvailable = errors.ErrBadRequest("reward is not available right now")
+)
+
+// defaultApprovalTTLHours is how long neurons stay on hold when a reward does not set it
+const defaultApprovalTTLHours = 72
+
+// Reward is an item of a classroom's store that students buy with neurons
+type Reward struct {
+	ID          int64  `json:"id" db:"id"`
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+	Cost        int    `json:"cost" db:"cost"`
+	// Stock is the number of units left; nil means unlimited
+	Stock *int `json:"stock" db:"stock"`
+	// PerStudentLimit caps how many units each student may redeem; nil means no cap
+	PerStudentLimit *int `json:"per_student_limit" db:"per_student_limit"`
+	// AvailableFrom and AvailableUntil bound the window in which the reward can be redeemed
+	AvailableFrom  *time.Time `json:"available_from" db:"available_from"`
+	AvailableUntil *time.Time `json:"available_until" db:"available_until"`
+	// RequiresApproval makes redemptions wait for the teacher with the neurons on hold
+	RequiresApproval bool `json:"requires_approval" db:"requires_approval"`
+	// ApprovalTTLHours is how long a hold lasts before the request expires
+	ApprovalTTLHours int       `json:"approval_ttl_hours" db:"approval_ttl_hours"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsAvailable reports whether the reward can be redeemed at the given time
+func (r *Reward) IsAvailable(now time.Time) bool {
+	if r.AvailableFrom != nil && now.Before(*r.AvailableFrom) {
+		return false
+	}
+	if r.AvailableUntil != nil && !now.Before(*r.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+func (r *Reward) validate() error {
+	if r.Name == "" {
+		return errors.ErrBadRequest("name is required")
+	}
+	if r.Cost <= 0 {
+		return errors.ErrBadRequest("cost must be positive")
+	}
+	if r.Stock != nil && *r.Stock < 0 {
+		return errors.ErrBadRequest("stock must not be negative")
+	}
+	if r.PerStudentLimit != nil && *r.PerStudentLimit <= 0 {
+		return errors.ErrBadRequest("per student limit must be positive")
+	}
+	if r.ApprovalTTLHours < 0 {
+		return errors.ErrBadRequest("approval ttl must not be negative")
+	}
+	if r.AvailableFrom != nil && r.AvailableUntil != nil && !r.AvailableFrom.Before(*r.AvailableUntil) {
+		return errors.ErrBadRequest("available_from must be before available_until")
+	}
+	return nil
+}
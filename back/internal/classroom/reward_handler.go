@@ -0,0 +1,170 @@
+package classroom
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// rewardInput is the editable part of a reward
+type rewardInput struct {
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Cost             int        `json:"cost"`
+	Stock            *int       `json:"stock"`
+	PerStudentLimit  *int       `json:"per_student_limit"`
+	AvailableFrom    *time.Time `json:"available_from"`
+	AvailableUntil   *time.Time `json:"available_until"`
+	RequiresApproval bool       `json:"requires_approval"`
+	ApprovalTTLHours int        `json:"approval_ttl_hours"`
+}
+
+func (in rewardInput) reward(classroomID int64) *Reward {
+	return &Reward{
+		ClassroomID:      classroomID,
+		Name:             in.Name,
+		Description:      in.Description,
+		Cost:             in.Cost,
+		Stock:            in.Stock,
+		PerStudentLimit:  in.PerStudentLimit,
+		AvailableFrom:    in.AvailableFrom,
+		AvailableUntil:   in.AvailableUntil,
+		RequiresApproval: in.RequiresApproval,
+		ApprovalTTLHours: in.ApprovalTTLHours,
+	}
+}
+
+func (h *Handler) CreateReward(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input rewardInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	reward, err := h.service.CreateReward(c.Context(), input.reward(classroomID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reward)
+}
+
+func (h *Handler) GetReward(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	rewardID, err := strconv.ParseInt(c.Params("rewardId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid reward id")
+	}
+
+	reward, err := h.service.GetReward(c.Context(), classroomID, rewardID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reward)
+}
+
+func (h *Handler) ListRewards(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	rewards, err := h.service.ListRewards(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(rewards)
+}
+
+func (h *Handler) UpdateReward(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	rewardID, err := strconv.ParseInt(c.Params("rewardId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid reward id")
+	}
+
+	var input rewardInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+	reward := input.reward(classroomID)
+	reward.ID = rewardID
+
+	reward, err = h.service.UpdateReward(c.Context(), reward)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reward)
+}
+
+func (h *Handler) DeleteReward(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	rewardID, err := strconv.ParseInt(c.Params("rewardId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid reward id")
+	}
+
+	err = h.service.DeleteReward(c.Context(), classroomID, rewardID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) RedeemReward(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	rewardID, err := strconv.ParseInt(c.Params("rewardId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid reward id")
+	}
+
+	reward, err := h.service.GetReward(c.Context(), classroomID, rewardID)
+	if err != nil {
+		return err
+	}
+
+	// Rewards that need approval are queued for the teacher instead
+	if reward.RequiresApproval {
+		request, err := h.service.RequestRedemption(c.Context(), u.ID, classroomID, rewardID)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusAccepted).JSON(request)
+	}
+
+	redemption, err := h.service.RedeemReward(c.Context(), u.ID, classroomID, rewardID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(redemption)
+}
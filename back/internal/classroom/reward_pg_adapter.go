@@ -0,0 +1,184 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+const rewardColumns = "id, classroom_id, name, description, cost, stock, per_student_limit, available_from, available_until, requires_approval, approval_ttl_hours, created_at"
+
+func (r *PostgresRepository) CreateReward(ctx context.Context, reward *Reward) error {
+	query := `
+		INSERT INTO rewards (classroom_id, name, description, cost, stock, per_student_limit, available_from, available_until,
+			requires_approval, approval_ttl_hours, created_at)
+		VALUES (:classroom_id, :name, :description, :cost, :stock, :per_student_limit, :available_from, :available_until,
+			:requires_approval, :approval_ttl_hours, :created_at)
+		RETURNING id
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, reward)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create reward: %v", err))
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&reward.ID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to scan reward ID: %v", err))
+		}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetReward(ctx context.Context, id int64) (*Reward, error) {
+	query := "SELECT " + rewardColumns + " FROM rewards WHERE id = $1"
+	var reward Reward
+	err := r.db.GetContext(ctx, &reward, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("reward not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get reward: %v", err))
+	}
+	return &reward, nil
+}
+
+func (r *PostgresRepository) ListRewards(ctx context.Context, classroomID int64) ([]*Reward, error) {
+	query := "SELECT " + rewardColumns + " FROM rewards WHERE classroom_id = $1 ORDER BY cost, id"
+	rewards := []*Reward{}
+	err := r.db.SelectContext(ctx, &rewards, query, classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list rewards: %v", err))
+	}
+	return rewards, nil
+}
+
+func (r *PostgresRepository) UpdateReward(ctx context.Context, reward *Reward) error {
+	query := `
+		UPDATE rewards
+		SET name = :name, description = :description, cost = :cost, stock = :stock,
+			per_student_limit = :per_student_limit, available_from = :available_from,
+			available_until = :available_until, requires_approval = :requires_approval,
+			approval_ttl_hours = :approval_ttl_hours
+		WHERE id = :id
+	`
+	_, err := r.db.NamedExecContext(ctx, query, reward)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update reward: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) DeleteReward(ctx context.Context, id int64) error {
+	var redeemed bool
+	err := r.db.GetContext(ctx, &redeemed, "SELECT EXISTS(SELECT 1 FROM neuron_transactions WHERE reward_id = $1)", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to check reward redemptions: %v", err))
+	}
+	if redeemed {
+		return errors.ErrConflict("reward has been redeemed; set its stock to 0 instead")
+	}
+
+	_, err = r.db.ExecContext(ctx, "DELETE FROM rewards WHERE id = $1", id)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to delete reward: %v", err))
+	}
+	return nil
+}
+
+// RedeemReward takes one unit of the reward out of stock and moves its cost from
+// the student's or their group's wallet to the classroom pool, all in one
+// database transaction
+func (r *PostgresRepository) RedeemReward(ctx context.Context, redemption *NeuronTransaction) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	reward, err := takeRewardUnit(ctx, tx, *redemption.RewardID, redemption.ClassroomID, redemption.UserID)
+	if err != nil {
+		return err
+	}
+	// Charge the cost as of the lock, in case the teacher just changed it
+	redemption.Amount = reward.Cost
+
+	pool, err := getClassroomAccount(ctx, tx, redemption.ClassroomID, AccountTypeClassroom)
+	if err != nil {
+		return err
+	}
+	// A group redemption is paid from the group's wallet by one of its members
+	var wallet *LedgerAccount
+	if redemption.GroupID != nil {
+		if err := checkGroupMember(ctx, tx, *redemption.GroupID, redemption.UserID); err != nil {
+			return err
+		}
+		wallet, err = ensureGroupAccount(ctx, tx, redemption.ClassroomID, *redemption.GroupID)
+	} else {
+		wallet, err = getStudentAccount(ctx, tx, redemption.ClassroomID, redemption.UserID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := transfer(ctx, tx, redemption, wallet, pool); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// takeRewardUnit locks the reward, checks its stock and the student's
+// per-student limit, and takes one unit out of stock
+func takeRewardUnit(ctx context.Context, tx *database.Tx, rewardID, classroomID, studentID int64) (*Reward, error) {
+	var reward Reward
+	err := tx.GetContext(ctx, &reward, "SELECT "+rewardColumns+" FROM rewards WHERE id = $1 FOR UPDATE", rewardID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("reward not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to lock reward: %v", err))
+	}
+	if reward.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("reward not found")
+	}
+
+	if reward.Stock != nil && *reward.Stock <= 0 {
+		return nil, ErrRewardOutOfStock
+	}
+	if reward.PerStudentLimit != nil {
+		// Pending requests count against the limit as well as completed redemptions
+		var redeemed int
+		err = tx.GetContext(ctx, &redeemed, `
+			SELECT
+				(SELECT COUNT(*)
+				 FROM neuron_transactions nt
+				 WHERE nt.reward_id = $1 AND nt.user_id = $2 AND nt.transaction_type = 'redemption'
+				   AND NOT EXISTS (SELECT 1 FROM neuron_transactions r WHERE r.reverses_id = nt.id))
+				+
+				(SELECT COUNT(*)
+				 FROM redemption_requests rr
+				 WHERE rr.reward_id = $1 AND rr.user_id = $2 AND rr.status = 'pending')
+		`, reward.ID, studentID)
+		if err != nil {
+			return nil, errors.ErrDatabase(fmt.Sprintf("failed to count reward redemptions: %v", err))
+		}
+		if redeemed >= *reward.PerStudentLimit {
+			return nil, ErrRewardLimitReached
+		}
+	}
+
+	if reward.Stock != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE rewards SET stock = stock - 1 WHERE id = $1", reward.ID)
+		if err != nil {
+			return nil, errors.ErrDatabase(fmt.Sprintf("failed to decrease reward stock: %v", err))
+		}
+	}
+	return &reward, nil
+}
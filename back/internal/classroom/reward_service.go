@@ -0,0 +1,175 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// CreateReward adds a reward to a classroom's store
+func (s *Service) CreateReward(ctx context.Context, reward *Reward) (*Reward, error) {
+	if err := reward.validate(); err != nil {
+		return nil, err
+	}
+	if reward.ApprovalTTLHours == 0 {
+		reward.ApprovalTTLHours = defaultApprovalTTLHours
+	}
+
+	// Verify that the classroom exists and is not archived
+	_, err := s.getActiveClassroom(ctx, reward.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	reward.CreatedAt = time.Now()
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateReward(ctx, reward)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(reward.ClassroomID, ActionRewardCreated, "reward", reward.ID, nil, reward), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reward, nil
+}
+
+// GetReward retrieves a reward of a classroom
+func (s *Service) GetReward(ctx context.Context, classroomID, rewardID int64) (*Reward, error) {
+	reward, err := s.repo.GetReward(ctx, rewardID)
+	if err != nil {
+		return nil, err
+	}
+	if reward.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("reward not found")
+	}
+	return reward, nil
+}
+
+// ListRewards retrieves the rewards of a classroom
+func (s *Service) ListRewards(ctx context.Context, classroomID int64) ([]*Reward, error) {
+	return s.repo.ListRewards(ctx, classroomID)
+}
+
+// UpdateReward updates a reward of a classroom
+func (s *Service) UpdateReward(ctx context.Context, reward *Reward) (*Reward, error) {
+	if err := reward.validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getActiveClassroom(ctx, reward.ClassroomID); err != nil {
+		return nil, err
+	}
+	existing, err := s.GetReward(ctx, reward.ClassroomID, reward.ID)
+	if err != nil {
+		return nil, err
+	}
+	reward.CreatedAt = existing.CreatedAt
+	if reward.ApprovalTTLHours == 0 {
+		reward.ApprovalTTLHours = existing.ApprovalTTLHours
+	}
+
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.UpdateReward(ctx, reward)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(reward.ClassroomID, ActionRewardUpdated, "reward", reward.ID, existing, reward), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reward, nil
+}
+
+// DeleteReward deletes a reward that was never redeemed
+func (s *Service) DeleteReward(ctx context.Context, classroomID, rewardID int64) error {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return err
+	}
+	reward, err := s.GetReward(ctx, classroomID, rewardID)
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.DeleteReward(ctx, rewardID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionRewardDeleted, "reward", rewardID, reward, nil), nil
+	})
+}
+
+// RedeemReward spends a student's neurons on a reward
+func (s *Service) RedeemReward(ctx context.Context, studentID, classroomID, rewardID int64) (*NeuronTransaction, error) {
+	return s.redeemReward(ctx, studentID, classroomID, rewardID, nil)
+}
+
+// redeemReward spends neurons on a reward from the student's wallet, or from
+// their group's wallet when groupID is set
+func (s *Service) redeemReward(ctx context.Context, studentID, classroomID, rewardID int64, groupID *int64) (*NeuronTransaction, error) {
+	// Verify that the user exists and is a student
+	student, err := s.userService.GetUser(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Role != "student" {
+		return nil, errors.ErrForbidden("only students can redeem rewards")
+	}
+
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	// Verify that the student is in the classroom
+	isStudentInClassroom, err := s.repo.IsStudentInClassroom(ctx, classroomID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !isStudentInClassroom {
+		return nil, errors.ErrBadRequest("student is not in this classroom")
+	}
+
+	reward, err := s.GetReward(ctx, classroomID, rewardID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !reward.IsAvailable(now) {
+		return nil, ErrRewardNotAvailable
+	}
+	if reward.RequiresApproval {
+		return nil, errors.ErrBadRequest("reward requires teacher approval; request it instead")
+	}
+
+	// Stock, per-student limit and balance are checked while the reward is locked
+	redemption := &NeuronTransaction{
+		ClassroomID:     classroomID,
+		UserID:          studentID,
+		Amount:          reward.Cost,
+		TransactionType: TransactionTypeRedemption,
+		RewardID:        &reward.ID,
+		GroupID:         groupID,
+		CreatedBy:       &studentID,
+		Note:            reward.Name,
+		CreatedAt:       now,
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.RedeemReward(ctx, redemption)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionRewardRedeemed, "neuron_transaction", redemption.ID, nil, redemption), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return redemption, nil
+}
@@ -2,40 +2,127 @@ package classroom
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/neurons/internal/budget"
+	"github.com/Abraxas-365/neurons/internal/organization"
 	"github.com/Abraxas-365/neurons/internal/user"
 	"github.com/Abraxas-365/toolkit/pkg/errors"
 )
 
 type Servicer interface {
-	SendNeurons(ctx context.Context, teacherID, classroomID, studentID int64, amount int) error
+	SendNeurons(ctx context.Context, teacherID, classroomID, studentID int64, amount int, details TransactionDetails) error
 	CreateClassRoom(ctx context.Context, teacherId int64, name string) (*ClassroomWithData, error)
 	GetClassroom(ctx context.Context, id int64) (*ClassroomWithData, error)
 	UpdateClassroom(ctx context.Context, classroom *Classroom) (*ClassroomWithData, error)
-	DeleteClassroom(ctx context.Context, id int64) error
-	ListClassrooms(ctx context.Context, limit, offset int) ([]*ClassroomWithData, error)
+	ArchiveClassroom(ctx context.Context, id int64) error
+	RestoreClassroom(ctx context.Context, id int64) error
+	PurgeClassroom(ctx context.Context, id int64) error
+	ListClassrooms(ctx context.Context, organizationID int64, archived bool, limit, offset int) ([]*ClassroomWithData, error)
 	AddStudentToClassroom(ctx context.Context, classroomID, studentID int64) error
 	RemoveStudentFromClassroom(ctx context.Context, classroomID, studentID int64) error
-	UpdateAvailableNeurons(ctx context.Context, classroomID int64, neurons int) error
+	UpdateAvailableNeurons(ctx context.Context, teacherID, classroomID int64, neurons int) error
 	GetClassroomStudents(ctx context.Context, classroomID int64) ([]*Student, error)
 	GetUserNeurons(ctx context.Context, userID, classroomID int64) (int, error)
-	ListUserClassrooms(ctx context.Context, userID int64, role string, limit, offset int) ([]*ClassroomWithData, error)
-	ReturnNeuronsToClassroom(ctx context.Context, studentID, classroomID int64, amount int) error
+	ListUserClassrooms(ctx context.Context, userID int64, role string, archived bool, limit, offset int) ([]*ClassroomWithData, error)
+	ReturnNeuronsToClassroom(ctx context.Context, studentID, classroomID int64, amount int, details TransactionDetails) error
+	DeductNeurons(ctx context.Context, teacherID, classroomID, studentID int64, amount int, details TransactionDetails) (*NeuronTransaction, error)
+	ReconcileLedger(ctx context.Context, classroomID int64) (*LedgerReconciliation, error)
+	ListClassroomTransactions(ctx context.Context, classroomID int64, filter TransactionFilter) (*TransactionPage, error)
+	ListUserTransactions(ctx context.Context, userID int64, filter TransactionFilter) (*TransactionPage, error)
+	ReverseTransaction(ctx context.Context, teacherID, classroomID, transactionID int64, reason string) (*NeuronTransaction, error)
+	SendNeuronsBulk(ctx context.Context, teacherID, classroomID int64, transfers []BulkTransfer, amountEach int, details TransactionDetails) (*TransactionBatch, error)
+	CreateCategory(ctx context.Context, classroomID int64, name string, defaultAmount int) (*TransactionCategory, error)
+	ListCategories(ctx context.Context, classroomID int64) ([]*TransactionCategory, error)
+	UpdateCategory(ctx context.Context, classroomID, categoryID int64, name string, defaultAmount int) (*TransactionCategory, error)
+	DeleteCategory(ctx context.Context, classroomID, categoryID int64) error
+	GetStudentCategoryTotals(ctx context.Context, classroomID, studentID int64) ([]*CategoryTotal, error)
+	CreateReward(ctx context.Context, reward *Reward) (*Reward, error)
+	GetReward(ctx context.Context, classroomID, rewardID int64) (*Reward, error)
+	ListRewards(ctx context.Context, classroomID int64) ([]*Reward, error)
+	UpdateReward(ctx context.Context, reward *Reward) (*Reward, error)
+	DeleteReward(ctx context.Context, classroomID, rewardID int64) error
+	RedeemReward(ctx context.Context, studentID, classroomID, rewardID int64) (*NeuronTransaction, error)
+	RequestRedemption(ctx context.Context, studentID, classroomID, rewardID int64) (*RedemptionRequest, error)
+	ListRedemptionRequests(ctx context.Context, classroomID int64, status string, userID *int64) ([]*RedemptionRequest, error)
+	ApproveRedemptionRequest(ctx context.Context, teacherID, classroomID, requestID int64) (*RedemptionRequest, error)
+	RejectRedemptionRequest(ctx context.Context, teacherID, classroomID, requestID int64, reason string) (*RedemptionRequest, error)
+	FulfillRedemptionRequest(ctx context.Context, classroomID, requestID int64) (*RedemptionRequest, error)
+	ExpireRedemptionRequests(ctx context.Context) (int, error)
+	GenerateJoinCode(ctx context.Context, joinCode *JoinCode) (*JoinCode, error)
+	GetJoinCode(ctx context.Context, classroomID int64) (*JoinCode, error)
+	UpdateJoinCode(ctx context.Context, classroomID int64, update *JoinCodeUpdate) (*JoinCode, error)
+	JoinClassroom(ctx context.Context, studentID int64, code string) (*JoinResult, error)
+	ListEnrollmentRequests(ctx context.Context, classroomID int64, status string) ([]*EnrollmentRequest, error)
+	ApproveEnrollmentRequests(ctx context.Context, teacherID, classroomID int64, requestIDs []int64) ([]*EnrollmentRequest, error)
+	RejectEnrollmentRequest(ctx context.Context, teacherID, classroomID, requestID int64, reason string) (*EnrollmentRequest, error)
+	ListStaff(ctx context.Context, classroomID int64) ([]*StaffMember, error)
+	InviteStaffMember(ctx context.Context, ownerID int64, member *StaffMember) (*StaffMember, error)
+	AcceptStaffInvitation(ctx context.Context, classroomID, userID int64) (*StaffMember, error)
+	UpdateStaffMember(ctx context.Context, member *StaffMember) (*StaffMember, error)
+	RemoveStaffMember(ctx context.Context, classroomID, userID int64) error
+	TransferOwnership(ctx context.Context, classroomID, ownerID, newOwnerID int64) (*ClassroomWithData, error)
+	ReassignClassroom(ctx context.Context, classroomID, teacherID int64) (*ClassroomWithData, error)
+	GetNeuronStats(ctx context.Context) (*NeuronStats, error)
+	ListAuditLog(ctx context.Context, classroomID int64, filter audit.Filter) ([]*audit.Entry, error)
+	ListGroups(ctx context.Context, classroomID int64) ([]*StudentGroup, error)
+	CreateGroup(ctx context.Context, group *StudentGroup) (*StudentGroup, error)
+	RenameGroup(ctx context.Context, classroomID, groupID int64, name string) (*StudentGroup, error)
+	SetGroupMembers(ctx context.Context, classroomID, groupID int64, memberIDs []int64) (*StudentGroup, error)
+	DeleteGroup(ctx context.Context, classroomID, groupID int64) error
+	ShuffleGroups(ctx context.Context, classroomID int64, teams int, namePrefix string) ([]*StudentGroup, error)
+	SendNeuronsToGroup(ctx context.Context, teacherID, classroomID, groupID int64, send GroupSend) (*TransactionBatch, error)
+	FundGroup(ctx context.Context, teacherID, classroomID, groupID int64, amount int, details TransactionDetails) (*NeuronTransaction, error)
+	ContributeToGroup(ctx context.Context, studentID, classroomID, groupID int64, amount int, details TransactionDetails) (*NeuronTransaction, error)
+	RedeemRewardForGroup(ctx context.Context, studentID, classroomID, groupID, rewardID int64) (*NeuronTransaction, error)
+	ListGroupTransactions(ctx context.Context, classroomID, groupID int64, filter TransactionFilter) (*TransactionPage, error)
+	GetGiftSettings(ctx context.Context, classroomID int64) (*GiftSettings, error)
+	UpdateGiftSettings(ctx context.Context, settings *GiftSettings) (*GiftSettings, error)
+	GiveGift(ctx context.Context, senderID, classroomID, recipientID int64, amount int, note string) (*Gift, error)
+	ListGifts(ctx context.Context, classroomID int64, status string, userID *int64) ([]*Gift, error)
+	ApproveGift(ctx context.Context, teacherID, classroomID, giftID int64) (*Gift, error)
+	RejectGift(ctx context.Context, teacherID, classroomID, giftID int64, reason string) (*Gift, error)
+	ListAllowanceRules(ctx context.Context, classroomID int64) ([]*AllowanceRule, error)
+	CreateAllowanceRule(ctx context.Context, rule *AllowanceRule) (*AllowanceRule, error)
+	UpdateAllowanceRule(ctx context.Context, rule *AllowanceRule) (*AllowanceRule, error)
+	DeleteAllowanceRule(ctx context.Context, classroomID, ruleID int64) error
+	ListAllowanceRuns(ctx context.Context, classroomID, ruleID int64, limit, offset int) ([]*AllowanceRun, error)
+	RunAllowances(ctx context.Context) (int, error)
+	GetNeuronPolicy(ctx context.Context, classroomID int64) (*NeuronPolicy, error)
+	UpdateNeuronPolicy(ctx context.Context, classroomID int64, update *NeuronPolicyUpdate) (*NeuronPolicy, error)
+	GetExpiringNeurons(ctx context.Context, classroomID, studentID int64, within time.Duration) (*ExpiringNeurons, error)
+	ExpireNeurons(ctx context.Context) (int, error)
+	EndTerm(ctx context.Context, teacherID, classroomID int64) (*TermEndResult, error)
+	ListBadges(ctx context.Context, classroomID int64) ([]*Badge, error)
+	CreateBadge(ctx context.Context, badge *Badge) (*Badge, error)
+	UpdateBadge(ctx context.Context, badge *Badge) (*Badge, error)
+	DeleteBadge(ctx context.Context, classroomID, badgeID int64) error
+	AwardBadge(ctx context.Context, teacherID, classroomID, badgeID, studentID int64, note string) (*BadgeAward, error)
+	ListBadgeAwards(ctx context.Context, classroomID int64, userID *int64) ([]*BadgeAward, error)
+	ListUserBadges(ctx context.Context, userID int64) ([]*BadgeAward, error)
 }
 
 var _ Servicer = (*Service)(nil)
 
 type Service struct {
-	userService user.Servicer
-	repo        DBRepository
+	userService         user.Servicer
+	organizationService organization.Servicer
+	budgetService       budget.Servicer
+	auditService        audit.Servicer
+	repo                DBRepository
 }
 
 // NewService creates a new classroom service
-func NewService(userService user.Servicer, repo DBRepository) *Service {
+func NewService(userService user.Servicer, organizationService organization.Servicer, budgetService budget.Servicer, auditService audit.Servicer, repo DBRepository) *Service {
 	return &Service{
-		userService: userService,
-		repo:        repo,
+		userService:         userService,
+		organizationService: organizationService,
+		budgetService:       budgetService,
+		auditService:        auditService,
+		repo:                repo,
 	}
 }
 
@@ -51,14 +138,47 @@ func (s *Service) CreateClassRoom(ctx context.Context, teacherId int64, name str
 		return nil, errors.ErrBadRequest("user is not a teacher")
 	}
 
+	// New classrooms open with the organization's default pool, drawn from
+	// the teacher's budget. They open with what the budget can cover, and
+	// with an empty pool without a budget.
+	org, err := s.organizationService.GetOrganization(ctx, teacher.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	neurons := org.DefaultClassroomNeurons
+	if neurons > 0 {
+		funding, err := s.budgetService.BudgetFor(ctx, teacherId, org.ID)
+		switch {
+		case err == budget.ErrNoBudget:
+			neurons = 0
+		case err != nil:
+			return nil, err
+		default:
+			neurons = min(neurons, funding.Balance)
+		}
+	}
+
 	classroom := &Classroom{
 		Name:             name,
 		TeacherID:        teacherId,
-		AvailableNeurons: 0, // Initial value, can be changed if needed
+		OrganizationID:   org.ID,
+		AvailableNeurons: neurons,
 		CreatedAt:        time.Now(),
 	}
 
-	classroomWithData, err := s.repo.CreateClassroom(ctx, classroom)
+	var classroomWithData *ClassroomWithData
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		classroomWithData, err = s.repo.CreateClassroom(ctx, classroom)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.budgetService.Allocate(ctx, teacherId, org.ID, classroomWithData.ID, neurons)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomWithData.ID, ActionClassroomCreated, "classroom", classroomWithData.ID, nil, classroomWithData.Classroom), nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -77,25 +197,95 @@ func (s *Service) GetClassroom(ctx context.Context, id int64) (*ClassroomWithDat
 
 // UpdateClassroom updates an existing classroom
 func (s *Service) UpdateClassroom(ctx context.Context, classroom *Classroom) (*ClassroomWithData, error) {
-	classroomWithData, err := s.repo.UpdateClassroom(ctx, classroom)
+	var classroomWithData *ClassroomWithData
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.getActiveClassroom(ctx, classroom.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		classroomWithData, err = s.repo.UpdateClassroom(ctx, classroom)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroom.ID, ActionClassroomUpdated, "classroom", classroom.ID, before.Classroom, classroomWithData.Classroom), nil
+	})
 	if err != nil {
 		return nil, err
 	}
 	return classroomWithData, nil
 }
 
-// DeleteClassroom deletes a classroom
-func (s *Service) DeleteClassroom(ctx context.Context, id int64) error {
-	err := s.repo.DeleteClassroom(ctx, id)
+// ArchiveClassroom makes a classroom read-only and hides it from listings.
+// Its history is kept until it is purged.
+func (s *Service) ArchiveClassroom(ctx context.Context, id int64) error {
+	return s.changeClassroom(ctx, ActionClassroomArchived, id, func(ctx context.Context) error {
+		return s.repo.ArchiveClassroom(ctx, id, time.Now())
+	})
+}
+
+// RestoreClassroom brings an archived classroom back
+func (s *Service) RestoreClassroom(ctx context.Context, id int64) error {
+	return s.changeClassroom(ctx, ActionClassroomRestored, id, func(ctx context.Context) error {
+		return s.repo.RestoreClassroom(ctx, id)
+	})
+}
+
+// PurgeClassroom permanently deletes a classroom that has been archived for
+// longer than PurgeRetention
+func (s *Service) PurgeClassroom(ctx context.Context, id int64) error {
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.GetClassroom(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.PurgeClassroom(ctx, id, time.Now().Add(-PurgeRetention))
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(id, ActionClassroomPurged, "classroom", id, before.Classroom, nil), nil
+	})
+}
+
+// changeClassroom applies a change to a classroom and records it in the audit log
+func (s *Service) changeClassroom(ctx context.Context, action string, id int64, change func(ctx context.Context) error) error {
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.GetClassroom(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		err = change(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := s.repo.GetClassroom(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(id, action, "classroom", id, before.Classroom, after.Classroom), nil
+	})
+}
+
+// getActiveClassroom retrieves a classroom for a change that archived
+// classrooms don't allow
+func (s *Service) getActiveClassroom(ctx context.Context, id int64) (*ClassroomWithData, error) {
+	classroom, err := s.repo.GetClassroom(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	if classroom.ArchivedAt != nil {
+		return nil, ErrClassroomArchived
+	}
+	return classroom, nil
 }
 
-// ListClassrooms retrieves a list of classrooms
-func (s *Service) ListClassrooms(ctx context.Context, limit, offset int) ([]*ClassroomWithData, error) {
-	classroomsWithData, err := s.repo.ListClassrooms(ctx, limit, offset)
+// ListClassrooms retrieves a list of an organization's classrooms, either the
+// active or the archived ones
+func (s *Service) ListClassrooms(ctx context.Context, organizationID int64, archived bool, limit, offset int) ([]*ClassroomWithData, error) {
+	classroomsWithData, err := s.repo.ListClassrooms(ctx, organizationID, archived, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -114,29 +304,77 @@ func (s *Service) AddStudentToClassroom(ctx context.Context, classroomID, studen
 		return errors.ErrBadRequest("user is not a student")
 	}
 
-	err = s.repo.AddStudentToClassroom(ctx, classroomID, studentID)
+	// Verify that the student belongs to the classroom's organization
+	classroom, err := s.getActiveClassroom(ctx, classroomID)
 	if err != nil {
 		return err
 	}
-	return nil
+	if classroom.OrganizationID != student.OrganizationID {
+		return errors.ErrNotFound("user not found")
+	}
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.AddStudentToClassroom(ctx, classroomID, studentID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionStudentAdded, "user", studentID, enrollment{}, enrollment{Enrolled: true}), nil
+	})
 }
 
 // RemoveStudentFromClassroom removes a student from a classroom
 func (s *Service) RemoveStudentFromClassroom(ctx context.Context, classroomID, studentID int64) error {
-	err := s.repo.RemoveStudentFromClassroom(ctx, classroomID, studentID)
-	if err != nil {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
 		return err
 	}
-	return nil
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		isStudentInClassroom, err := s.repo.IsStudentInClassroom(ctx, classroomID, studentID)
+		if err != nil {
+			return nil, err
+		}
+		if !isStudentInClassroom {
+			return nil, nil
+		}
+
+		err = s.repo.RemoveStudentFromClassroom(ctx, classroomID, studentID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionStudentRemoved, "user", studentID, enrollment{Enrolled: true}, enrollment{}), nil
+	})
 }
 
-// UpdateAvailableNeurons updates the available neurons for a classroom
-func (s *Service) UpdateAvailableNeurons(ctx context.Context, classroomID int64, neurons int) error {
-	err := s.repo.UpdateAvailableNeurons(ctx, classroomID, neurons)
-	if err != nil {
-		return err
-	}
-	return nil
+// UpdateAvailableNeurons tops up or drains a classroom's pool. Neurons added
+// are drawn from the teacher's budget and neurons removed go back to the
+// budgets that funded the classroom.
+func (s *Service) UpdateAvailableNeurons(ctx context.Context, teacherID, classroomID int64, neurons int) error {
+	if neurons < 0 {
+		return errors.ErrBadRequest("available neurons cannot be negative")
+	}
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.getActiveClassroom(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		delta, err := s.repo.UpdateAvailableNeurons(ctx, classroomID, neurons)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.budgetService.Allocate(ctx, teacherID, before.OrganizationID, classroomID, delta)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := s.repo.GetClassroom(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionPoolUpdated, "classroom", classroomID, before.Classroom, after.Classroom), nil
+	})
 }
 
 // GetClassroomStudents retrieves all students in a classroom
@@ -148,7 +386,7 @@ func (s *Service) GetClassroomStudents(ctx context.Context, classroomID int64) (
 	return students, nil
 }
 
-func (s *Service) SendNeurons(ctx context.Context, teacherID, classroomID, studentID int64, amount int) error {
+func (s *Service) SendNeurons(ctx context.Context, teacherID, classroomID, studentID int64, amount int, details TransactionDetails) error {
 	// Verify that the sender is a teacher
 	teacher, err := s.userService.GetUser(ctx, teacherID)
 	if err != nil {
@@ -158,14 +396,11 @@ func (s *Service) SendNeurons(ctx context.Context, teacherID, classroomID, stude
 		return errors.ErrForbidden("only teachers can send neurons")
 	}
 
-	// Verify that the classroom belongs to the teacher
-	classroom, err := s.repo.GetClassroom(ctx, classroomID)
+	// Verify that the classroom exists and is not archived
+	_, err = s.getActiveClassroom(ctx, classroomID)
 	if err != nil {
 		return err
 	}
-	if classroom.TeacherID != teacherID {
-		return errors.ErrForbidden("teacher does not own this classroom")
-	}
 
 	// Verify that the student is in the classroom
 	isStudentInClassroom, err := s.repo.IsStudentInClassroom(ctx, classroomID, studentID)
@@ -176,31 +411,144 @@ func (s *Service) SendNeurons(ctx context.Context, teacherID, classroomID, stude
 		return errors.ErrBadRequest("student is not in this classroom")
 	}
 
-	// Verify that the classroom has enough neurons
-	if classroom.AvailableNeurons < amount {
-		return errors.ErrBadRequest("not enough neurons in the classroom")
-	}
-
-	// Perform the neuron transfer
-	err = s.repo.TransferNeurons(ctx, classroomID, studentID, amount)
+	// Fall back to the category's default amount
+	category, err := s.resolveCategory(ctx, classroomID, details)
 	if err != nil {
 		return err
 	}
+	if amount == 0 && category != nil {
+		amount = category.DefaultAmount
+	}
+	if amount <= 0 {
+		return ErrAmountNotPositive
+	}
 
-	// Record the transaction
+	// Perform the neuron transfer; the balance and daily limit checks happen
+	// in the same database transaction so concurrent sends cannot overdraw
+	// the classroom or exceed the teacher's limit
 	transaction := &NeuronTransaction{
 		ClassroomID:     classroomID,
 		UserID:          studentID,
 		Amount:          amount,
-		TransactionType: "assignment",
+		TransactionType: TransactionTypeAssignment,
+		CategoryID:      details.CategoryID,
+		CreatedBy:       &teacherID,
+		Note:            details.Note,
 		CreatedAt:       time.Now(),
 	}
-	err = s.repo.RecordNeuronTransaction(ctx, transaction)
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		// Verify that the teacher is on the classroom's staff and within their daily limit
+		if err := s.checkSendAllowance(ctx, classroomID, teacherID, amount); err != nil {
+			return nil, err
+		}
+
+		err := s.repo.TransferNeurons(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionNeuronsSent, "neuron_transaction", transaction.ID, nil, transaction), nil
+	})
+}
+
+// SendNeuronsBulk distributes neurons from the classroom pool to many students in
+// one all-or-nothing batch. When amountEach is set instead of transfers, every
+// student in the classroom receives that amount.
+func (s *Service) SendNeuronsBulk(ctx context.Context, teacherID, classroomID int64, transfers []BulkTransfer, amountEach int, details TransactionDetails) (*TransactionBatch, error) {
+	// Verify that the sender is a teacher
+	teacher, err := s.userService.GetUser(ctx, teacherID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if teacher.Role != "teacher" {
+		return nil, errors.ErrForbidden("only teachers can send neurons")
 	}
 
-	return nil
+	classroom, err := s.getActiveClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := s.resolveCategory(ctx, classroomID, details)
+	if err != nil {
+		return nil, err
+	}
+	if amountEach == 0 && len(transfers) == 0 && category != nil {
+		amountEach = category.DefaultAmount
+	}
+
+	if amountEach != 0 {
+		if len(transfers) > 0 {
+			return nil, errors.ErrBadRequest("use either transfers or amount_each, not both")
+		}
+		for _, student := range classroom.Students {
+			transfers = append(transfers, BulkTransfer{StudentID: student.ID, Amount: amountEach})
+		}
+	}
+	if len(transfers) == 0 {
+		return nil, errors.ErrBadRequest("no students to send neurons to")
+	}
+
+	// Validate the whole batch before touching any balance
+	enrolled := make(map[int64]bool, len(classroom.Students))
+	for _, student := range classroom.Students {
+		enrolled[student.ID] = true
+	}
+	seen := make(map[int64]bool, len(transfers))
+	total := 0
+	for _, transfer := range transfers {
+		if transfer.Amount <= 0 {
+			return nil, errors.ErrBadRequest(fmt.Sprintf("amount for student %d must be positive", transfer.StudentID))
+		}
+		if !enrolled[transfer.StudentID] {
+			return nil, errors.ErrBadRequest(fmt.Sprintf("student %d is not in this classroom", transfer.StudentID))
+		}
+		if seen[transfer.StudentID] {
+			return nil, errors.ErrBadRequest(fmt.Sprintf("student %d appears more than once", transfer.StudentID))
+		}
+		seen[transfer.StudentID] = true
+		total += transfer.Amount
+	}
+	if classroom.AvailableNeurons < total {
+		return nil, ErrInsufficientClassroomNeurons
+	}
+
+	now := time.Now()
+	batch := &TransactionBatch{
+		ClassroomID: classroomID,
+		CreatedBy:   teacherID,
+		CreatedAt:   now,
+	}
+	for _, transfer := range transfers {
+		batch.Transactions = append(batch.Transactions, &NeuronTransaction{
+			ClassroomID:     classroomID,
+			UserID:          transfer.StudentID,
+			Amount:          transfer.Amount,
+			TransactionType: TransactionTypeAssignment,
+			CategoryID:      details.CategoryID,
+			CreatedBy:       &teacherID,
+			Note:            details.Note,
+			CreatedAt:       now,
+		})
+	}
+
+	// The pool balance is checked again under lock when the batch is applied
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		// Verify that the teacher is on the classroom's staff and within their daily limit
+		if err := s.checkSendAllowance(ctx, classroomID, teacherID, total); err != nil {
+			return nil, err
+		}
+
+		err := s.repo.TransferNeuronsBulk(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionNeuronsSentBulk, "transaction_batch", batch.ID, nil, batch), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
 }
 
 func (s *Service) GetUserNeurons(ctx context.Context, userID, classroomID int64) (int, error) {
@@ -225,7 +573,7 @@ func (s *Service) GetUserNeurons(ctx context.Context, userID, classroomID int64)
 	return neurons, nil
 }
 
-func (s *Service) ListUserClassrooms(ctx context.Context, userID int64, role string, limit, offset int) ([]*ClassroomWithData, error) {
+func (s *Service) ListUserClassrooms(ctx context.Context, userID int64, role string, archived bool, limit, offset int) ([]*ClassroomWithData, error) {
 	// Verify that the user exists and has the correct role
 	user, err := s.userService.GetUser(ctx, userID)
 	if err != nil {
@@ -236,10 +584,14 @@ func (s *Service) ListUserClassrooms(ctx context.Context, userID int64, role str
 		return nil, errors.ErrBadRequest("user role does not match the requested role")
 	}
 
-	return s.repo.ListUserClassrooms(ctx, userID, role, limit, offset)
+	return s.repo.ListUserClassrooms(ctx, userID, role, archived, limit, offset)
 }
 
-func (s *Service) ReturnNeuronsToClassroom(ctx context.Context, studentID, classroomID int64, amount int) error {
+func (s *Service) ReturnNeuronsToClassroom(ctx context.Context, studentID, classroomID int64, amount int, details TransactionDetails) error {
+	if amount <= 0 {
+		return ErrAmountNotPositive
+	}
+
 	// Verify that the user exists and is a student
 	student, err := s.userService.GetUser(ctx, studentID)
 	if err != nil {
@@ -249,8 +601,8 @@ func (s *Service) ReturnNeuronsToClassroom(ctx context.Context, studentID, class
 		return errors.ErrForbidden("only students can return neurons")
 	}
 
-	// Verify that the classroom exists
-	_, err = s.repo.GetClassroom(ctx, classroomID)
+	// Verify that the classroom exists and is not archived
+	_, err = s.getActiveClassroom(ctx, classroomID)
 	if err != nil {
 		return err
 	}
@@ -264,33 +616,337 @@ func (s *Service) ReturnNeuronsToClassroom(ctx context.Context, studentID, class
 		return errors.ErrBadRequest("student is not in this classroom")
 	}
 
-	// Verify that the student has enough neurons to return
-	studentNeurons, err := s.repo.GetUserNeurons(ctx, studentID, classroomID)
-	if err != nil {
+	if _, err := s.resolveCategory(ctx, classroomID, details); err != nil {
 		return err
 	}
-	if studentNeurons < amount {
-		return errors.ErrBadRequest("student does not have enough neurons to return")
+
+	// Perform the neuron transfer (from student back to classroom); the
+	// student's balance is checked while their row is locked
+	transaction := &NeuronTransaction{
+		ClassroomID:     classroomID,
+		UserID:          studentID,
+		Amount:          amount,
+		TransactionType: TransactionTypeReturn,
+		CategoryID:      details.CategoryID,
+		CreatedBy:       &studentID,
+		Note:            details.Note,
+		CreatedAt:       time.Now(),
+	}
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.TransferNeuronsToClassroom(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionNeuronsReturned, "neuron_transaction", transaction.ID, nil, transaction), nil
+	})
+}
+
+// DeductNeurons takes neurons from a student back to the classroom pool, for
+// example as a behavior penalty. The note is the required reason, and the
+// student can only end up below zero if the classroom's policy allows it.
+func (s *Service) DeductNeurons(ctx context.Context, teacherID, classroomID, studentID int64, amount int, details TransactionDetails) (*NeuronTransaction, error) {
+	details.Note = strings.TrimSpace(details.Note)
+	if details.Note == "" {
+		return nil, errors.ErrBadRequest("reason is required")
+	}
+
+	// Verify that the teacher may take neurons away
+	if err := s.checkStaffPermission(ctx, classroomID, teacherID, PermissionDeductNeurons); err != nil {
+		return nil, err
+	}
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
 	}
 
-	// Perform the neuron transfer (from student back to classroom)
-	err = s.repo.TransferNeuronsToClassroom(ctx, classroomID, studentID, amount)
+	isStudentInClassroom, err := s.repo.IsStudentInClassroom(ctx, classroomID, studentID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !isStudentInClassroom {
+		return nil, errors.ErrBadRequest("student is not in this classroom")
+	}
+
+	category, err := s.resolveCategory(ctx, classroomID, details)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 && category != nil {
+		amount = category.DefaultAmount
+	}
+	if amount <= 0 {
+		return nil, ErrAmountNotPositive
 	}
 
-	// Record the transaction
 	transaction := &NeuronTransaction{
 		ClassroomID:     classroomID,
 		UserID:          studentID,
 		Amount:          amount,
-		TransactionType: "return",
+		TransactionType: TransactionTypeDeduction,
+		CategoryID:      details.CategoryID,
+		CreatedBy:       &teacherID,
+		Note:            details.Note,
 		CreatedAt:       time.Now(),
 	}
-	err = s.repo.RecordNeuronTransaction(ctx, transaction)
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.DeductNeurons(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionNeuronsDeducted, "neuron_transaction", transaction.ID, nil, transaction), nil
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// ReconcileLedger checks that every account balance in the classroom matches its postings
+func (s *Service) ReconcileLedger(ctx context.Context, classroomID int64) (*LedgerReconciliation, error) {
+	// Verify that the classroom exists
+	_, err := s.repo.GetClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ReconcileLedger(ctx, classroomID)
+}
+
+// GetNeuronStats summarizes neurons across all classrooms
+func (s *Service) GetNeuronStats(ctx context.Context) (*NeuronStats, error) {
+	return s.repo.GetNeuronStats(ctx)
+}
+
+// ListClassroomTransactions retrieves the neuron transaction history of a classroom
+func (s *Service) ListClassroomTransactions(ctx context.Context, classroomID int64, filter TransactionFilter) (*TransactionPage, error) {
+	// Verify that the classroom exists
+	_, err := s.repo.GetClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
 	}
 
+	filter.ClassroomID = &classroomID
+	if err := validateTransactionFilter(&filter); err != nil {
+		return nil, err
+	}
+	return s.repo.ListNeuronTransactions(ctx, filter)
+}
+
+// ListUserTransactions retrieves the neuron transaction history of a user across their classrooms
+func (s *Service) ListUserTransactions(ctx context.Context, userID int64, filter TransactionFilter) (*TransactionPage, error) {
+	// Verify that the user exists
+	_, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.UserID = &userID
+	if err := validateTransactionFilter(&filter); err != nil {
+		return nil, err
+	}
+	return s.repo.ListNeuronTransactions(ctx, filter)
+}
+
+const (
+	defaultTransactionPageSize = 20
+	maxTransactionPageSize     = 100
+)
+
+func validateTransactionFilter(filter *TransactionFilter) error {
+	if filter.TransactionType != "" && !transactionTypes[filter.TransactionType] {
+		return errors.ErrBadRequest("invalid transaction type")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return errors.ErrBadRequest("from must be before to")
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return errors.ErrBadRequest("min_amount must not be greater than max_amount")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTransactionPageSize
+	}
+	if filter.Limit > maxTransactionPageSize {
+		filter.Limit = maxTransactionPageSize
+	}
 	return nil
 }
+
+// ReverseTransaction undoes a neuron transaction with a compensating reversal,
+// moving the neurons back as long as the balances still allow it
+func (s *Service) ReverseTransaction(ctx context.Context, teacherID, classroomID, transactionID int64, reason string) (*NeuronTransaction, error) {
+	if reason == "" {
+		return nil, errors.ErrBadRequest("reason is required")
+	}
+
+	// Verify that the teacher may manage the classroom
+	if err := s.checkStaffPermission(ctx, classroomID, teacherID, PermissionManageClassroom); err != nil {
+		return nil, err
+	}
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+
+	// Verify that the transaction belongs to the classroom and can be reversed
+	original, err := s.repo.GetNeuronTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if original.ClassroomID != classroomID {
+		return nil, errors.ErrNotFound("transaction not found")
+	}
+	switch original.TransactionType {
+	case TransactionTypeReversal:
+		return nil, errors.ErrBadRequest("a reversal cannot be reversed")
+	case TransactionTypeHold, TransactionTypeRelease:
+		return nil, errors.ErrBadRequest("holds are settled through their redemption request")
+	}
+
+	reversal := &NeuronTransaction{
+		ClassroomID:     classroomID,
+		UserID:          original.UserID,
+		Amount:          original.Amount,
+		TransactionType: TransactionTypeReversal,
+		ReversesID:      &original.ID,
+		GroupID:         original.GroupID,
+		RecipientID:     original.RecipientID,
+		CreatedBy:       &teacherID,
+		Note:            reason,
+		CreatedAt:       time.Now(),
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.ReverseNeuronTransaction(ctx, reversal)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionTransactionReversed, "neuron_transaction", original.ID, original, reversal), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+// resolveCategory returns the category referenced by the details, if any,
+// making sure it belongs to the classroom
+func (s *Service) resolveCategory(ctx context.Context, classroomID int64, details TransactionDetails) (*TransactionCategory, error) {
+	if details.CategoryID == nil {
+		return nil, nil
+	}
+	category, err := s.repo.GetCategory(ctx, *details.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if category.ClassroomID != classroomID {
+		return nil, errors.ErrBadRequest("category does not belong to this classroom")
+	}
+	return category, nil
+}
+
+// CreateCategory creates a transaction category in a classroom
+func (s *Service) CreateCategory(ctx context.Context, classroomID int64, name string, defaultAmount int) (*TransactionCategory, error) {
+	if name == "" {
+		return nil, errors.ErrBadRequest("name is required")
+	}
+	if defaultAmount < 0 {
+		return nil, errors.ErrBadRequest("default amount must not be negative")
+	}
+
+	// Verify that the classroom exists and is not archived
+	_, err := s.getActiveClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	category := &TransactionCategory{
+		ClassroomID:   classroomID,
+		Name:          name,
+		DefaultAmount: defaultAmount,
+		CreatedAt:     time.Now(),
+	}
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.CreateCategory(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionCategoryCreated, "category", category.ID, nil, category), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// ListCategories retrieves the transaction categories of a classroom
+func (s *Service) ListCategories(ctx context.Context, classroomID int64) ([]*TransactionCategory, error) {
+	return s.repo.ListCategories(ctx, classroomID)
+}
+
+// UpdateCategory renames a category or changes its default amount
+func (s *Service) UpdateCategory(ctx context.Context, classroomID, categoryID int64, name string, defaultAmount int) (*TransactionCategory, error) {
+	if name == "" {
+		return nil, errors.ErrBadRequest("name is required")
+	}
+	if defaultAmount < 0 {
+		return nil, errors.ErrBadRequest("default amount must not be negative")
+	}
+
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return nil, err
+	}
+	category, err := s.resolveCategory(ctx, classroomID, TransactionDetails{CategoryID: &categoryID})
+	if err != nil {
+		return nil, err
+	}
+	before := *category
+	category.Name = name
+	category.DefaultAmount = defaultAmount
+
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.UpdateCategory(ctx, category)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionCategoryUpdated, "category", categoryID, before, category), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// DeleteCategory deletes a category that no transaction uses
+func (s *Service) DeleteCategory(ctx context.Context, classroomID, categoryID int64) error {
+	if _, err := s.getActiveClassroom(ctx, classroomID); err != nil {
+		return err
+	}
+	category, err := s.resolveCategory(ctx, classroomID, TransactionDetails{CategoryID: &categoryID})
+	if err != nil {
+		return err
+	}
+
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.DeleteCategory(ctx, categoryID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionCategoryDeleted, "category", categoryID, category, nil), nil
+	})
+}
+
+// GetStudentCategoryTotals retrieves how many neurons a student earned per category
+func (s *Service) GetStudentCategoryTotals(ctx context.Context, classroomID, studentID int64) ([]*CategoryTotal, error) {
+	// Verify that the student is in the classroom
+	isStudentInClassroom, err := s.repo.IsStudentInClassroom(ctx, classroomID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !isStudentInClassroom {
+		return nil, errors.ErrBadRequest("student is not in this classroom")
+	}
+
+	return s.repo.GetStudentCategoryTotals(ctx, classroomID, studentID)
+}
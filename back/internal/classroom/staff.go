@@ -0,0 +1,96 @@
+package classroom
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Staff roles within a classroom
+const (
+	StaffRoleOwner     = "owner"
+	StaffRoleCoTeacher = "co_teacher"
+	StaffRoleAssistant = "assistant"
+)
+
+// Staff membership statuses
+const (
+	StaffStatusInvited = "invited"
+	StaffStatusActive  = "active"
+)
+
+// Permissions granted to staff roles
+const (
+	// PermissionView allows reading the classroom, its students and its history
+	PermissionView = "view"
+	// PermissionSendNeurons allows sending neurons from the pool to students
+	PermissionSendNeurons = "send_neurons"
+	// PermissionManageClassroom allows managing students, categories, rewards,
+	// requests and reversals
+	PermissionManageClassroom = "manage_classroom"
+	// PermissionManagePool allows changing the classroom's available neurons
+	PermissionManagePool = "manage_pool"
+	// PermissionDeductNeurons allows taking neurons away from students
+	PermissionDeductNeurons = "deduct_neurons"
+)
+
+// defaultAssistantDailyLimit caps what an assistant can send per day unless the owner sets another limit
+const defaultAssistantDailyLimit = 50
+
+var rolePermissions = map[string]map[string]bool{
+	StaffRoleOwner: {
+		PermissionView:            true,
+		PermissionSendNeurons:     true,
+		PermissionManageClassroom: true,
+		PermissionManagePool:      true,
+		PermissionDeductNeurons:   true,
+	},
+	StaffRoleCoTeacher: {
+		PermissionView:            true,
+		PermissionSendNeurons:     true,
+		PermissionManageClassroom: true,
+		PermissionManagePool:      true,
+		PermissionDeductNeurons:   true,
+	},
+	StaffRoleAssistant: {
+		PermissionView:        true,
+		PermissionSendNeurons: true,
+	},
+}
+
+// ErrDailySendLimitReached is returned when an assistant tries to send more than their daily allowance
+var ErrDailySendLimitReached = errors.ErrForbidden("daily send limit reached")
+
+// StaffMember is a teacher who helps run a classroom
+type StaffMember struct {
+	ClassroomID int64  `json:"classroom_id" db:"classroom_id"`
+	UserID      int64  `json:"user_id" db:"user_id"`
+	Role        string `json:"role" db:"role"`
+	Status      string `json:"status" db:"status"`
+	// DailySendLimit caps the neurons the member can send per day; nil means no cap
+	DailySendLimit *int       `json:"daily_send_limit,omitempty" db:"daily_send_limit"`
+	InvitedBy      *int64     `json:"invited_by,omitempty" db:"invited_by"`
+	JoinedAt       *time.Time `json:"joined_at,omitempty" db:"joined_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	User           user.User  `json:"user" db:"user"`
+}
+
+// Can reports whether the member's role grants the permission
+func (m *StaffMember) Can(permission string) bool {
+	return m.Status == StaffStatusActive && rolePermissions[m.Role][permission]
+}
+
+func (m *StaffMember) validate() error {
+	switch m.Role {
+	case StaffRoleCoTeacher, StaffRoleAssistant:
+	case StaffRoleOwner:
+		return errors.ErrBadRequest("use ownership transfer to make someone the owner")
+	default:
+		return errors.ErrBadRequest("invalid staff role")
+	}
+	if m.DailySendLimit != nil && *m.DailySendLimit < 0 {
+		return errors.ErrBadRequest("daily send limit cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package classroom
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// staffInput is the editable part of a staff membership
+type staffInput struct {
+	Role           string `json:"role"`
+	DailySendLimit *int   `json:"daily_send_limit"`
+}
+
+func (h *Handler) ListStaff(c *fiber.Ctx) error {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	staff, err := h.service.ListStaff(c.Context(), classroomID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(staff)
+}
+
+func (h *Handler) InviteStaffMember(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		UserID int64 `json:"user_id"`
+		staffInput
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	member, err := h.service.InviteStaffMember(c.Context(), u.ID, &StaffMember{
+		ClassroomID:    classroomID,
+		UserID:         input.UserID,
+		Role:           input.Role,
+		DailySendLimit: input.DailySendLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(member)
+}
+
+func (h *Handler) AcceptStaffInvitation(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	member, err := h.service.AcceptStaffInvitation(c.Context(), classroomID, u.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(member)
+}
+
+func (h *Handler) UpdateStaffMember(c *fiber.Ctx) error {
+	classroomID, userID, err := staffMemberParams(c)
+	if err != nil {
+		return err
+	}
+
+	var input staffInput
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	member, err := h.service.UpdateStaffMember(c.Context(), &StaffMember{
+		ClassroomID:    classroomID,
+		UserID:         userID,
+		Role:           input.Role,
+		DailySendLimit: input.DailySendLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(member)
+}
+
+func (h *Handler) RemoveStaffMember(c *fiber.Ctx) error {
+	classroomID, userID, err := staffMemberParams(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.RemoveStaffMember(c.Context(), classroomID, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) TransferOwnership(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid classroom id")
+	}
+
+	var input struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	classroom, err := h.service.TransferOwnership(c.Context(), classroomID, u.ID, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(classroom)
+}
+
+func staffMemberParams(c *fiber.Ctx) (int64, int64, error) {
+	classroomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid classroom id")
+	}
+
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.ErrBadRequest("invalid user id")
+	}
+
+	return classroomID, userID, nil
+}
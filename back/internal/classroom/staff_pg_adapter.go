@@ -0,0 +1,266 @@
+package classroom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+const staffMemberQuery = `
+	SELECT cs.classroom_id, cs.user_id, cs.role, cs.status, cs.daily_send_limit, cs.invited_by, cs.joined_at, cs.created_at,
+		   u.id AS "user.id", u.name AS "user.name", u.email AS "user.email",
+		   u.role AS "user.role", u.created_at AS "user.created_at"
+	FROM classroom_staff cs
+	JOIN users u ON u.id = cs.user_id
+`
+
+// addClassroomOwner records the teacher who created the classroom as its owner
+func addClassroomOwner(ctx context.Context, tx *database.Tx, classroomID, teacherID int64, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO classroom_staff (classroom_id, user_id, role, status, joined_at, created_at)
+		VALUES ($1, $2, 'owner', 'active', $3, $3)
+	`, classroomID, teacherID, at)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to add classroom owner: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetStaffMember(ctx context.Context, classroomID, userID int64) (*StaffMember, error) {
+	var member StaffMember
+	err := r.db.GetContext(ctx, &member, staffMemberQuery+"WHERE cs.classroom_id = $1 AND cs.user_id = $2", classroomID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("staff member not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get staff member: %v", err))
+	}
+	return &member, nil
+}
+
+func (r *PostgresRepository) ListStaff(ctx context.Context, classroomID int64) ([]*StaffMember, error) {
+	members := []*StaffMember{}
+	err := r.db.SelectContext(ctx, &members, staffMemberQuery+"WHERE cs.classroom_id = $1 ORDER BY cs.created_at, cs.user_id", classroomID)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list staff: %v", err))
+	}
+	return members, nil
+}
+
+// HasClassroomPermission checks whether the user is active staff whose role grants the permission
+func (r *PostgresRepository) HasClassroomPermission(ctx context.Context, classroomID, userID int64, permission string) (bool, error) {
+	var member StaffMember
+	err := r.db.GetContext(ctx, &member, `
+		SELECT role, status FROM classroom_staff WHERE classroom_id = $1 AND user_id = $2
+	`, classroomID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, errors.ErrDatabase(fmt.Sprintf("failed to check staff permission: %v", err))
+	}
+	return member.Can(permission), nil
+}
+
+func (r *PostgresRepository) InviteStaffMember(ctx context.Context, member *StaffMember) error {
+	_, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO classroom_staff (classroom_id, user_id, role, status, daily_send_limit, invited_by, created_at)
+		VALUES (:classroom_id, :user_id, :role, :status, :daily_send_limit, :invited_by, :created_at)
+	`, member)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errors.ErrConflict("user is already on this classroom's staff")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to invite staff member: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) AcceptStaffInvitation(ctx context.Context, classroomID, userID int64, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE classroom_staff
+		SET status = 'active', joined_at = $3
+		WHERE classroom_id = $1 AND user_id = $2 AND status = 'invited'
+	`, classroomID, userID, at)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to accept staff invitation: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrNotFound("staff invitation not found")
+	}
+	return nil
+}
+
+// UpdateStaffMember changes a member's role and daily limit; the owner can't be changed this way
+func (r *PostgresRepository) UpdateStaffMember(ctx context.Context, member *StaffMember) error {
+	res, err := r.db.NamedExecContext(ctx, `
+		UPDATE classroom_staff
+		SET role = :role, daily_send_limit = :daily_send_limit
+		WHERE classroom_id = :classroom_id AND user_id = :user_id AND role <> 'owner'
+	`, member)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update staff member: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrNotFound("staff member not found")
+	}
+	return nil
+}
+
+func (r *PostgresRepository) RemoveStaffMember(ctx context.Context, classroomID, userID int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM classroom_staff
+		WHERE classroom_id = $1 AND user_id = $2 AND role <> 'owner'
+	`, classroomID, userID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to remove staff member: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrNotFound("staff member not found")
+	}
+	return nil
+}
+
+// TransferOwnership makes an active staff member the owner; the previous owner
+// stays on as a co-teacher
+func (r *PostgresRepository) TransferOwnership(ctx context.Context, classroomID, fromID, toID int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var teacherID int64
+	err = tx.GetContext(ctx, &teacherID, "SELECT teacher_id FROM classrooms WHERE id = $1 FOR UPDATE", classroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("classroom not found")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock classroom: %v", err))
+	}
+	if teacherID != fromID {
+		return errors.ErrForbidden("only the owner can transfer the classroom")
+	}
+
+	// Demote first: a classroom can only have one owner at a time
+	_, err = tx.ExecContext(ctx, `
+		UPDATE classroom_staff SET role = 'co_teacher'
+		WHERE classroom_id = $1 AND user_id = $2
+	`, classroomID, fromID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to demote previous owner: %v", err))
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE classroom_staff
+		SET role = 'owner', daily_send_limit = NULL
+		WHERE classroom_id = $1 AND user_id = $2 AND status = 'active'
+	`, classroomID, toID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to promote new owner: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrBadRequest("the new owner must be an active staff member")
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE classrooms SET teacher_id = $2 WHERE id = $1", classroomID, toID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to transfer classroom: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// CheckDailySendLimit verifies that sending the amount keeps the staff member
+// within their daily limit. It must run in the transaction that records the
+// send: the member's row stays locked until it commits, so their concurrent
+// sends are counted one after the other.
+func (r *PostgresRepository) CheckDailySendLimit(ctx context.Context, classroomID, userID int64, amount int, since time.Time) error {
+	var limit *int
+	err := r.db.GetContext(ctx, &limit, `
+		SELECT daily_send_limit FROM classroom_staff
+		WHERE classroom_id = $1 AND user_id = $2 AND status = 'active'
+		FOR UPDATE
+	`, classroomID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrForbidden("teacher is not on this classroom's staff")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock staff member: %v", err))
+	}
+	if limit == nil {
+		return nil
+	}
+
+	var sent int
+	err = r.db.GetContext(ctx, &sent, `
+		SELECT COALESCE(SUM(amount), 0) FROM neuron_transactions
+		WHERE classroom_id = $1 AND created_by = $2 AND transaction_type IN ('assignment', 'group_funding') AND created_at >= $3
+	`, classroomID, userID, since)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to sum sent neurons: %v", err))
+	}
+	if sent+amount > *limit {
+		return ErrDailySendLimitReached
+	}
+	return nil
+}
+
+// ReassignClassroom makes the teacher the owner of the classroom, adding them to
+// its staff if needed; the previous owner stays on as a co-teacher
+func (r *PostgresRepository) ReassignClassroom(ctx context.Context, classroomID, teacherID int64, at time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var ownerID int64
+	err = tx.GetContext(ctx, &ownerID, "SELECT teacher_id FROM classrooms WHERE id = $1 FOR UPDATE", classroomID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("classroom not found")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to lock classroom: %v", err))
+	}
+	if ownerID == teacherID {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE classroom_staff SET role = 'co_teacher'
+		WHERE classroom_id = $1 AND user_id = $2
+	`, classroomID, ownerID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to demote previous owner: %v", err))
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO classroom_staff (classroom_id, user_id, role, status, joined_at, created_at)
+		VALUES ($1, $2, 'owner', 'active', $3, $3)
+		ON CONFLICT (classroom_id, user_id) DO UPDATE
+		SET role = 'owner', status = 'active', daily_send_limit = NULL,
+			joined_at = COALESCE(classroom_staff.joined_at, EXCLUDED.joined_at)
+	`, classroomID, teacherID, at)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to add new owner: %v", err))
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE classrooms SET teacher_id = $2 WHERE id = $1", classroomID, teacherID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to reassign classroom: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package classroom
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentSendsCannotExceedDailyLimit(t *testing.T) {
+	ctx := context.Background()
+	f := newTransferFixture(t, 1000, 0)
+
+	assistantID := createTestUser(t, f.db, "assistant", "teacher")
+	_, err := f.db.Exec(`
+		INSERT INTO classroom_staff (classroom_id, user_id, role, status, daily_send_limit, joined_at)
+		VALUES ($1, $2, 'assistant', 'active', 50, now())
+	`, f.classroomID, assistantID)
+	if err != nil {
+		t.Fatalf("failed to add assistant: %v", err)
+	}
+
+	since := time.Now().UTC().Truncate(24 * time.Hour)
+	var mu sync.Mutex
+	sent, limited := 0, 0
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			transaction := f.transaction(TransactionTypeAssignment, 5)
+			transaction.CreatedBy = &assistantID
+			err := f.repo.db.WithTx(ctx, func(ctx context.Context) error {
+				if err := f.repo.CheckDailySendLimit(ctx, f.classroomID, assistantID, transaction.Amount, since); err != nil {
+					return err
+				}
+				return f.repo.TransferNeurons(ctx, transaction)
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				sent++
+			case ErrDailySendLimitReached:
+				limited++
+			default:
+				t.Errorf("unexpected error %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if sent != 10 || limited != 10 {
+		t.Errorf("expected 10 sends within the limit and 10 over it, got %d and %d", sent, limited)
+	}
+	if _, wallet := f.balances(t); wallet != 50 {
+		t.Errorf("expected the student to receive 50, got %d", wallet)
+	}
+}
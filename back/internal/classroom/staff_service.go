@@ -0,0 +1,206 @@
+package classroom
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// ListStaff retrieves the owner, co-teachers and assistants of a classroom
+func (s *Service) ListStaff(ctx context.Context, classroomID int64) ([]*StaffMember, error) {
+	return s.repo.ListStaff(ctx, classroomID)
+}
+
+// InviteStaffMember invites a teacher to help run the classroom; they join once they accept
+func (s *Service) InviteStaffMember(ctx context.Context, ownerID int64, member *StaffMember) (*StaffMember, error) {
+	if err := member.validate(); err != nil {
+		return nil, err
+	}
+
+	// Verify that the invited user exists and is a teacher
+	invitee, err := s.userService.GetUser(ctx, member.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if invitee.Role != "teacher" {
+		return nil, errors.ErrBadRequest("only teachers can join a classroom's staff")
+	}
+
+	// Verify that the invitee belongs to the classroom's organization
+	classroom, err := s.repo.GetClassroom(ctx, member.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+	if classroom.OrganizationID != invitee.OrganizationID {
+		return nil, errors.ErrNotFound("user not found")
+	}
+
+	if member.Role == StaffRoleAssistant && member.DailySendLimit == nil {
+		limit := defaultAssistantDailyLimit
+		member.DailySendLimit = &limit
+	}
+	member.Status = StaffStatusInvited
+	member.InvitedBy = &ownerID
+	member.CreatedAt = time.Now()
+	return s.changeStaffMember(ctx, ActionStaffInvited, member.ClassroomID, member.UserID, func(ctx context.Context) error {
+		return s.repo.InviteStaffMember(ctx, member)
+	})
+}
+
+// AcceptStaffInvitation makes an invited teacher an active staff member
+func (s *Service) AcceptStaffInvitation(ctx context.Context, classroomID, userID int64) (*StaffMember, error) {
+	return s.changeStaffMember(ctx, ActionStaffInvitationAccepted, classroomID, userID, func(ctx context.Context) error {
+		return s.repo.AcceptStaffInvitation(ctx, classroomID, userID, time.Now())
+	})
+}
+
+// UpdateStaffMember changes a staff member's role or daily send limit
+func (s *Service) UpdateStaffMember(ctx context.Context, member *StaffMember) (*StaffMember, error) {
+	if err := member.validate(); err != nil {
+		return nil, err
+	}
+
+	if member.Role == StaffRoleAssistant && member.DailySendLimit == nil {
+		limit := defaultAssistantDailyLimit
+		member.DailySendLimit = &limit
+	}
+	return s.changeStaffMember(ctx, ActionStaffUpdated, member.ClassroomID, member.UserID, func(ctx context.Context) error {
+		return s.repo.UpdateStaffMember(ctx, member)
+	})
+}
+
+// RemoveStaffMember removes a co-teacher or assistant, or withdraws their invitation
+func (s *Service) RemoveStaffMember(ctx context.Context, classroomID, userID int64) error {
+	_, err := s.changeStaffMember(ctx, ActionStaffRemoved, classroomID, userID, func(ctx context.Context) error {
+		return s.repo.RemoveStaffMember(ctx, classroomID, userID)
+	})
+	return err
+}
+
+// TransferOwnership hands the classroom over to another active staff member
+func (s *Service) TransferOwnership(ctx context.Context, classroomID, ownerID, newOwnerID int64) (*ClassroomWithData, error) {
+	if ownerID == newOwnerID {
+		return nil, errors.ErrBadRequest("you already own this classroom")
+	}
+
+	var classroom *ClassroomWithData
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.GetClassroom(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.repo.TransferOwnership(ctx, classroomID, ownerID, newOwnerID)
+		if err != nil {
+			return nil, err
+		}
+
+		classroom, err = s.repo.GetClassroom(ctx, classroomID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, ActionOwnershipTransferred, "classroom", classroomID, before.Classroom, classroom.Classroom), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return classroom, nil
+}
+
+// changeStaffMember applies a change to a staff member and records their
+// membership before and after it in the audit log. The member returned is nil
+// once they are removed.
+func (s *Service) changeStaffMember(ctx context.Context, action string, classroomID, userID int64, change func(ctx context.Context) error) (*StaffMember, error) {
+	var after *StaffMember
+	err := s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.findStaffMember(ctx, classroomID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = change(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err = s.findStaffMember(ctx, classroomID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return classroomChange(classroomID, action, "user", userID, before, after), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// findStaffMember is GetStaffMember returning nil for users not on the staff
+func (s *Service) findStaffMember(ctx context.Context, classroomID, userID int64) (*StaffMember, error) {
+	member, err := s.repo.GetStaffMember(ctx, classroomID, userID)
+	if apiErr, ok := err.(errors.ApiError); ok && apiErr.Type == "NotFound" {
+		return nil, nil
+	}
+	return member, err
+}
+
+// checkStaffPermission verifies that the user is active staff whose role grants the permission
+func (s *Service) checkStaffPermission(ctx context.Context, classroomID, userID int64, permission string) error {
+	allowed, err := s.repo.HasClassroomPermission(ctx, classroomID, userID, permission)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.ErrForbidden("teacher is not allowed to do this in this classroom")
+	}
+	return nil
+}
+
+// checkSendAllowance verifies that the teacher may send the amount today.
+// Daily limits reset at midnight UTC. It must be called inside the
+// transaction that records the send so concurrent sends can't each pass the
+// limit.
+func (s *Service) checkSendAllowance(ctx context.Context, classroomID, teacherID int64, amount int) error {
+	member, err := s.repo.GetStaffMember(ctx, classroomID, teacherID)
+	if err != nil {
+		if apiErr, ok := err.(errors.ApiError); ok && apiErr.Type == "NotFound" {
+			return errors.ErrForbidden("teacher is not on this classroom's staff")
+		}
+		return err
+	}
+	if !member.Can(PermissionSendNeurons) {
+		return errors.ErrForbidden("teacher is not allowed to send neurons in this classroom")
+	}
+
+	return s.repo.CheckDailySendLimit(ctx, classroomID, teacherID, amount, time.Now().UTC().Truncate(24*time.Hour))
+}
+
+// ReassignClassroom hands a classroom to another teacher of its organization
+// without the current owner's involvement
+func (s *Service) ReassignClassroom(ctx context.Context, classroomID, teacherID int64) (*ClassroomWithData, error) {
+	teacher, err := s.userService.GetUser(ctx, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	if teacher.Role != "teacher" {
+		return nil, errors.ErrBadRequest("user is not a teacher")
+	}
+
+	classroom, err := s.repo.GetClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+	if classroom.OrganizationID != teacher.OrganizationID {
+		return nil, errors.ErrBadRequest("teacher belongs to another organization")
+	}
+
+	err = s.repo.ReassignClassroom(ctx, classroomID, teacherID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetClassroom(ctx, classroomID)
+}
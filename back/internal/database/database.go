@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// DB wraps a connection pool so that queries run inside the transaction
+// carried by the context, if there is one. This lets a service make changes
+// through several repositories and commit them together.
+type DB struct {
+	db *sqlx.DB
+}
+
+// New wraps a connection pool
+func New(db *sqlx.DB) *DB {
+	return &DB{db: db}
+}
+
+// Tx is a transaction started by BeginTxx. When it joins a transaction that
+// is already in the context, Commit and Rollback are left to its owner.
+type Tx struct {
+	*sqlx.Tx
+	joined bool
+}
+
+// Commit commits the transaction unless it belongs to an outer WithTx
+func (t *Tx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Rollback aborts the transaction unless it belongs to an outer WithTx, in
+// which case the error returned to WithTx rolls it back
+func (t *Tx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+type conn interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+func (d *DB) conn(ctx context.Context) conn {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return tx.Tx
+	}
+	return d.db
+}
+
+// WithTx runs fn in a transaction that every query made with its context
+// joins. The transaction is committed if fn succeeds and rolled back
+// otherwise. Nested calls run in the outermost transaction.
+func (d *DB) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	err = fn(context.WithValue(ctx, txKey{}, &Tx{Tx: tx}))
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+// BeginTxx starts a transaction, or joins the one in the context
+func (d *DB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return &Tx{Tx: tx.Tx, joined: true}, nil
+	}
+
+	tx, err := d.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+func (d *DB) DriverName() string {
+	return d.db.DriverName()
+}
+
+func (d *DB) Rebind(query string) string {
+	return d.db.Rebind(query)
+}
+
+func (d *DB) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return d.db.BindNamed(query, arg)
+}
+
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.conn(ctx).QueryContext(ctx, query, args...)
+}
+
+func (d *DB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return d.conn(ctx).QueryxContext(ctx, query, args...)
+}
+
+func (d *DB) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return d.conn(ctx).QueryRowxContext(ctx, query, args...)
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.conn(ctx).ExecContext(ctx, query, args...)
+}
+
+func (d *DB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return d.conn(ctx).GetContext(ctx, dest, query, args...)
+}
+
+func (d *DB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return d.conn(ctx).SelectContext(ctx, dest, query, args...)
+}
+
+func (d *DB) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return d.conn(ctx).NamedExecContext(ctx, query, arg)
+}
+
+func (d *DB) NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error) {
+	return sqlx.NamedQueryContext(ctx, d.conn(ctx), query, arg)
+}
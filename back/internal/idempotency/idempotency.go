@@ -0,0 +1,31 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// HeaderName is the request header clients use to make a request safe to retry
+const HeaderName = "Idempotency-Key"
+
+// ErrFingerprintMismatch is returned when a key is reused with a different request
+var ErrFingerprintMismatch = errors.NewApiError("FingerprintMismatch", "idempotency key was already used with a different request")
+
+// Record stores the outcome of the first request made with an idempotency key.
+// ResponseStatus is nil while that request is still being processed.
+type Record struct {
+	UserID         string    `json:"user_id" db:"user_id"`
+	Key            string    `json:"key" db:"key"`
+	Fingerprint    string    `json:"fingerprint" db:"fingerprint"`
+	ResponseStatus *int      `json:"response_status" db:"response_status"`
+	ContentType    string    `json:"content_type" db:"content_type"`
+	ResponseBody   []byte    `json:"response_body" db:"response_body"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// Completed reports whether the stored response can be replayed
+func (r *Record) Completed() bool {
+	return r.ResponseStatus != nil
+}
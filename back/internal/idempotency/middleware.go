@@ -0,0 +1,70 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/Abraxas-365/toolkit/pkg/lucia"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware makes a route safe to retry with the Idempotency-Key header. The
+// first successful response for a user and key is stored and replayed to later
+// requests with the same key; reusing a key for a different request is a 422.
+// Requests without the header are passed through unchanged.
+func Middleware(service Servicer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderName)
+		if key == "" {
+			return c.Next()
+		}
+		session := lucia.GetSession(c)
+		if session == nil {
+			return errors.ErrUnauthorized("No valid session found")
+		}
+
+		record, err := service.Begin(c.Context(), session.UserID, key, fingerprint(c))
+		if err == ErrFingerprintMismatch {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": ErrFingerprintMismatch.Message})
+		}
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, record.ContentType)
+			return c.Status(*record.ResponseStatus).Send(record.ResponseBody)
+		}
+
+		// Only successful responses are stored; failed requests free the key for a retry
+		if err := c.Next(); err != nil {
+			if releaseErr := service.Release(c.Context(), session.UserID, key); releaseErr != nil {
+				return releaseErr
+			}
+			return err
+		}
+
+		// The request already took effect, so a storage failure must not fail it;
+		// the key stays reserved until it expires and retries get a conflict
+		response := c.Response()
+		err = service.Complete(c.Context(), session.UserID, key, response.StatusCode(),
+			string(response.Header.ContentType()), response.Body())
+		if err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+		}
+		return nil
+	}
+}
+
+// fingerprint identifies the request a key was first used for
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+type PostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+func (r *PostgresRepository) Reserve(ctx context.Context, record *Record) (*Record, error) {
+	// Take over the key only if it is new or its previous record has expired
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, created_at, expires_at)
+		VALUES (:user_id, :key, :fingerprint, :created_at, :expires_at)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, response_status = NULL, content_type = '',
+			response_body = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < EXCLUDED.created_at
+	`
+	res, err := r.db.NamedExecContext(ctx, query, record)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to reserve idempotency key: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+
+	var existing Record
+	err = r.db.GetContext(ctx, &existing, `
+		SELECT user_id, key, fingerprint, response_status, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`, record.UserID, record.Key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrConflict("idempotency key was released, retry the request")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get idempotency key: %v", err))
+	}
+	return &existing, nil
+}
+
+func (r *PostgresRepository) Complete(ctx context.Context, userID, key string, status int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response_status = $1, content_type = $2, response_body = $3
+		WHERE user_id = $4 AND key = $5
+	`
+	_, err := r.db.ExecContext(ctx, query, status, contentType, body, userID, key)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to complete idempotency key: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) Release(ctx context.Context, userID, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND response_status IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, key)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to release idempotency key: %v", err))
+	}
+	return nil
+}
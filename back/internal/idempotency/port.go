@@ -0,0 +1,18 @@
+package idempotency
+
+import (
+	"context"
+)
+
+// DBRepository defines the interface for idempotency key storage
+type DBRepository interface {
+	// Reserve claims the record's key for the user. It returns nil if the key was
+	// free or expired, or the record currently holding the key otherwise.
+	Reserve(ctx context.Context, record *Record) (*Record, error)
+
+	// Complete stores the response of the request holding the key
+	Complete(ctx context.Context, userID, key string, status int, contentType string, body []byte) error
+
+	// Release frees a key whose request did not complete
+	Release(ctx context.Context, userID, key string) error
+}
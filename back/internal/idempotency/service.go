@@ -0,0 +1,72 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// keyTTL is how long a stored response is replayed for
+const keyTTL = 24 * time.Hour
+
+// Servicer defines the interface for idempotency key operations
+type Servicer interface {
+	Begin(ctx context.Context, userID, key, fingerprint string) (*Record, error)
+	Complete(ctx context.Context, userID, key string, status int, contentType string, body []byte) error
+	Release(ctx context.Context, userID, key string) error
+}
+
+// Ensure Service implements Servicer
+var _ Servicer = (*Service)(nil)
+
+// Service implements the Servicer interface
+type Service struct {
+	repo DBRepository
+}
+
+// NewService creates a new idempotency service
+func NewService(repo DBRepository) *Service {
+	return &Service{repo: repo}
+}
+
+// Begin claims the key for a new request. It returns nil if the request should
+// be processed, or the completed record whose response must be replayed.
+func (s *Service) Begin(ctx context.Context, userID, key, fingerprint string) (*Record, error) {
+	if len(key) > 255 {
+		return nil, errors.ErrBadRequest("idempotency key is too long")
+	}
+
+	now := time.Now()
+	existing, err := s.repo.Reserve(ctx, &Record{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(keyTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+	if !existing.Completed() {
+		return nil, errors.ErrConflict("a request with this idempotency key is still being processed")
+	}
+	return existing, nil
+}
+
+// Complete stores the response to replay for the key
+func (s *Service) Complete(ctx context.Context, userID, key string, status int, contentType string, body []byte) error {
+	return s.repo.Complete(ctx, userID, key, status, contentType, body)
+}
+
+// Release frees the key so the request can be retried
+func (s *Service) Release(ctx context.Context, userID, key string) error {
+	return s.repo.Release(ctx, userID, key)
+}
@@ -0,0 +1,99 @@
+package organization
+
+import (
+	"strconv"
+
+	"github.com/Abraxas-365/neurons/internal/authz"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	service Servicer
+	policy  *authz.Policy
+}
+
+func NewHandler(service Servicer, policy *authz.Policy) *Handler {
+	return &Handler{
+		service: service,
+		policy:  policy,
+	}
+}
+
+func (h *Handler) RegisterRoutes(app *fiber.App) {
+	orgGroup := app.Group("/organization")
+
+	// Routes act on the current user's organization
+	orgGroup.Use(h.policy.Authenticate)
+	orgGroup.Get("/", authz.Require(authz.Authenticated), h.GetOrganization)
+	orgGroup.Put("/", authz.Require(authz.OrgAdmin), h.UpdateOrganization)
+	orgGroup.Get("/members", authz.Require(authz.OrgAdmin), h.ListMembers)
+	orgGroup.Put("/members/:userId/admin", authz.Require(authz.OrgAdmin), h.SetOrgAdmin)
+}
+
+func (h *Handler) GetOrganization(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	org, err := h.service.GetOrganization(c.Context(), u.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(org)
+}
+
+func (h *Handler) UpdateOrganization(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	var input Settings
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	org, err := h.service.UpdateOrganization(c.Context(), u.OrganizationID, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(org)
+}
+
+func (h *Handler) ListMembers(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	members, err := h.service.ListMembers(c.Context(), u.OrganizationID, c.Query("role"), limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(members)
+}
+
+func (h *Handler) SetOrgAdmin(c *fiber.Ctx) error {
+	u := authz.CurrentUser(c)
+
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return errors.ErrBadRequest("invalid user id")
+	}
+	if userID == u.ID {
+		return errors.ErrBadRequest("you cannot change your own admin rights")
+	}
+
+	var input struct {
+		OrgAdmin bool `json:"org_admin"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errors.ErrBadRequest("invalid input")
+	}
+
+	err = h.service.SetOrgAdmin(c.Context(), u.OrganizationID, userID, input.OrgAdmin)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
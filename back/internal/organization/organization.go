@@ -0,0 +1,56 @@
+package organization
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/lib/pq"
+)
+
+// Organization is a school that owns its users and classrooms
+type Organization struct {
+	ID   int64  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// DefaultClassroomNeurons is the opening pool of every new classroom
+	DefaultClassroomNeurons int `json:"default_classroom_neurons" db:"default_classroom_neurons"`
+	// AllowedEmailDomains restricts sign-ups to these domains and places new users
+	// with them in the organization; empty means no restriction
+	AllowedEmailDomains pq.StringArray `json:"allowed_email_domains" db:"allowed_email_domains"`
+	CreatedAt           time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Settings is the editable part of an organization
+type Settings struct {
+	Name                    string   `json:"name"`
+	DefaultClassroomNeurons int      `json:"default_classroom_neurons"`
+	AllowedEmailDomains     []string `json:"allowed_email_domains"`
+}
+
+// apply validates the settings and copies them onto the organization
+func (s Settings) apply(org *Organization) error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.ErrBadRequest("name is required")
+	}
+	if s.DefaultClassroomNeurons < 0 {
+		return errors.ErrBadRequest("default classroom neurons cannot be negative")
+	}
+
+	domains := make([]string, 0, len(s.AllowedEmailDomains))
+	seen := make(map[string]bool, len(s.AllowedEmailDomains))
+	for _, domain := range s.AllowedEmailDomains {
+		domain = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(domain, "@")))
+		if domain == "" || strings.ContainsAny(domain, "@ ") || !strings.Contains(domain, ".") {
+			return errors.ErrBadRequest("invalid email domain: " + domain)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+
+	org.Name = strings.TrimSpace(s.Name)
+	org.DefaultClassroomNeurons = s.DefaultClassroomNeurons
+	org.AllowedEmailDomains = domains
+	return nil
+}
@@ -0,0 +1,151 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Abraxas-365/neurons/internal/database"
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const organizationQuery = `
+	SELECT o.id, o.name, o.default_classroom_neurons, o.created_at,
+		   ARRAY(SELECT d.domain FROM organization_email_domains d WHERE d.organization_id = o.id ORDER BY d.domain) AS allowed_email_domains
+	FROM organizations o
+`
+
+type PostgresRepository struct {
+	db *database.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
+	return &PostgresRepository{db: database.New(db)}
+}
+
+func (r *PostgresRepository) CreateOrganization(ctx context.Context, org *Organization) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &org.ID, `
+		INSERT INTO organizations (name, default_classroom_neurons, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, org.Name, org.DefaultClassroomNeurons, org.CreatedAt)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to create organization: %v", err))
+	}
+
+	if err := setEmailDomains(ctx, tx, org); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetOrganization(ctx context.Context, id int64) (*Organization, error) {
+	var org Organization
+	err := r.db.GetContext(ctx, &org, organizationQuery+"WHERE o.id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("organization not found")
+		}
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to get organization: %v", err))
+	}
+	return &org, nil
+}
+
+func (r *PostgresRepository) ListOrganizations(ctx context.Context, limit, offset int) ([]*Organization, error) {
+	orgs := []*Organization{}
+	err := r.db.SelectContext(ctx, &orgs, organizationQuery+"ORDER BY o.id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list organizations: %v", err))
+	}
+	return orgs, nil
+}
+
+func (r *PostgresRepository) UpdateOrganization(ctx context.Context, org *Organization) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE organizations
+		SET name = $2, default_classroom_neurons = $3
+		WHERE id = $1
+	`, org.ID, org.Name, org.DefaultClassroomNeurons)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update organization: %v", err))
+	}
+
+	if err := setEmailDomains(ctx, tx, org); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListMembers(ctx context.Context, organizationID int64, role string, limit, offset int) ([]*user.User, error) {
+	query := `
+		SELECT id, auth_user_id, name, email, role, organization_id, org_admin, created_at
+		FROM users
+		WHERE organization_id = $1 AND ($2 = '' OR role = $2)
+		ORDER BY id
+		LIMIT $3 OFFSET $4
+	`
+	users := []*user.User{}
+	err := r.db.SelectContext(ctx, &users, query, organizationID, role, limit, offset)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to list organization members: %v", err))
+	}
+	return users, nil
+}
+
+func (r *PostgresRepository) SetOrgAdmin(ctx context.Context, organizationID, userID int64, orgAdmin bool) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users SET org_admin = $3
+		WHERE id = $2 AND organization_id = $1
+	`, organizationID, userID, orgAdmin)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to update organization admin: %v", err))
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.ErrNotFound("user not found")
+	}
+	return nil
+}
+
+// setEmailDomains replaces the email domains the organization accepts sign-ups from.
+// A domain can only belong to one organization.
+func setEmailDomains(ctx context.Context, tx *database.Tx, org *Organization) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM organization_email_domains WHERE organization_id = $1", org.ID)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to clear email domains: %v", err))
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO organization_email_domains (domain, organization_id)
+		SELECT UNNEST($2::TEXT[]), $1
+	`, org.ID, pq.Array([]string(org.AllowedEmailDomains)))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errors.ErrConflict("an email domain is already claimed by another organization")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to set email domains: %v", err))
+	}
+	return nil
+}
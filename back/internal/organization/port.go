@@ -0,0 +1,28 @@
+package organization
+
+import (
+	"context"
+
+	"github.com/Abraxas-365/neurons/internal/user"
+)
+
+// DBRepository defines the interface for organization database operations
+type DBRepository interface {
+	// CreateOrganization creates a new organization
+	CreateOrganization(ctx context.Context, org *Organization) error
+
+	// GetOrganization retrieves an organization by its ID
+	GetOrganization(ctx context.Context, id int64) (*Organization, error)
+
+	// ListOrganizations retrieves a page of organizations
+	ListOrganizations(ctx context.Context, limit, offset int) ([]*Organization, error)
+
+	// UpdateOrganization updates an organization's settings
+	UpdateOrganization(ctx context.Context, org *Organization) error
+
+	// ListMembers retrieves the users of an organization, optionally with one role
+	ListMembers(ctx context.Context, organizationID int64, role string, limit, offset int) ([]*user.User, error)
+
+	// SetOrgAdmin grants or revokes a member's organization admin rights
+	SetOrgAdmin(ctx context.Context, organizationID, userID int64, orgAdmin bool) error
+}
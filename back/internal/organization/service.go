@@ -0,0 +1,116 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	"github.com/Abraxas-365/neurons/internal/audit"
+	"github.com/Abraxas-365/neurons/internal/user"
+	"github.com/Abraxas-365/toolkit/pkg/errors"
+)
+
+// Servicer defines the interface for organization-related operations
+type Servicer interface {
+	CreateOrganization(ctx context.Context, settings Settings) (*Organization, error)
+	GetOrganization(ctx context.Context, id int64) (*Organization, error)
+	ListOrganizations(ctx context.Context, limit, offset int) ([]*Organization, error)
+	UpdateOrganization(ctx context.Context, id int64, settings Settings) (*Organization, error)
+	ListMembers(ctx context.Context, organizationID int64, role string, limit, offset int) ([]*user.User, error)
+	SetOrgAdmin(ctx context.Context, organizationID, userID int64, orgAdmin bool) error
+}
+
+// Ensure Service implements Servicer
+var _ Servicer = (*Service)(nil)
+
+// Service implements the Servicer interface
+type Service struct {
+	repo         DBRepository
+	auditService audit.Servicer
+}
+
+// Audit log actions performed by organization admins
+const (
+	ActionOrganizationUpdated = "organization.updated"
+	ActionOrgAdminChanged     = "organization.admin_changed"
+)
+
+// NewService creates a new organization service
+func NewService(repo DBRepository, auditService audit.Servicer) *Service {
+	return &Service{repo: repo, auditService: auditService}
+}
+
+// CreateOrganization creates a new school
+func (s *Service) CreateOrganization(ctx context.Context, settings Settings) (*Organization, error) {
+	org := &Organization{CreatedAt: time.Now()}
+	if err := settings.apply(org); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.CreateOrganization(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// GetOrganization retrieves an organization by ID
+func (s *Service) GetOrganization(ctx context.Context, id int64) (*Organization, error) {
+	return s.repo.GetOrganization(ctx, id)
+}
+
+// ListOrganizations retrieves a list of organizations
+func (s *Service) ListOrganizations(ctx context.Context, limit, offset int) ([]*Organization, error) {
+	return s.repo.ListOrganizations(ctx, limit, offset)
+}
+
+// UpdateOrganization changes an organization's name and settings
+func (s *Service) UpdateOrganization(ctx context.Context, id int64, settings Settings) (*Organization, error) {
+	org, err := s.repo.GetOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *org
+	if err := settings.apply(org); err != nil {
+		return nil, err
+	}
+
+	err = s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.UpdateOrganization(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: ActionOrganizationUpdated, TargetType: "organization", TargetID: id, Before: before, After: org}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+// ListMembers retrieves the users of an organization
+func (s *Service) ListMembers(ctx context.Context, organizationID int64, role string, limit, offset int) ([]*user.User, error) {
+	switch role {
+	case "", "teacher", "student":
+	default:
+		return nil, errors.ErrBadRequest("invalid role")
+	}
+	return s.repo.ListMembers(ctx, organizationID, role, limit, offset)
+}
+
+// SetOrgAdmin grants or revokes a member's organization admin rights
+func (s *Service) SetOrgAdmin(ctx context.Context, organizationID, userID int64, orgAdmin bool) error {
+	return s.auditService.Track(ctx, func(ctx context.Context) (*audit.Change, error) {
+		err := s.repo.SetOrgAdmin(ctx, organizationID, userID, orgAdmin)
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Change{
+			Action:     ActionOrgAdminChanged,
+			TargetType: "user",
+			TargetID:   userID,
+			After:      map[string]bool{"org_admin": orgAdmin},
+		}, nil
+	})
+}
@@ -4,25 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/Abraxas-365/neurons/internal/database"
 	"github.com/Abraxas-365/toolkit/pkg/errors"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
 type PostgresRepository struct {
-	db *sqlx.DB
+	db *database.DB
 }
 
 func NewPostgresRepository(db *sqlx.DB) *PostgresRepository {
-	return &PostgresRepository{db: db}
+	return &PostgresRepository{db: database.New(db)}
 }
 
 func (r *PostgresRepository) CreateUser(ctx context.Context, user *User) error {
 	query := `
-		INSERT INTO users (name, email, role, created_at)
-		VALUES (:name, :email, :role, :created_at)
-		RETURNING id
+		INSERT INTO users (name, email, role, organization_id, created_at)
+		VALUES (:name, :email, :role, NULLIF(:organization_id, 0), :created_at)
+		RETURNING id, organization_id
 	`
 	rows, err := r.db.NamedQueryContext(ctx, query, user)
 	if err != nil {
@@ -31,7 +34,7 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, user *User) error {
 	defer rows.Close()
 
 	if rows.Next() {
-		err = rows.Scan(&user.ID)
+		err = rows.Scan(&user.ID, &user.OrganizationID)
 		if err != nil {
 			return errors.ErrDatabase(fmt.Sprintf("failed to scan user ID: %v", err))
 		}
@@ -41,7 +44,7 @@ func (r *PostgresRepository) CreateUser(ctx context.Context, user *User) error {
 
 func (r *PostgresRepository) GetUser(ctx context.Context, id int64) (*User, error) {
 	query := `
-		SELECT id, name, email, role, created_at
+		SELECT id, auth_user_id, name, email, role, organization_id, org_admin, deactivated_at, created_at
 		FROM users
 		WHERE id = $1
 	`
@@ -58,7 +61,7 @@ func (r *PostgresRepository) GetUser(ctx context.Context, id int64) (*User, erro
 
 func (r *PostgresRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, name, email, role, created_at
+		SELECT id, auth_user_id, name, email, role, organization_id, org_admin, deactivated_at, created_at
 		FROM users
 		WHERE email = $1
 	`
@@ -97,7 +100,7 @@ func (r *PostgresRepository) DeleteUser(ctx context.Context, id int64) error {
 
 func (r *PostgresRepository) ListUsers(ctx context.Context, limit, offset int) ([]*User, error) {
 	query := `
-		SELECT id, name, email, role, created_at
+		SELECT id, auth_user_id, name, email, role, organization_id, org_admin, deactivated_at, created_at
 		FROM users
 		ORDER BY id
 		LIMIT $1 OFFSET $2
@@ -112,7 +115,7 @@ func (r *PostgresRepository) ListUsers(ctx context.Context, limit, offset int) (
 
 func (r *PostgresRepository) ListUsersByRole(ctx context.Context, role string, limit, offset int) ([]*User, error) {
 	query := `
-		SELECT id, name, email, role, created_at
+		SELECT id, auth_user_id, name, email, role, organization_id, org_admin, deactivated_at, created_at
 		FROM users
 		WHERE role = $1
 		ORDER BY id
@@ -161,7 +164,7 @@ func (r *PostgresRepository) CountUsersByRole(ctx context.Context, role string)
 
 func (r *PostgresRepository) GetUserByAuthUserID(ctx context.Context, authUserID string) (*User, error) {
 	query := `
-		SELECT id, auth_user_id, name, email, role, created_at
+		SELECT id, auth_user_id, name, email, role, organization_id, org_admin, deactivated_at, created_at
 		FROM users
 		WHERE auth_user_id = $1
 	`
@@ -175,3 +178,74 @@ func (r *PostgresRepository) GetUserByAuthUserID(ctx context.Context, authUserID
 	}
 	return &user, nil
 }
+
+func (r *PostgresRepository) SearchUsers(ctx context.Context, filter Filter) ([]*User, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.Query != "" {
+		addCondition("(name ILIKE ? OR email ILIKE ?)", "%"+filter.Query+"%")
+	}
+	if filter.Role != "" {
+		addCondition("role = ?", filter.Role)
+	}
+	if filter.OrganizationID != nil {
+		addCondition("organization_id = ?", *filter.OrganizationID)
+	}
+	if filter.Deactivated != nil {
+		addCondition("(deactivated_at IS NOT NULL) = ?", *filter.Deactivated)
+	}
+
+	query := "SELECT id, auth_user_id, name, email, role, organization_id, org_admin, deactivated_at, created_at FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	users := []*User{}
+	err := r.db.SelectContext(ctx, &users, query, args...)
+	if err != nil {
+		return nil, errors.ErrDatabase(fmt.Sprintf("failed to search users: %v", err))
+	}
+	return users, nil
+}
+
+// SetUserDeactivated blocks (non-nil deactivatedAt) or unblocks a user. Blocking
+// also ends the user's open sessions.
+func (r *PostgresRepository) SetUserDeactivated(ctx context.Context, id int64, deactivatedAt *time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var authUserID string
+	err = tx.GetContext(ctx, &authUserID, `
+		UPDATE users SET deactivated_at = $2
+		WHERE id = $1
+		RETURNING auth_user_id
+	`, id, deactivatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("user not found")
+		}
+		return errors.ErrDatabase(fmt.Sprintf("failed to update user: %v", err))
+	}
+
+	if deactivatedAt != nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM user_session WHERE user_id = $1", authUserID)
+		if err != nil {
+			return errors.ErrDatabase(fmt.Sprintf("failed to end user sessions: %v", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.ErrDatabase(fmt.Sprintf("failed to commit transaction: %v", err))
+	}
+	return nil
+}
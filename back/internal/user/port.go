@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"time"
 )
 
 // DBRepository defines the interface for user database operations
@@ -37,4 +38,10 @@ type DBRepository interface {
 	CountUsersByRole(ctx context.Context, role string) (int64, error)
 
 	GetUserByAuthUserID(ctx context.Context, authUserID string) (*User, error)
+
+	// SearchUsers retrieves users matching the filter
+	SearchUsers(ctx context.Context, filter Filter) ([]*User, error)
+
+	// SetUserDeactivated blocks or unblocks a user from signing in
+	SetUserDeactivated(ctx context.Context, id int64, deactivatedAt *time.Time) error
 }
@@ -19,6 +19,9 @@ type Servicer interface {
 	CountUsers(ctx context.Context) (int64, error)
 	CountUsersByRole(ctx context.Context, role string) (int64, error)
 	GetUserByAuthUserID(ctx context.Context, authUserID string) (*User, error)
+	SearchUsers(ctx context.Context, filter Filter) ([]*User, error)
+	DeactivateUser(ctx context.Context, id int64) error
+	ReactivateUser(ctx context.Context, id int64) error
 }
 
 // Ensure Service implements Servicer
@@ -117,6 +120,9 @@ func (s *Service) ChangeUserRole(ctx context.Context, userID int64, newRole stri
 	if newRole == "" {
 		return errors.ErrBadRequest("new role is required")
 	}
+	if !roles[newRole] {
+		return errors.ErrBadRequest("invalid role")
+	}
 	err := s.repo.ChangeUserRole(ctx, userID, newRole)
 	if err != nil {
 		return err
@@ -145,3 +151,25 @@ func (s *Service) CountUsersByRole(ctx context.Context, role string) (int64, err
 func (s *Service) GetUserByAuthUserID(ctx context.Context, authUserID string) (*User, error) {
 	return s.repo.GetUserByAuthUserID(ctx, authUserID)
 }
+
+// SearchUsers retrieves users matching the filter
+func (s *Service) SearchUsers(ctx context.Context, filter Filter) ([]*User, error) {
+	if filter.Role != "" && !roles[filter.Role] {
+		return nil, errors.ErrBadRequest("invalid role")
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+	}
+	return s.repo.SearchUsers(ctx, filter)
+}
+
+// DeactivateUser blocks a user from signing in and ends their sessions
+func (s *Service) DeactivateUser(ctx context.Context, id int64) error {
+	now := time.Now()
+	return s.repo.SetUserDeactivated(ctx, id, &now)
+}
+
+// ReactivateUser lets a deactivated user sign in again
+func (s *Service) ReactivateUser(ctx context.Context, id int64) error {
+	return s.repo.SetUserDeactivated(ctx, id, nil)
+}
@@ -3,10 +3,33 @@ package user
 import "time"
 
 type User struct {
-	ID         int64     `json:"id" db:"id"`
-	Name       string    `json:"name" db:"name"`
-	Email      string    `json:"email" db:"email"`
-	Role       string    `json:"role" db:"role"`
-	AuthUserID string    `json:"auth_user_id" db:"auth_user_id"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID             int64  `json:"id" db:"id"`
+	Name           string `json:"name" db:"name"`
+	Email          string `json:"email" db:"email"`
+	Role           string `json:"role" db:"role"`
+	AuthUserID     string `json:"auth_user_id" db:"auth_user_id"`
+	OrganizationID int64  `json:"organization_id" db:"organization_id"`
+	// OrgAdmin lets the user manage their organization's settings and members
+	OrgAdmin bool `json:"org_admin" db:"org_admin"`
+	// DeactivatedAt is set while the account is blocked from signing in
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty" db:"deactivated_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Roles a user can have
+var roles = map[string]bool{
+	"teacher": true,
+	"student": true,
+	"admin":   true,
+}
+
+// Filter narrows down user searches; zero values are ignored
+type Filter struct {
+	// Query matches the name or email
+	Query          string
+	Role           string
+	OrganizationID *int64
+	Deactivated    *bool
+	Limit          int
+	Offset         int
 }
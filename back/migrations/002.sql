@@ -0,0 +1,98 @@
+-- Create table for Ledger accounts: a mint and a pool per classroom and a wallet per student
+CREATE TABLE ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    user_id INTEGER REFERENCES users(id),
+    account_type VARCHAR(20) NOT NULL CHECK (account_type IN ('mint', 'classroom', 'student')),
+    balance INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((account_type = 'student') = (user_id IS NOT NULL))
+);
+
+-- Create table for immutable journal entries
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    transaction_id INTEGER REFERENCES neuron_transactions(id),
+    description TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create table for the debit (negative) and credit (positive) postings of each entry
+CREATE TABLE ledger_postings (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES ledger_entries(id),
+    account_id INTEGER NOT NULL REFERENCES ledger_accounts(id),
+    amount INTEGER NOT NULL CHECK (amount <> 0)
+);
+
+CREATE UNIQUE INDEX idx_ledger_accounts_owner ON ledger_accounts(classroom_id, account_type, COALESCE(user_id, 0));
+CREATE INDEX idx_ledger_entries_classroom_id ON ledger_entries(classroom_id);
+CREATE INDEX idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);
+CREATE INDEX idx_ledger_postings_entry_id ON ledger_postings(entry_id);
+CREATE INDEX idx_ledger_postings_account_id ON ledger_postings(account_id);
+
+-- Open accounts for existing classrooms and enrolled students
+INSERT INTO ledger_accounts (classroom_id, account_type)
+SELECT id, 'mint' FROM classrooms;
+
+INSERT INTO ledger_accounts (classroom_id, account_type)
+SELECT id, 'classroom' FROM classrooms;
+
+INSERT INTO ledger_accounts (classroom_id, user_id, account_type)
+SELECT classroom_id, user_id, 'student' FROM users_classrooms;
+
+-- Carry the current balances over as one opening entry per classroom funded by its mint
+INSERT INTO ledger_entries (classroom_id, description)
+SELECT id, 'opening balance' FROM classrooms;
+
+INSERT INTO ledger_postings (entry_id, account_id, amount)
+SELECT e.id, a.id, c.available_neurons
+FROM ledger_entries e
+JOIN classrooms c ON c.id = e.classroom_id
+JOIN ledger_accounts a ON a.classroom_id = c.id AND a.account_type = 'classroom'
+WHERE c.available_neurons <> 0;
+
+INSERT INTO ledger_postings (entry_id, account_id, amount)
+SELECT e.id, a.id, uc.neurons
+FROM ledger_entries e
+JOIN users_classrooms uc ON uc.classroom_id = e.classroom_id
+JOIN ledger_accounts a ON a.classroom_id = uc.classroom_id AND a.user_id = uc.user_id AND a.account_type = 'student'
+WHERE uc.neurons <> 0;
+
+INSERT INTO ledger_postings (entry_id, account_id, amount)
+SELECT e.id, a.id, -SUM(p.amount)
+FROM ledger_entries e
+JOIN ledger_accounts a ON a.classroom_id = e.classroom_id AND a.account_type = 'mint'
+JOIN ledger_postings p ON p.entry_id = e.id
+GROUP BY e.id, a.id
+HAVING SUM(p.amount) <> 0;
+
+DELETE FROM ledger_entries e
+WHERE NOT EXISTS (SELECT 1 FROM ledger_postings p WHERE p.entry_id = e.id);
+
+UPDATE ledger_accounts a
+SET balance = COALESCE((SELECT SUM(p.amount) FROM ledger_postings p WHERE p.account_id = a.id), 0);
+
+-- Only the mint may go negative; NOT VALID so legacy overdrawn balances do not block the migration
+ALTER TABLE ledger_accounts
+    ADD CONSTRAINT ledger_accounts_balance_check CHECK (account_type = 'mint' OR balance >= 0) NOT VALID;
+
+-- Balances now live in the ledger
+ALTER TABLE classrooms DROP COLUMN available_neurons;
+ALTER TABLE users_classrooms DROP COLUMN neurons;
+
+-- Journal entries and postings are append-only; corrections are new entries
+CREATE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% rows are immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_immutable
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+CREATE TRIGGER ledger_postings_immutable
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
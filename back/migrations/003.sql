@@ -0,0 +1,3 @@
+-- Create indexes for transaction history keyset pagination
+CREATE INDEX idx_neuron_transactions_classroom_created_at ON neuron_transactions(classroom_id, created_at, id);
+CREATE INDEX idx_neuron_transactions_user_created_at ON neuron_transactions(user_id, created_at, id);
@@ -0,0 +1,14 @@
+-- Create table for Idempotency keys of retried requests
+CREATE TABLE idempotency_keys (
+    user_id TEXT NOT NULL REFERENCES auth_user(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    fingerprint TEXT NOT NULL,
+    response_status INTEGER,
+    content_type TEXT NOT NULL DEFAULT '',
+    response_body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
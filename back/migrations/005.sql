@@ -0,0 +1,10 @@
+-- Add reversal transactions linked to the transaction they compensate
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal'));
+
+ALTER TABLE neuron_transactions ADD COLUMN reverses_id INTEGER UNIQUE REFERENCES neuron_transactions(id);
+
+-- Record who performed each transaction and why
+ALTER TABLE neuron_transactions ADD COLUMN created_by INTEGER REFERENCES users(id);
+ALTER TABLE neuron_transactions ADD COLUMN note TEXT NOT NULL DEFAULT '';
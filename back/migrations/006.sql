@@ -0,0 +1,11 @@
+-- Create table for batches of transactions applied together by bulk operations
+CREATE TABLE neuron_transaction_batches (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE neuron_transactions ADD COLUMN batch_id INTEGER REFERENCES neuron_transaction_batches(id);
+
+CREATE INDEX idx_neuron_transactions_batch_id ON neuron_transactions(batch_id);
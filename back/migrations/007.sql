@@ -0,0 +1,13 @@
+-- Create table for teacher-defined transaction categories
+CREATE TABLE transaction_categories (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    name VARCHAR(100) NOT NULL,
+    default_amount INTEGER NOT NULL DEFAULT 0 CHECK (default_amount >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (classroom_id, name)
+);
+
+ALTER TABLE neuron_transactions ADD COLUMN category_id INTEGER REFERENCES transaction_categories(id);
+
+CREATE INDEX idx_neuron_transactions_category_id ON neuron_transactions(category_id);
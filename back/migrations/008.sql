@@ -0,0 +1,24 @@
+-- Create table for Rewards students can redeem with their neurons
+CREATE TABLE rewards (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cost INTEGER NOT NULL CHECK (cost > 0),
+    stock INTEGER CHECK (stock >= 0),
+    per_student_limit INTEGER CHECK (per_student_limit > 0),
+    available_from TIMESTAMP WITH TIME ZONE,
+    available_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rewards_classroom_id ON rewards(classroom_id);
+
+-- Add redemption transactions paying for a reward
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal', 'redemption'));
+
+ALTER TABLE neuron_transactions ADD COLUMN reward_id INTEGER REFERENCES rewards(id);
+
+CREATE INDEX idx_neuron_transactions_reward_id ON neuron_transactions(reward_id);
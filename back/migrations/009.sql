@@ -0,0 +1,39 @@
+-- Rewards may require the teacher's approval before neurons are spent
+ALTER TABLE rewards
+    ADD COLUMN requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN approval_ttl_hours INTEGER NOT NULL DEFAULT 72 CHECK (approval_ttl_hours > 0);
+
+-- Hold accounts keep a student's neurons reserved while a request is pending
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_account_type_check;
+ALTER TABLE ledger_accounts
+    ADD CONSTRAINT ledger_accounts_account_type_check CHECK (account_type IN ('mint', 'classroom', 'student', 'hold'));
+
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_check;
+ALTER TABLE ledger_accounts
+    ADD CONSTRAINT ledger_accounts_check CHECK ((account_type IN ('student', 'hold')) = (user_id IS NOT NULL));
+
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions
+    ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal', 'redemption', 'hold', 'release'));
+
+-- Create table for Redemption requests awaiting the teacher's decision
+CREATE TABLE redemption_requests (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    reward_id INTEGER NOT NULL REFERENCES rewards(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected', 'fulfilled', 'expired')),
+    hold_transaction_id INTEGER NOT NULL REFERENCES neuron_transactions(id),
+    redemption_transaction_id INTEGER REFERENCES neuron_transactions(id),
+    decided_by INTEGER REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    fulfilled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_redemption_requests_classroom_status ON redemption_requests(classroom_id, status);
+CREATE INDEX idx_redemption_requests_status_expires_at ON redemption_requests(status, expires_at);
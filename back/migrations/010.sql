@@ -0,0 +1,24 @@
+-- Create table for Classroom join codes students use to enroll themselves
+CREATE TABLE classroom_join_codes (
+    classroom_id INTEGER PRIMARY KEY REFERENCES classrooms(id),
+    code VARCHAR(16) NOT NULL UNIQUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    max_uses INTEGER CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create table for Enrollment requests waiting for the teacher's approval
+CREATE TABLE enrollment_requests (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A student can only have one pending request per classroom
+CREATE UNIQUE INDEX idx_enrollment_requests_pending ON enrollment_requests(classroom_id, user_id) WHERE status = 'pending';
+CREATE INDEX idx_enrollment_requests_user_id ON enrollment_requests(user_id);
@@ -0,0 +1,7 @@
+-- Record the teacher's decision on enrollment requests
+ALTER TABLE enrollment_requests
+    ADD COLUMN reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN decided_by INTEGER REFERENCES users(id),
+    ADD COLUMN decided_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_enrollment_requests_classroom_status ON enrollment_requests(classroom_id, status);
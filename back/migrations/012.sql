@@ -0,0 +1,23 @@
+-- Create table for Classroom staff: the owner, co-teachers and assistants
+CREATE TABLE classroom_staff (
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'co_teacher', 'assistant')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('invited', 'active')),
+    daily_send_limit INTEGER CHECK (daily_send_limit >= 0),
+    invited_by INTEGER REFERENCES users(id),
+    joined_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (classroom_id, user_id)
+);
+
+-- Each classroom has exactly one owner, kept in sync with classrooms.teacher_id
+CREATE UNIQUE INDEX idx_classroom_staff_owner ON classroom_staff(classroom_id) WHERE role = 'owner';
+CREATE INDEX idx_classroom_staff_user_id ON classroom_staff(user_id);
+
+-- Daily send limits sum a staff member's assignments
+CREATE INDEX idx_neuron_transactions_created_by ON neuron_transactions(classroom_id, created_by, created_at);
+
+-- The current teachers own their classrooms
+INSERT INTO classroom_staff (classroom_id, user_id, role, status, joined_at, created_at)
+SELECT id, teacher_id, 'owner', 'active', created_at, created_at FROM classrooms;
@@ -0,0 +1,70 @@
+-- Create table for Organizations (schools) that own users and classrooms
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    default_classroom_neurons INTEGER NOT NULL DEFAULT 0 CHECK (default_classroom_neurons >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create table for the email domains an organization accepts sign-ups from
+CREATE TABLE organization_email_domains (
+    domain VARCHAR(255) PRIMARY KEY CHECK (domain = LOWER(domain)),
+    organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_organization_email_domains_organization_id ON organization_email_domains(organization_id);
+
+-- Existing users and classrooms move into a default organization
+INSERT INTO organizations (name) VALUES ('Default');
+
+ALTER TABLE users
+    ADD COLUMN organization_id INTEGER REFERENCES organizations(id),
+    ADD COLUMN org_admin BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET organization_id = (SELECT MIN(id) FROM organizations);
+ALTER TABLE users ALTER COLUMN organization_id SET NOT NULL;
+
+ALTER TABLE classrooms ADD COLUMN organization_id INTEGER REFERENCES organizations(id);
+UPDATE classrooms c SET organization_id = u.organization_id FROM users u WHERE u.id = c.teacher_id;
+ALTER TABLE classrooms ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX idx_users_organization_id ON users(organization_id);
+CREATE INDEX idx_classrooms_organization_id ON classrooms(organization_id);
+
+-- Users sign up outside the API, so new users are placed in an organization here:
+-- the one that claims their email domain, otherwise the oldest organization
+-- without domain restrictions. Signing up into an organization that restricts
+-- domains requires one of its domains.
+CREATE FUNCTION assign_user_organization() RETURNS trigger AS $$
+DECLARE
+    email_domain TEXT := LOWER(SPLIT_PART(NEW.email, '@', 2));
+BEGIN
+    IF NEW.organization_id IS NULL THEN
+        SELECT organization_id INTO NEW.organization_id
+        FROM organization_email_domains
+        WHERE domain = email_domain;
+    END IF;
+
+    IF NEW.organization_id IS NULL THEN
+        SELECT o.id INTO NEW.organization_id
+        FROM organizations o
+        WHERE NOT EXISTS (SELECT 1 FROM organization_email_domains d WHERE d.organization_id = o.id)
+        ORDER BY o.id
+        LIMIT 1;
+    END IF;
+
+    IF NEW.organization_id IS NULL THEN
+        RAISE EXCEPTION 'no organization accepts sign-ups from %', email_domain;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM organization_email_domains WHERE organization_id = NEW.organization_id)
+       AND NOT EXISTS (SELECT 1 FROM organization_email_domains WHERE organization_id = NEW.organization_id AND domain = email_domain) THEN
+        RAISE EXCEPTION 'organization does not accept sign-ups from %', email_domain;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_assign_organization
+    BEFORE INSERT ON users
+    FOR EACH ROW EXECUTE FUNCTION assign_user_organization();
@@ -0,0 +1,26 @@
+-- Admins run the whole deployment
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('teacher', 'student', 'admin'));
+
+-- Deactivated users can't sign in
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP WITH TIME ZONE;
+
+-- Create table for the Audit log
+CREATE TABLE audit_log (
+    id SERIAL PRIMARY KEY,
+    actor_id INTEGER REFERENCES users(id),
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id INTEGER NOT NULL,
+    details JSONB NOT NULL DEFAULT 'null',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_target ON audit_log(target_type, target_id);
+CREATE INDEX idx_audit_log_actor_id ON audit_log(actor_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- The audit log is append-only
+CREATE TRIGGER audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
@@ -0,0 +1,10 @@
+-- Audit entries record the state of the target before and after the change,
+-- the classroom it belongs to and the request it was made in
+ALTER TABLE audit_log RENAME COLUMN details TO diff;
+ALTER TABLE audit_log ADD COLUMN before JSONB NOT NULL DEFAULT 'null';
+ALTER TABLE audit_log ADD COLUMN after JSONB NOT NULL DEFAULT 'null';
+ALTER TABLE audit_log ADD COLUMN classroom_id INTEGER;
+ALTER TABLE audit_log ADD COLUMN request_id VARCHAR(100);
+ALTER TABLE audit_log ADD COLUMN ip INET;
+
+CREATE INDEX idx_audit_log_classroom_id ON audit_log(classroom_id, created_at);
@@ -0,0 +1,16 @@
+-- Archived classrooms are read-only and hidden from listings until restored
+ALTER TABLE classrooms ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_classrooms_archived_at ON classrooms(archived_at) WHERE archived_at IS NOT NULL;
+
+-- Purging an archived classroom deletes its ledger. It sets neurons.purging
+-- for the duration of its transaction; the audit log stays immutable.
+CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND TG_TABLE_NAME <> 'audit_log'
+       AND current_setting('neurons.purging', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION '% rows are immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
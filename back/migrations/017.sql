@@ -0,0 +1,16 @@
+-- Create table for groups of students within a classroom
+CREATE TABLE student_groups (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (classroom_id, name)
+);
+
+CREATE TABLE student_group_members (
+    group_id INTEGER NOT NULL REFERENCES student_groups(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX idx_student_group_members_user_id ON student_group_members(user_id);
@@ -0,0 +1,21 @@
+-- Student groups share a wallet in the ledger
+ALTER TABLE ledger_accounts ADD COLUMN group_id INTEGER REFERENCES student_groups(id);
+
+ALTER TABLE ledger_accounts DROP CONSTRAINT ledger_accounts_account_type_check;
+ALTER TABLE ledger_accounts
+    ADD CONSTRAINT ledger_accounts_account_type_check CHECK (account_type IN ('mint', 'classroom', 'student', 'hold', 'group'));
+ALTER TABLE ledger_accounts
+    ADD CONSTRAINT ledger_accounts_group_check CHECK ((account_type = 'group') = (group_id IS NOT NULL));
+
+DROP INDEX idx_ledger_accounts_owner;
+CREATE UNIQUE INDEX idx_ledger_accounts_owner ON ledger_accounts(classroom_id, account_type, COALESCE(user_id, 0), COALESCE(group_id, 0));
+
+-- Transactions with a group wallet name the group as the counterparty
+ALTER TABLE neuron_transactions ADD COLUMN group_id INTEGER REFERENCES student_groups(id);
+
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions
+    ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal', 'redemption', 'hold', 'release', 'group_funding', 'contribution'));
+
+CREATE INDEX idx_neuron_transactions_group_id ON neuron_transactions(group_id) WHERE group_id IS NOT NULL;
@@ -0,0 +1,41 @@
+-- Create table for each classroom's rules on students gifting neurons to each other
+CREATE TABLE classroom_gift_settings (
+    classroom_id INTEGER PRIMARY KEY REFERENCES classrooms(id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    daily_cap INTEGER CHECK (daily_cap > 0),
+    max_per_gift INTEGER CHECK (max_per_gift > 0),
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Gifts move neurons from one student to another, named as the recipient
+ALTER TABLE neuron_transactions ADD COLUMN recipient_id INTEGER REFERENCES users(id);
+
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions
+    ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal', 'redemption', 'hold', 'release', 'group_funding', 'contribution', 'gift'));
+
+CREATE INDEX idx_neuron_transactions_recipient_id ON neuron_transactions(recipient_id) WHERE recipient_id IS NOT NULL;
+
+-- Create table for Gifts, including those waiting for the teacher's approval
+CREATE TABLE gifts (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    sender_id INTEGER NOT NULL REFERENCES users(id),
+    recipient_id INTEGER NOT NULL REFERENCES users(id),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    note TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'given', 'rejected')),
+    hold_transaction_id INTEGER REFERENCES neuron_transactions(id),
+    transaction_id INTEGER REFERENCES neuron_transactions(id),
+    decided_by INTEGER REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (sender_id <> recipient_id)
+);
+
+CREATE INDEX idx_gifts_classroom_status ON gifts(classroom_id, status);
+-- Daily caps sum a sender's gifts
+CREATE INDEX idx_gifts_sender ON gifts(classroom_id, sender_id, created_at);
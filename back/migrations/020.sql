@@ -0,0 +1,36 @@
+-- Create table for the neuron budgets of teachers and organizations that
+-- classroom pools are drawn from
+CREATE TABLE budgets (
+    id SERIAL PRIMARY KEY,
+    organization_id INTEGER NOT NULL REFERENCES organizations(id),
+    user_id INTEGER REFERENCES users(id),
+    balance INTEGER NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    allocation_amount INTEGER NOT NULL DEFAULT 0 CHECK (allocation_amount >= 0),
+    allocation_period VARCHAR(20) NOT NULL DEFAULT '' CHECK (allocation_period IN ('', 'weekly', 'monthly')),
+    next_allocation_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One budget per organization and one per teacher
+CREATE UNIQUE INDEX idx_budgets_organization ON budgets(organization_id) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX idx_budgets_user ON budgets(user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_budgets_next_allocation_at ON budgets(next_allocation_at) WHERE allocation_amount > 0;
+
+-- Create table for the history of each budget. classroom_id has no foreign
+-- key so the history survives classroom purges.
+CREATE TABLE budget_transactions (
+    id SERIAL PRIMARY KEY,
+    budget_id INTEGER NOT NULL REFERENCES budgets(id),
+    classroom_id INTEGER,
+    amount INTEGER NOT NULL CHECK (amount <> 0),
+    transaction_type VARCHAR(20) NOT NULL CHECK (transaction_type IN ('mint', 'allocate', 'deallocate')),
+    created_by INTEGER REFERENCES users(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_budget_transactions_budget_id ON budget_transactions(budget_id, created_at);
+
+CREATE TRIGGER budget_transactions_immutable
+    BEFORE UPDATE OR DELETE ON budget_transactions
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
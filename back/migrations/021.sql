@@ -0,0 +1,32 @@
+-- Create table for allowances paid to students on a schedule
+CREATE TABLE allowance_rules (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    name VARCHAR(100) NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    schedule VARCHAR(100) NOT NULL,
+    group_id INTEGER REFERENCES student_groups(id),
+    category_id INTEGER REFERENCES transaction_categories(id),
+    note TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_allowance_rules_classroom_id ON allowance_rules(classroom_id);
+CREATE INDEX idx_allowance_rules_next_run_at ON allowance_rules(next_run_at) WHERE next_run_at IS NOT NULL;
+
+-- Each scheduled run of a rule is recorded once, so restarts and other
+-- replicas can't pay it twice
+CREATE TABLE allowance_runs (
+    id SERIAL PRIMARY KEY,
+    rule_id INTEGER NOT NULL REFERENCES allowance_rules(id),
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('paid', 'failed')),
+    batch_id INTEGER REFERENCES neuron_transaction_batches(id),
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (rule_id, scheduled_for)
+);
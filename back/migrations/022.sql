@@ -0,0 +1,31 @@
+-- Create table for each classroom's rules on neuron expiry and the end of term
+CREATE TABLE classroom_neuron_policies (
+    classroom_id INTEGER PRIMARY KEY REFERENCES classrooms(id),
+    expires_after_days INTEGER CHECK (expires_after_days > 0),
+    term_end_action VARCHAR(20) NOT NULL DEFAULT 'carry_over' CHECK (term_end_action IN ('carry_over', 'reset', 'convert')),
+    conversion_percent INTEGER NOT NULL DEFAULT 100 CHECK (conversion_percent BETWEEN 0 AND 100),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Expired neurons go back to the classroom pool
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions
+    ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal', 'redemption', 'hold', 'release', 'group_funding', 'contribution', 'gift', 'expiry'));
+
+-- Create table for the lots of neurons granted while expiry is on. Students
+-- spend their oldest lots first.
+CREATE TABLE neuron_lots (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    transaction_id INTEGER NOT NULL REFERENCES neuron_transactions(id),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expired_amount INTEGER NOT NULL DEFAULT 0 CHECK (expired_amount >= 0),
+    processed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_neuron_lots_user ON neuron_lots(classroom_id, user_id) WHERE processed_at IS NULL;
+CREATE INDEX idx_neuron_lots_expires_at ON neuron_lots(expires_at) WHERE processed_at IS NULL;
@@ -0,0 +1,13 @@
+-- Deductions take neurons from a student back to the classroom pool
+ALTER TABLE neuron_transactions DROP CONSTRAINT neuron_transactions_transaction_type_check;
+ALTER TABLE neuron_transactions
+    ADD CONSTRAINT neuron_transactions_transaction_type_check
+    CHECK (transaction_type IN ('assignment', 'return', 'reversal', 'redemption', 'hold', 'release', 'group_funding', 'contribution', 'gift', 'expiry', 'deduction'));
+
+-- The direction of a transaction comes from its type, never from the sign of
+-- its amount. Rows recorded before this rule are left as they are.
+ALTER TABLE neuron_transactions
+    ADD CONSTRAINT neuron_transactions_amount_check CHECK (amount > 0) NOT VALID;
+
+-- Whether deductions may take a student's balance below zero
+ALTER TABLE classroom_neuron_policies ADD COLUMN allow_negative_balance BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,43 @@
+-- Create table for badges. Badges without a classroom are system badges that
+-- every classroom offers; badges without a rule are only awarded by hand.
+CREATE TABLE badges (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER REFERENCES classrooms(id),
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    icon VARCHAR(100) NOT NULL DEFAULT '',
+    rule_metric VARCHAR(20) CHECK (rule_metric IN ('count', 'amount')),
+    rule_transaction_type VARCHAR(20),
+    rule_category_id INTEGER REFERENCES transaction_categories(id),
+    rule_threshold INTEGER CHECK (rule_threshold > 0),
+    rule_window_days INTEGER CHECK (rule_window_days > 0),
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((rule_metric IS NULL) = (rule_transaction_type IS NULL)),
+    CHECK ((rule_metric IS NULL) = (rule_threshold IS NULL))
+);
+
+CREATE INDEX idx_badges_classroom_id ON badges(classroom_id);
+
+-- A student earns each badge at most once per classroom
+CREATE TABLE badge_awards (
+    id SERIAL PRIMARY KEY,
+    badge_id INTEGER NOT NULL REFERENCES badges(id),
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    transaction_id INTEGER REFERENCES neuron_transactions(id),
+    awarded_by INTEGER REFERENCES users(id),
+    note TEXT NOT NULL DEFAULT '',
+    awarded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (badge_id, classroom_id, user_id)
+);
+
+CREATE INDEX idx_badge_awards_classroom_id ON badge_awards(classroom_id);
+CREATE INDEX idx_badge_awards_user_id ON badge_awards(user_id);
+
+-- System badges
+INSERT INTO badges (name, description, icon, rule_metric, rule_transaction_type, rule_threshold) VALUES
+    ('First Neurons', 'Received neurons for the first time', 'spark', 'count', 'assignment', 1),
+    ('Centurion', 'Earned 100 neurons in total', 'hundred', 'amount', 'assignment', 100),
+    ('First Redemption', 'Redeemed a reward for the first time', 'gift', 'count', 'redemption', 1),
+    ('Generous', 'Gave 5 gifts to classmates', 'heart', 'count', 'gift', 5);
@@ -0,0 +1,4 @@
+-- Redemption requests whose redemption transaction was reversed are marked as refunded
+ALTER TABLE redemption_requests DROP CONSTRAINT redemption_requests_status_check;
+ALTER TABLE redemption_requests ADD CONSTRAINT redemption_requests_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'fulfilled', 'expired', 'refunded'));
@@ -0,0 +1,30 @@
+-- Lots track how much of them has been spent. Every debit of a student's
+-- wallet spends their lots soonest to expire first; neurons credited outside
+-- of a lot never expire.
+ALTER TABLE neuron_lots ADD COLUMN spent INTEGER NOT NULL DEFAULT 0;
+
+-- Open lots are marked as spent where the student's balance, neurons on hold
+-- included, no longer covers them. Lots of reversed assignments are spent.
+WITH held AS (
+    SELECT classroom_id, user_id, SUM(balance) AS balance
+    FROM ledger_accounts
+    WHERE account_type IN ('student', 'hold')
+    GROUP BY classroom_id, user_id
+), lots AS (
+    SELECT l.id, l.amount,
+           SUM(l.amount) OVER (PARTITION BY l.classroom_id, l.user_id ORDER BY l.expires_at DESC, l.id DESC) AS running,
+           COALESCE(h.balance, 0) AS balance,
+           EXISTS (SELECT 1 FROM neuron_transactions r WHERE r.reverses_id = l.transaction_id) AS reversed
+    FROM neuron_lots l
+    LEFT JOIN held h ON h.classroom_id = l.classroom_id AND h.user_id = l.user_id
+    WHERE l.processed_at IS NULL
+)
+UPDATE neuron_lots l
+SET spent = CASE
+        WHEN lots.reversed THEN lots.amount
+        ELSE lots.amount - LEAST(lots.amount, GREATEST(0, lots.balance - (lots.running - lots.amount)))
+    END
+FROM lots
+WHERE l.id = lots.id;
+
+ALTER TABLE neuron_lots ADD CONSTRAINT neuron_lots_spent_check CHECK (spent >= 0 AND spent <= amount);